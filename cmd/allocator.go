@@ -0,0 +1,63 @@
+package main
+
+import "fmt"
+
+const (
+	allocSlots   = "slots"   // tradingCapital split evenly over defaultMaxPositions
+	allocDynamic = "dynamic" // free capital split over the position slots still open
+)
+
+var (
+	// tradingCapital is the capital shared by every position. Entries are
+	// sized by the allocator so that positions and pending entries never
+	// tie up more than this; 0 sizes each entry at defaultBudget instead.
+	tradingCapital = 0.0
+	allocMode      = allocSlots
+)
+
+func setAllocMode(s string) error {
+	switch s {
+	case allocSlots, allocDynamic:
+		allocMode = s
+		return nil
+	}
+	return fmt.Errorf("unknown allocation %q (want %s or %s)", s, allocSlots, allocDynamic)
+}
+
+// deployedCapital is the capital tied up in open positions and pending
+// entries, and how many position slots those take.
+func deployedCapital() (deployed float64, slots int) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, pos := range longPositions {
+		deployed += pos.Capital
+	}
+	for _, pos := range shortPositions {
+		deployed += pos.Capital
+	}
+	for _, p := range pendingEntries {
+		deployed += p.restNotional() / max(p.Leverage, 1)
+	}
+	return deployed, len(longPositions) + len(shortPositions) + len(pendingEntries)
+}
+
+// entryBudget is the capital, before leverage, for an entry of the given
+// signal strength. With tradingCapital set it is the allocator's share,
+// scaled by strength but never more than the capital still free.
+func entryBudget(strength float64) float64 {
+	if tradingCapital <= 0 {
+		return defaultBudget * strengthScale(strength)
+	}
+
+	deployed, used := deployedCapital()
+	free := tradingCapital - deployed
+	if free <= 0 {
+		return 0
+	}
+	share := tradingCapital / float64(max(defaultMaxPositions, 1))
+	if allocMode == allocDynamic {
+		share = free / float64(max(defaultMaxPositions-used, 1))
+	}
+	return min(share*strengthScale(strength), free)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+func TestDeployedCapitalAccounting(t *testing.T) {
+	resetBooks(t)
+
+	openLong("AAA", entrySource{}, 100, 100, 200, 1, client.ProductCNC)
+	openShort("BBB", entrySource{}, 50, 50, 400, 5, client.ProductMIS)
+	pendingEntries["X-1"] = pendingOrder{ID: "X-1", Symbol: "CCC", Qty: 10, Limit: 100, Leverage: 2}
+
+	check := func(step string, want float64, wantSlots int) {
+		t.Helper()
+		got, slots := deployedCapital()
+		if math.Abs(got-want) > 1e-6 || slots != wantSlots {
+			t.Errorf("%s: deployed = %.2f in %d slots, want %.2f in %d", step, got, slots, want, wantSlots)
+		}
+	}
+	check("after entries", 20000+4000+500, 3)
+
+	openLong("AAA", entrySource{}, 110, 110, 100, 1, client.ProductCNC)
+	check("after adding to AAA", 31000+4000+500, 3)
+
+	bookLongExit("AAA", 120, 150, ReasonManual)
+	check("after half of AAA exits", 15500+4000+500, 3)
+
+	bookShortExit("BBB", 45, 400, ReasonManual)
+	delete(pendingEntries, "X-1")
+	check("after BBB exits", 15500, 1)
+
+	bookLongExit("AAA", 120, 150, ReasonManual)
+	check("flat", 0, 0)
+}
+
+func TestEntryBudgetAllocation(t *testing.T) {
+	resetBooks(t)
+	defer func(c float64, mode string, n int) {
+		tradingCapital, allocMode, defaultMaxPositions = c, mode, n
+	}(tradingCapital, allocMode, defaultMaxPositions)
+	tradingCapital, defaultMaxPositions = 100000, 4
+
+	allocMode = allocSlots
+	if got := entryBudget(1); got != 25000 {
+		t.Errorf("slots budget = %.2f, want 25000", got)
+	}
+	openLong("AAA", entrySource{}, 100, 100, 400, 1, client.ProductCNC)
+	openLong("BBB", entrySource{}, 100, 100, 100, 1, client.ProductCNC)
+	if got := entryBudget(1); got != 25000 {
+		t.Errorf("slots budget with 50000 deployed = %.2f, want 25000", got)
+	}
+
+	allocMode = allocDynamic
+	if got := entryBudget(1); got != 25000 {
+		t.Errorf("dynamic budget = %.2f, want 50000 free over 2 open slots", got)
+	}
+
+	openLong("CCC", entrySource{}, 100, 100, 400, 1, client.ProductCNC)
+	if got := entryBudget(1); got != 10000 {
+		t.Errorf("dynamic budget = %.2f, want the 10000 left", got)
+	}
+	allocMode = allocSlots
+	if got := entryBudget(1); got != 10000 {
+		t.Errorf("slots budget = %.2f, want capped at the 10000 left", got)
+	}
+
+	openLong("DDD", entrySource{}, 100, 100, 100, 1, client.ProductCNC)
+	if got := entryBudget(1); got != 0 {
+		t.Errorf("budget with all capital deployed = %.2f, want 0", got)
+	}
+	enterLong("EEE", entrySource{}, 100, 1, 1)
+	if hasPosition("EEE", models.Long) {
+		t.Error("entered with no capital free")
+	}
+
+	tradingCapital = 0
+	if got := entryBudget(1); got != defaultBudget {
+		t.Errorf("budget without -capital = %.2f, want -budget %.2f", got, defaultBudget)
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// strategyLabel names t's entry strategy for grouping; trades logged
+// before the strategy was recorded fall under "unknown".
+func strategyLabel(t TradeRecord) string {
+	if t.Strategy == "" {
+		return "unknown"
+	}
+	return t.Strategy
+}
+
+// hasTag reports whether t carries tag.
+func (t TradeRecord) hasTag(tag string) bool {
+	return slices.Contains(t.Tags, tag)
+}
+
+// filterTag returns the trades carrying tag, or all of them when tag is
+// empty.
+func filterTag(trades []TradeRecord, tag string) []TradeRecord {
+	if tag == "" {
+		return trades
+	}
+	var out []TradeRecord
+	for _, t := range trades {
+		if t.hasTag(tag) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// strategySummary is one entry strategy's line in the daily summary.
+type strategySummary struct {
+	Strategy string  `json:"strategy"`
+	Trades   int     `json:"trades"`
+	PnL      float64 `json:"pnl"`
+}
+
+// summarizeStrategies totals trades by entry strategy, worst P&L first so
+// the strategies worth pruning lead the summary.
+func summarizeStrategies(trades []TradeRecord) []strategySummary {
+	var out []strategySummary
+	idx := make(map[string]int)
+	for _, t := range trades {
+		name := strategyLabel(t)
+		i, ok := idx[name]
+		if !ok {
+			i = len(out)
+			idx[name] = i
+			out = append(out, strategySummary{Strategy: name})
+		}
+		out[i].Trades++
+		out[i].PnL += t.PnL
+	}
+	slices.SortStableFunc(out, func(a, b strategySummary) int { return cmp.Compare(a.PnL, b.PnL) })
+	return out
+}
+
+// tradeStats aggregates closed trades for one group.
+type tradeStats struct {
+	Trades   int
+	Wins     int
+	Losses   int
+	TotalPnL float64
+	WinPnL   float64
+	LossPnL  float64 // sum of losing P&L, negative
+}
+
+func (s *tradeStats) add(pnl float64) {
+	s.Trades++
+	s.TotalPnL += pnl
+	switch {
+	case pnl > 0:
+		s.Wins++
+		s.WinPnL += pnl
+	case pnl < 0:
+		s.Losses++
+		s.LossPnL += pnl
+	}
+}
+
+func (s tradeStats) WinRate() float64 {
+	if s.Trades == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Trades) * 100
+}
+
+func (s tradeStats) AvgWin() float64 {
+	if s.Wins == 0 {
+		return 0
+	}
+	return s.WinPnL / float64(s.Wins)
+}
+
+func (s tradeStats) AvgLoss() float64 {
+	if s.Losses == 0 {
+		return 0
+	}
+	return s.LossPnL / float64(s.Losses)
+}
+
+// Expectancy is the average P&L per trade.
+func (s tradeStats) Expectancy() float64 {
+	if s.Trades == 0 {
+		return 0
+	}
+	return s.TotalPnL / float64(s.Trades)
+}
+
+// dayStats is the win/loss breakdown in the daily summary.
+type dayStats struct {
+	tradeStats
+	Best, Worst TradeRecord // by P&L; the first such trade on a tie
+}
+
+// summarizeDay computes dayStats over trades. P&L is rounded to the
+// displayed precision first, so near-zero trades count as neither wins
+// nor losses.
+func summarizeDay(trades []TradeRecord) dayStats {
+	var d dayStats
+	for i, t := range trades {
+		d.add(roundMoney(t.PnL))
+		if i == 0 || t.PnL > d.Best.PnL {
+			d.Best = t
+		}
+		if i == 0 || t.PnL < d.Worst.PnL {
+			d.Worst = t
+		}
+	}
+	return d
+}
+
+// holdBuckets are the upper bounds of the hold-time histogram; the last
+// bucket is open-ended.
+var holdBuckets = []struct {
+	Label string
+	Max   time.Duration
+}{
+	{"< 5m", 5 * time.Minute},
+	{"5-15m", 15 * time.Minute},
+	{"15-60m", time.Hour},
+	{"1-3h", 3 * time.Hour},
+	{"> 3h", 0},
+}
+
+func holdBucket(d time.Duration) int {
+	for i, b := range holdBuckets {
+		if b.Max == 0 || d < b.Max {
+			return i
+		}
+	}
+	return len(holdBuckets) - 1
+}
+
+// tradeAnalysis is the result of analyzeTrades.
+type tradeAnalysis struct {
+	Overall    tradeStats
+	ByStrategy map[string]*tradeStats
+	BySymbol   map[string]*tradeStats
+	ByReason   map[string]*tradeStats // keyed by the reason's label
+	ByTag      map[string]*tradeStats // a trade counts once under each of its tags
+	Holds      []int                  // count per holdBuckets entry
+}
+
+// analyzeTrades aggregates trades that exited within [from, to).
+func analyzeTrades(trades []TradeRecord, from, to time.Time) tradeAnalysis {
+	a := tradeAnalysis{
+		ByStrategy: make(map[string]*tradeStats),
+		BySymbol:   make(map[string]*tradeStats),
+		ByReason:   make(map[string]*tradeStats),
+		ByTag:      make(map[string]*tradeStats),
+		Holds:      make([]int, len(holdBuckets)),
+	}
+	group := func(m map[string]*tradeStats, key string) *tradeStats {
+		s, ok := m[key]
+		if !ok {
+			s = &tradeStats{}
+			m[key] = s
+		}
+		return s
+	}
+
+	for _, t := range trades {
+		if t.ExitTime.Before(from) || !t.ExitTime.Before(to) {
+			continue
+		}
+		a.Overall.add(t.PnL)
+		group(a.ByStrategy, strategyLabel(t)).add(t.PnL)
+		group(a.BySymbol, t.Symbol).add(t.PnL)
+		group(a.ByReason, parseExitReason(string(t.Reason)).String()).add(t.PnL)
+		for _, tag := range t.Tags {
+			group(a.ByTag, tag).add(t.PnL)
+		}
+		if !t.EntryTime.IsZero() {
+			a.Holds[holdBucket(t.ExitTime.Sub(t.EntryTime))]++
+		}
+	}
+	return a
+}
+
+// loadTradeLogs reads every trades.jsonl file matching pattern. Lines that
+// do not parse are skipped with a warning so one bad write cannot hide a
+// whole log.
+func loadTradeLogs(pattern string) ([]TradeRecord, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []TradeRecord
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		sc := bufio.NewScanner(f)
+		for n := 1; sc.Scan(); n++ {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" {
+				continue
+			}
+			var t TradeRecord
+			if err := json.Unmarshal([]byte(line), &t); err != nil {
+				fmt.Fprintf(os.Stderr, "%s:%d: skipping bad line: %v\n", path, n, err)
+				continue
+			}
+			trades = append(trades, t)
+		}
+		err = sc.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return trades, nil
+}
+
+// printAnalysis writes a as a plain-text report.
+func printAnalysis(w io.Writer, a tradeAnalysis) {
+	fmt.Fprintf(w, "Trades: %d  Win rate: %.1f%%  Net P&L: %s  Expectancy: %s\n\n",
+		a.Overall.Trades, a.Overall.WinRate(), money(a.Overall.TotalPnL), money(a.Overall.Expectancy()))
+
+	printGroups(w, "STRATEGY", a.ByStrategy)
+	printGroups(w, "SYMBOL", a.BySymbol)
+	printGroups(w, "EXIT REASON", a.ByReason)
+	if len(a.ByTag) > 0 {
+		printGroups(w, "TAG", a.ByTag)
+	}
+
+	fmt.Fprintln(w, "Hold time:")
+	for i, b := range holdBuckets {
+		fmt.Fprintf(w, "  %-8s %d\n", b.Label, a.Holds[i])
+	}
+}
+
+// printGroups writes one table row per group, best expectancy first.
+func printGroups(w io.Writer, title string, groups map[string]*tradeStats) {
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(x, y string) int {
+		ex, ey := groups[x].Expectancy(), groups[y].Expectancy()
+		switch {
+		case ex > ey:
+			return -1
+		case ex < ey:
+			return 1
+		}
+		return strings.Compare(x, y)
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "%s\tTRADES\tWIN%%\tAVG WIN\tAVG LOSS\tEXPECTANCY\tNET\t\n", title)
+	for _, k := range keys {
+		s := groups[k]
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%s\t%s\t%s\t%s\t\n",
+			k, s.Trades, s.WinRate(), plainMoney(s.AvgWin()), plainMoney(s.AvgLoss()), plainMoney(s.Expectancy()), plainMoney(s.TotalPnL))
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+}
+
+// runAnalyze implements the `analyze` subcommand.
+func runAnalyze(args []string) error {
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	pattern := fs.String("files", "", "glob of trade logs to read (default trades*.jsonl in -log-dir)")
+	days := fs.Int("days", 30, "analyse trades that exited in the last N days")
+	fromFlag := fs.String("from", "", "start date YYYY-MM-DD (overrides -days)")
+	toFlag := fs.String("to", "", "end date YYYY-MM-DD, inclusive (default today)")
+	tag := fs.String("tag", "", "only analyse trades carrying this tag")
+	dirFlags(fs)
+	moneyFlags(fs)
+	fs.Parse(args)
+	if *pattern == "" {
+		*pattern = filepath.Join(logDir, "trades*.jsonl")
+	}
+
+	y, m, d := time.Now().Date()
+	to := time.Date(y, m, d+1, 0, 0, 0, 0, time.Local)
+	if *toFlag != "" {
+		d, err := time.ParseInLocation("2006-01-02", *toFlag, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid -to: %v", err)
+		}
+		to = d.AddDate(0, 0, 1)
+	}
+	from := to.AddDate(0, 0, -*days)
+	if *fromFlag != "" {
+		d, err := time.ParseInLocation("2006-01-02", *fromFlag, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid -from: %v", err)
+		}
+		from = d
+	}
+
+	trades, err := loadTradeLogs(*pattern)
+	if err != nil {
+		return err
+	}
+	a := analyzeTrades(filterTag(trades, *tag), from, to)
+	if a.Overall.Trades == 0 {
+		fmt.Printf("No trades in %s between %s and %s\n", *pattern, from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+		return nil
+	}
+
+	fmt.Printf("Trades %s → %s\n", from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+	printAnalysis(os.Stdout, a)
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeTrades(t *testing.T) {
+	day := time.Date(2024, 3, 4, 10, 0, 0, 0, time.Local)
+	trade := func(strat, sym string, pnl float64, hold time.Duration, reason ExitReason) TradeRecord {
+		return TradeRecord{Symbol: sym, EntryTime: day, ExitTime: day.Add(hold), PnL: pnl, Reason: reason, Strategy: strat}
+	}
+	trades := []TradeRecord{
+		trade("breakout_long", "SBIN", 300, 2*time.Minute, ReasonTarget),
+		trade("breakout_long", "SBIN", -100, 10*time.Minute, ReasonFixedSL),
+		trade("breakout_long", "INFY", 200, 30*time.Minute, "Target 2.0%"), // logged before reasons were typed
+		trade("quick_drop", "INFY", -150, 2*time.Hour, "Fixed SL 1.0%"),
+		trade("", "TCS", 50, 5*time.Hour, ReasonEOD),
+		// Outside the range.
+		trade("quick_drop", "TCS", 999, time.Minute, ReasonTarget),
+	}
+	trades[5].ExitTime = day.AddDate(0, 0, 5)
+
+	a := analyzeTrades(trades, day.Add(-10*time.Hour), day.AddDate(0, 0, 1))
+
+	if a.Overall.Trades != 5 || math.Abs(a.Overall.TotalPnL-300) > 1e-9 {
+		t.Fatalf("overall = %+v, want 5 trades netting 300", a.Overall)
+	}
+	bl := a.ByStrategy["breakout_long"]
+	if bl.Trades != 3 || bl.AvgWin() != 250 || bl.AvgLoss() != -100 {
+		t.Errorf("breakout_long = %+v (avg win %.2f, avg loss %.2f)", bl, bl.AvgWin(), bl.AvgLoss())
+	}
+	if got := bl.Expectancy(); math.Abs(got-400.0/3) > 1e-9 {
+		t.Errorf("breakout_long expectancy = %.4f", got)
+	}
+	if a.ByStrategy["unknown"] == nil {
+		t.Error("untagged trade not grouped under unknown")
+	}
+	if s := a.BySymbol["INFY"]; s.Trades != 2 || s.TotalPnL != 50 {
+		t.Errorf("INFY = %+v", s)
+	}
+	if a.ByReason["Target"].Trades != 2 || a.ByReason["Fixed SL"].TotalPnL != -250 || a.ByReason["EOD Square-off"].Trades != 1 {
+		t.Errorf("by reason: target %+v, fixed SL %+v", a.ByReason["Target"], a.ByReason["Fixed SL"])
+	}
+	if want := []int{1, 1, 1, 1, 1}; !slices.Equal(a.Holds, want) {
+		t.Errorf("holds = %v, want %v", a.Holds, want)
+	}
+}
+
+func TestTradeRecordsRoundTripThroughJSONL(t *testing.T) {
+	resetBooks(t)
+	now := time.Now()
+	logTradeRecord(TradeRecord{Symbol: "SBIN", Direction: "LONG", EntryTime: now.Add(-time.Minute), ExitTime: now, PnL: 12.5, Reason: ReasonTarget})
+
+	path := filepath.Join(logDir, "trades.jsonl")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("not json\n")
+	f.Close()
+
+	trades, err := loadTradeLogs(filepath.Join(logDir, "trades*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 1 || trades[0].Symbol != "SBIN" || trades[0].PnL != 12.5 || trades[0].Reason != ReasonTarget {
+		t.Fatalf("trades = %+v, want the one logged record", trades)
+	}
+}
+
+func TestParseExitReason(t *testing.T) {
+	for in, want := range map[string]ExitReason{
+		"fixed_sl":       ReasonFixedSL,
+		"Fixed SL 1.5%":  ReasonFixedSL,
+		"Bracket target": ReasonBracketTarget,
+		"Bracket SL":     ReasonBracketSL,
+		"Target 2.0%":    ReasonTarget,
+		"Max hold time":  ReasonMaxHold,
+		"something else": ReasonUnknown,
+	} {
+		if got := parseExitReason(in); got != want {
+			t.Errorf("parseExitReason(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestSummarizeStrategies(t *testing.T) {
+	got := summarizeStrategies([]TradeRecord{
+		{Strategy: "breakout_long", PnL: 300},
+		{Strategy: "quick_drop", PnL: -150},
+		{Strategy: "breakout_long", PnL: -100},
+		{PnL: 20},
+	})
+	want := []strategySummary{
+		{Strategy: "quick_drop", Trades: 1, PnL: -150},
+		{Strategy: "unknown", Trades: 1, PnL: 20},
+		{Strategy: "breakout_long", Trades: 2, PnL: 200},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("summarizeStrategies = %+v, want %+v", got, want)
+	}
+}
+
+func TestAnalyzeTradesByTag(t *testing.T) {
+	day := time.Date(2024, 3, 4, 10, 0, 0, 0, time.Local)
+	trades := []TradeRecord{
+		{Symbol: "SBIN", ExitTime: day, PnL: 100, Tags: []string{"gap-play", "earnings"}},
+		{Symbol: "INFY", ExitTime: day, PnL: -40, Tags: []string{"gap-play"}},
+		{Symbol: "TCS", ExitTime: day, PnL: 70},
+	}
+
+	a := analyzeTrades(trades, day.Add(-time.Hour), day.Add(time.Hour))
+	if gp := a.ByTag["gap-play"]; gp == nil || gp.Trades != 2 || gp.TotalPnL != 60 {
+		t.Errorf("gap-play = %+v, want 2 trades netting 60", gp)
+	}
+	if a.ByTag["earnings"].Trades != 1 || len(a.ByTag) != 2 {
+		t.Errorf("ByTag = %v, want earnings once and no untagged group", a.ByTag)
+	}
+
+	if got := filterTag(trades, "earnings"); len(got) != 1 || got[0].Symbol != "SBIN" {
+		t.Errorf("filterTag(earnings) = %+v, want only SBIN", got)
+	}
+	if got := filterTag(trades, ""); len(got) != 3 {
+		t.Errorf("filterTag with no tag kept %d trades, want all 3", len(got))
+	}
+}
+
+func TestSummarizeDay(t *testing.T) {
+	trades := []TradeRecord{
+		{Symbol: "AAA", Direction: "LONG", PnL: 300},
+		{Symbol: "BBB", Direction: "SHORT", PnL: -120},
+		{Symbol: "CCC", Direction: "LONG", PnL: 100},
+		{Symbol: "DDD", Direction: "LONG", PnL: -80},
+		{Symbol: "EEE", Direction: "SHORT", PnL: 0.001}, // shows as 0.00: flat
+	}
+	d := summarizeDay(trades)
+
+	if d.Trades != 5 || d.Wins != 2 || d.Losses != 2 {
+		t.Errorf("trades/wins/losses = %d/%d/%d, want 5/2/2", d.Trades, d.Wins, d.Losses)
+	}
+	if d.WinRate() != 40 {
+		t.Errorf("win rate = %.1f, want 40", d.WinRate())
+	}
+	if d.AvgWin() != 200 || d.AvgLoss() != -100 {
+		t.Errorf("avg win/loss = %.2f/%.2f, want 200/-100", d.AvgWin(), d.AvgLoss())
+	}
+	if d.Best.Symbol != "AAA" || d.Worst.Symbol != "BBB" {
+		t.Errorf("best %s, worst %s; want AAA and BBB", d.Best.Symbol, d.Worst.Symbol)
+	}
+
+	if d := summarizeDay(nil); d.Trades != 0 || d.WinRate() != 0 {
+		t.Errorf("empty day = %+v", d)
+	}
+}
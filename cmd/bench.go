@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/may-bach/Axiom/internal/notify"
+)
+
+var (
+	// maxConsecutiveLosses benches a symbol for the rest of the day after
+	// this many losing trades in a row on it; 0 disables. Symbols may set
+	// their own max_consecutive_losses.
+	maxConsecutiveLosses = 0
+
+	lossStreaks = make(map[string]int)  // losing trades in a row today, by symbol
+	benched     = make(map[string]bool) // symbols out of entries until the daily reset
+)
+
+// recordStreak updates trade's symbol's losing streak: a loss extends it, a
+// win ends it and a flat trade leaves it alone. The loss that reaches the
+// symbol's limit benches it. Exits are unaffected.
+func recordStreak(trade TradeRecord) {
+	limit := getStrategy(trade.Symbol).MaxConsecutiveLosses
+	pnl := roundMoney(trade.PnL)
+
+	mu.Lock()
+	switch {
+	case pnl > 0:
+		delete(lossStreaks, trade.Symbol)
+	case pnl < 0:
+		lossStreaks[trade.Symbol]++
+	}
+	streak := lossStreaks[trade.Symbol]
+	bench := limit > 0 && streak >= limit && !benched[trade.Symbol]
+	if bench {
+		benched[trade.Symbol] = true
+	}
+	mu.Unlock()
+
+	if bench {
+		notifyTrade(notify.EventError, fmt.Sprintf("%s BENCHED for the day after %d losing trades in a row", trade.Symbol, streak))
+	}
+}
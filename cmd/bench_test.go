@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+func TestLossStreakBenchesSymbol(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", SL: 0.01, Target: 0.02, Leverage: 1, BreakoutLong: 0.001, MaxConsecutiveLosses: 3,
+	}
+	loss := TradeRecord{Symbol: "TEST", Direction: "LONG", PnL: -50}
+
+	logTradeRecord(loss)
+	logTradeRecord(loss)
+	if n := lossStreaks["TEST"]; n != 2 {
+		t.Fatalf("streak = %d after two losses, want 2", n)
+	}
+
+	// A flat trade leaves the streak alone; a winner resets it.
+	logTradeRecord(TradeRecord{Symbol: "TEST", Direction: "LONG", PnL: 0.001})
+	if n := lossStreaks["TEST"]; n != 2 {
+		t.Errorf("streak = %d after a flat trade, want 2", n)
+	}
+	logTradeRecord(TradeRecord{Symbol: "TEST", Direction: "LONG", PnL: 80})
+	if n := lossStreaks["TEST"]; n != 0 {
+		t.Fatalf("streak = %d after a win, want 0", n)
+	}
+
+	// Other symbols keep their own streaks.
+	logTradeRecord(TradeRecord{Symbol: "OTHER", Direction: "SHORT", PnL: -10})
+	for i := 1; i <= 3; i++ {
+		if benched["TEST"] {
+			t.Fatalf("benched after %d losses, want 3", i-1)
+		}
+		logTradeRecord(loss)
+	}
+	if !benched["TEST"] || benched["OTHER"] {
+		t.Fatalf("benched = %v, want only TEST", benched)
+	}
+
+	markets["TEST"] = &state.MarketState{Symbol: "TEST", High: 100, Low: 95, Ticks: warmupTicks}
+	checkAllEntries("TEST", 101)
+	if hasPosition("TEST", models.Long) {
+		t.Error("benched symbol entered")
+	}
+
+	resetDay()
+	if benched["TEST"] || lossStreaks["OTHER"] != 0 {
+		t.Error("bench and streaks not cleared by the daily reset")
+	}
+	checkAllEntries("TEST", 101)
+	if !hasPosition("TEST", models.Long) {
+		t.Error("symbol still benched the next day")
+	}
+}
+
+func TestLossStreakDisabled(t *testing.T) {
+	resetBooks(t)
+	for range 10 {
+		logTradeRecord(TradeRecord{Symbol: "TEST", Direction: "LONG", PnL: -50})
+	}
+	if benched["TEST"] {
+		t.Error("benched with no loss limit set")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+func TestBracketPositionSkipsLocalExits(t *testing.T) {
+	resetBooks(t)
+	useBracketOrders = true
+	slippageBps["B"] = 0
+	t.Cleanup(func() { useBracketOrders = false; slippageBps["B"] = 5 })
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", SL: 0.01, Target: 0.02, Leverage: 1,
+		TrailPercent: 0.001, BreakEvenTrigger: 0.001, MaxHoldMinutes: 1,
+	}
+
+	enterLong("TEST", entrySource{}, 100, 1, 0)
+	mu.Lock()
+	pos, ok := longPositions["TEST"]
+	if ok {
+		pos.EntryTime = time.Now().Add(-time.Hour) // past max hold
+		longPositions["TEST"] = pos
+	}
+	mu.Unlock()
+	if !ok || pos.BracketOrder == "" {
+		t.Fatal("bracket entry did not open a bracket position")
+	}
+	if pos.BracketStop != 99 || pos.BracketTarget != 102 {
+		t.Fatalf("legs = SL %.2f target %.2f, want 99 / 102", pos.BracketStop, pos.BracketTarget)
+	}
+
+	// Trailing, break-even and max-hold would all fire here for a local position.
+	checkLongExit("TEST", 101.5)
+	checkLongExit("TEST", 100.2)
+	if !hasPosition("TEST", models.Long) {
+		t.Fatal("a local exit rule fired on a bracket position")
+	}
+
+	// Crossing a leg's price books nothing until the leg reports a fill.
+	checkLongExit("TEST", 98.9)
+	if !hasPosition("TEST", models.Long) {
+		t.Fatal("bracket SL booked from the last price alone")
+	}
+
+	matchPaperOrders("TEST", 98.9)
+	pollBracketPositions()
+	if hasPosition("TEST", models.Long) {
+		t.Fatal("filled bracket SL leg was not booked")
+	}
+	tr := lastTrade(t)
+	if tr.Reason != ReasonBracketSL || tr.ExitPrice != 98.9 {
+		t.Errorf("exit = %q @ %.2f, want Bracket SL @ 98.90", tr.Reason, tr.ExitPrice)
+	}
+	target, _, err := bracketLegs(pos.BracketOrder)
+	if err != nil || target.Status != client.StatusCancelled {
+		t.Errorf("target leg = %q (%v), want cancelled with the stop filled", target.Status, err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// brainCmd is the command that regenerates config.json: an interpreter
+	// and its arguments, run from dataDir. A bare interpreter name is looked
+	// up on PATH; relative paths are relative to dataDir. Empty runs
+	// "python brain.py".
+	brainCmd []string
+
+	brainEnv []string // KEY=VALUE pairs added to the brain command's environment
+)
+
+// brainCommand is brainCmd or its default.
+func brainCommand() []string {
+	if len(brainCmd) > 0 {
+		return brainCmd
+	}
+	return []string{"python", "brain.py"}
+}
+
+// checkBrainCommand fails if the brain command's executable cannot be
+// found, so a bad interpreter is reported at startup rather than on
+// every refresh.
+func checkBrainCommand() error {
+	name := brainCommand()[0]
+	if strings.ContainsRune(name, filepath.Separator) && !filepath.IsAbs(name) {
+		name = filepath.Join(dataDir, name)
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("brain command %q: %v (set -brain-cmd, e.g. \"python3 brain.py\")", name, err)
+	}
+	return nil
+}
+
+func setBrainCmd(v string) error {
+	brainCmd = strings.Fields(v)
+	if len(brainCmd) == 0 {
+		return fmt.Errorf("empty brain command")
+	}
+	return nil
+}
+
+func addBrainEnv(v string) error {
+	if k, _, ok := strings.Cut(v, "="); !ok || k == "" {
+		return fmt.Errorf("want KEY=VALUE, got %q", v)
+	}
+	brainEnv = append(brainEnv, v)
+	return nil
+}
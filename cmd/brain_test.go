@@ -0,0 +1,62 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBrainCommandFromFlags(t *testing.T) {
+	oldCmd, oldEnv := brainCmd, brainEnv
+	t.Cleanup(func() { brainCmd, brainEnv = oldCmd, oldEnv })
+	brainCmd, brainEnv = nil, nil
+
+	if got := strings.Join(brainCommand(), " "); got != "python brain.py" {
+		t.Errorf("default brain command = %q", got)
+	}
+	if err := setBrainCmd("  .venv/bin/python   brain.py --fast "); err != nil {
+		t.Fatal(err)
+	}
+	if got := brainCommand(); len(got) != 3 || got[0] != ".venv/bin/python" || got[2] != "--fast" {
+		t.Errorf("brain command = %q", got)
+	}
+	if err := setBrainCmd("   "); err == nil {
+		t.Error("empty brain command accepted")
+	}
+	if err := addBrainEnv("NOVALUE"); err == nil {
+		t.Error("brain env without = accepted")
+	}
+	if err := addBrainEnv("=x"); err == nil {
+		t.Error("brain env without a key accepted")
+	}
+}
+
+func TestCheckBrainCommand(t *testing.T) {
+	oldCmd := brainCmd
+	t.Cleanup(func() { brainCmd = oldCmd })
+
+	brainCmd = []string{"axiom-no-such-interpreter", "brain.py"}
+	if err := checkBrainCommand(); err == nil || !strings.Contains(err.Error(), "axiom-no-such-interpreter") {
+		t.Errorf("missing interpreter: err = %v", err)
+	}
+	brainCmd = []string{"sh", "brain.sh"}
+	if err := checkBrainCommand(); err != nil {
+		t.Errorf("sh not found: %v", err)
+	}
+}
+
+func TestRunBrainAndReloadUsesCommandAndEnv(t *testing.T) {
+	resetBooks(t)
+	oldCmd, oldEnv, oldConfig, oldDataDir := brainCmd, brainEnv, brainConfigPath, dataDir
+	t.Cleanup(func() { brainCmd, brainEnv, brainConfigPath, dataDir = oldCmd, oldEnv, oldConfig, oldDataDir })
+
+	dataDir = t.TempDir()
+	brainConfigPath = filepath.Join(dataDir, "config.json")
+	brainCmd = []string{"sh", "-c", `printf '{"%s": {"class": "A"}}' "$BRAIN_SYMBOL" > config.json`}
+	brainEnv = []string{"BRAIN_SYMBOL=TEST"}
+
+	runBrainAndReload()
+	if got := getStrategy("TEST").Class; got != "A" {
+		t.Errorf("TEST class = %q after the refresh, want A from the brain command", got)
+	}
+}
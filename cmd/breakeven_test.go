@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+func TestBreakEvenStop(t *testing.T) {
+	strat := models.StockStrategy{BreakEvenTrigger: 0.01, BreakEvenBuffer: 0.001}
+
+	tests := []struct {
+		name      string
+		fn        func(entry, best float64, s models.StockStrategy) (float64, bool)
+		best      float64
+		wantArmed bool
+		wantStop  float64
+	}{
+		{"long below trigger", breakEvenStopLong, 100.9, false, 0},
+		{"long at trigger", breakEvenStopLong, 101, true, 100.1},
+		{"long past trigger", breakEvenStopLong, 103, true, 100.1},
+		{"short above trigger", breakEvenStopShort, 99.1, false, 0},
+		{"short at trigger", breakEvenStopShort, 99, true, 99.9},
+		{"short past trigger", breakEvenStopShort, 97, true, 99.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stop, armed := tt.fn(100, tt.best, strat)
+			if armed != tt.wantArmed {
+				t.Fatalf("armed = %v, want %v", armed, tt.wantArmed)
+			}
+			if armed && math.Abs(stop-tt.wantStop) > 1e-9 {
+				t.Errorf("stop = %.4f, want %.4f", stop, tt.wantStop)
+			}
+		})
+	}
+
+	if _, armed := breakEvenStopLong(100, 110, models.StockStrategy{}); armed {
+		t.Error("break-even armed with a zero trigger")
+	}
+}
+
+func TestCheckLongExitBreakEven(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", SL: 0.02, Target: 0.05, BreakEvenTrigger: 0.01, TrailPercent: 0.5,
+	}
+	seedLong("TEST", 100, 10)
+
+	// Before the trigger, a dip below entry is only subject to the fixed SL.
+	checkLongExit("TEST", 99.5)
+	if !hasPosition("TEST", models.Long) {
+		t.Fatal("exited below entry before break-even armed")
+	}
+
+	checkLongExit("TEST", 101.2) // arms break-even
+	checkLongExit("TEST", 100.5)
+	if !hasPosition("TEST", models.Long) {
+		t.Fatal("exited above the break-even stop")
+	}
+	checkLongExit("TEST", 100)
+	if hasPosition("TEST", models.Long) {
+		t.Fatal("did not exit at break-even after the trigger")
+	}
+	if got := lastTrade(t).Reason; got != ReasonBreakEven {
+		t.Errorf("reason = %q, want Break-even SL", got)
+	}
+}
+
+func TestCheckShortExitBreakEven(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", SL: 0.02, Target: 0.05, BreakEvenTrigger: 0.01, BreakEvenBuffer: 0.002, TrailPercent: 0.5,
+	}
+	seedShort("TEST", 100, 10)
+
+	checkShortExit("TEST", 98.9) // arms break-even; stop at 99.8
+	checkShortExit("TEST", 99.7)
+	if !hasPosition("TEST", models.Short) {
+		t.Fatal("exited before the break-even stop")
+	}
+	checkShortExit("TEST", 99.8)
+	if hasPosition("TEST", models.Short) {
+		t.Fatal("did not exit at the break-even stop")
+	}
+	if got := lastTrade(t).Reason; got != ReasonBreakEven {
+		t.Errorf("reason = %q, want Break-even SL", got)
+	}
+}
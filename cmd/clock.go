@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ist = time.FixedZone("IST", 5*60*60+30*60)
+
+// nowIST is the current time in IST; replaced in tests.
+var nowIST = func() time.Time {
+	return time.Now().In(ist)
+}
+
+// parseClock parses "HH:MM" into minutes since midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// clockWindow is a time-of-day range in IST, from From up to but not
+// including To, both in minutes past midnight.
+type clockWindow struct {
+	From, To int
+}
+
+func (w clockWindow) String() string {
+	return formatClock(w.From) + "-" + formatClock(w.To)
+}
+
+// contains reports whether t's IST time of day falls in w.
+func (w clockWindow) contains(t time.Time) bool {
+	m := minuteOfDay(t.In(ist))
+	return m >= w.From && m < w.To
+}
+
+// parseWindows parses "HH:MM-HH:MM" ranges, each ending after it starts.
+func parseWindows(ranges []string) ([]clockWindow, error) {
+	var out []clockWindow
+	for _, r := range ranges {
+		from, to, ok := strings.Cut(strings.TrimSpace(r), "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid window %q, want HH:MM-HH:MM", r)
+		}
+		f, err := parseClock(strings.TrimSpace(from))
+		if err != nil {
+			return nil, err
+		}
+		t, err := parseClock(strings.TrimSpace(to))
+		if err != nil {
+			return nil, err
+		}
+		if t <= f {
+			return nil, fmt.Errorf("window %q ends before it starts", r)
+		}
+		out = append(out, clockWindow{f, t})
+	}
+	return out, nil
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
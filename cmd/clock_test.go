@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+func TestParseWindows(t *testing.T) {
+	got, err := parseWindows([]string{"09:30-11:30", " 13:00 - 14:30 "})
+	if err != nil || len(got) != 2 || got[0] != (clockWindow{570, 690}) || got[1].String() != "13:00-14:30" {
+		t.Errorf("parseWindows = %v, %v", got, err)
+	}
+	for _, bad := range []string{"09:30", "11:30-09:30", "09:30-09:30", "9-10", "09:30-25:00"} {
+		if _, err := parseWindows([]string{bad}); err == nil {
+			t.Errorf("parseWindows accepted %q", bad)
+		}
+	}
+}
+
+func TestInEntryWindowBoundaries(t *testing.T) {
+	strat := models.StockStrategy{EntryWindows: []string{"09:30-11:30", "13:00-14:30"}}
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{istAt(2, 9, 29, 59), false},
+		{istAt(2, 9, 30, 0), true},
+		{istAt(2, 11, 29, 59), true},
+		{istAt(2, 11, 30, 0), false}, // lunch lull
+		{istAt(2, 12, 59, 0), false},
+		{istAt(2, 13, 0, 0), true},
+		{istAt(2, 14, 30, 0), false},
+		{time.Date(2026, 3, 2, 4, 0, 0, 0, time.UTC), true}, // 09:30 IST
+	}
+	for _, tt := range tests {
+		if got := inEntryWindow(strat, tt.at); got != tt.want {
+			t.Errorf("inEntryWindow at %s = %v, want %v", tt.at.Format("15:04:05 MST"), got, tt.want)
+		}
+	}
+	if !inEntryWindow(models.StockStrategy{}, istAt(2, 9, 15, 0)) {
+		t.Error("no windows blocked an entry")
+	}
+}
+
+func TestCheckAllEntriesEntryWindows(t *testing.T) {
+	defer func(f func() time.Time) { nowIST = f }(nowIST)
+	defer func(w []string) { entryWindows = w }(entryWindows)
+	entryWindows = []string{"09:30-14:30"}
+
+	for _, tt := range []struct {
+		name    string
+		at      time.Time
+		windows []string
+		enter   bool
+	}{
+		{"before the global window", istAt(2, 9, 20, 0), nil, false},
+		{"inside the global window", istAt(2, 10, 0, 0), nil, true},
+		{"symbol window overrides", istAt(2, 10, 0, 0), []string{"13:00-14:00"}, false},
+		{"inside the symbol window", istAt(2, 13, 30, 0), []string{"13:00-14:00"}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resetBooks(t)
+			nowIST = func() time.Time { return tt.at }
+			stockStrategies["TEST"] = models.StockStrategy{
+				Class: "B", SL: 0.01, Target: 0.02, Leverage: 1, BreakoutLong: 0.001, EntryWindows: tt.windows,
+			}
+			markets["TEST"] = &state.MarketState{Symbol: "TEST", High: 100, Low: 95, Ticks: warmupTicks}
+
+			checkAllEntries("TEST", 101)
+			if got := hasPosition("TEST", models.Long); got != tt.enter {
+				t.Errorf("entered = %v, want %v", got, tt.enter)
+			}
+		})
+	}
+}
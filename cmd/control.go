@@ -0,0 +1,190 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/may-bach/Axiom/internal/config"
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+// The control API steers the running bot. Every endpoint is a POST that
+// requires "Authorization: Bearer <AXIOM_CONTROL_TOKEN>" and answers with
+// a controlResponse:
+//
+//	{"ok": true, "message": "LONG entry submitted for SBIN"}
+//
+// Failures use a 4xx/5xx status with "ok": false and the reason in message.
+type controlResponse struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message"`
+}
+
+// strategyManual attributes positions opened via /control/enter.
+const strategyManual = "manual"
+
+// controlRequest is the body of /control/enter and /control/exit:
+//
+//	{"symbol": "SBIN", "direction": "LONG", "tags": ["earnings"]}
+//
+// direction is "LONG" or "SHORT"; /control/exit closes both sides when it
+// is omitted. tags label a manual entry for later analysis.
+type controlRequest struct {
+	Symbol    string           `json:"symbol"`
+	Direction models.Direction `json:"direction"`
+	Tags      []string         `json:"tags,omitempty"`
+}
+
+func registerControlHandlers(mux *http.ServeMux) {
+	// POST /control/enter   {"symbol","direction"[,"tags"]} - market entry now, bypassing strategies
+	// POST /control/exit    {"symbol"[,"direction"]} - market exit of the open position(s)
+	// POST /control/flatten (no body) - exit every open position
+	// POST /control/pause   (no body) - stop taking new entries
+	// POST /control/resume  (no body) - take new entries again
+	// POST /control/heartbeat (no body) - keep the dead-man's switch from firing; any other command does too
+	mux.HandleFunc("POST /control/enter", requireControlToken(handleControlEnter))
+	mux.HandleFunc("POST /control/exit", requireControlToken(handleControlExit))
+	mux.HandleFunc("POST /control/flatten", requireControlToken(handleControlFlatten))
+	mux.HandleFunc("POST /control/pause", requireControlToken(handleControlPause(true)))
+	mux.HandleFunc("POST /control/resume", requireControlToken(handleControlPause(false)))
+	mux.HandleFunc("POST /control/heartbeat", requireControlToken(handleControlHeartbeat))
+}
+
+// requireControlToken rejects requests without the configured bearer
+// token, and every request when no token is configured.
+func requireControlToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		want := config.C.ControlToken
+		if want == "" {
+			writeControl(w, http.StatusForbidden, false, "control API disabled: AXIOM_CONTROL_TOKEN not set")
+			return
+		}
+		got, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			writeControl(w, http.StatusUnauthorized, false, "invalid control token")
+			return
+		}
+		touchControl()
+		next(w, r)
+	}
+}
+
+func writeControl(w http.ResponseWriter, status int, ok bool, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(controlResponse{OK: ok, Message: msg})
+}
+
+// decodeControlRequest parses and validates a symbol command.
+func decodeControlRequest(r *http.Request) (controlRequest, error) {
+	var req controlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, fmt.Errorf("invalid JSON: %v", err)
+	}
+	req.Symbol = strings.ToUpper(strings.TrimSpace(req.Symbol))
+	req.Direction = models.Direction(strings.ToUpper(string(req.Direction)))
+
+	if req.Symbol == "" {
+		return req, fmt.Errorf("symbol required")
+	}
+	if req.Direction != "" && req.Direction != models.Long && req.Direction != models.Short {
+		return req, fmt.Errorf("direction must be LONG or SHORT")
+	}
+
+	mu.Lock()
+	_, known := symbolToToken[req.Symbol]
+	mu.Unlock()
+	if !known {
+		return req, fmt.Errorf("unknown symbol %s", req.Symbol)
+	}
+	return req, nil
+}
+
+func handleControlEnter(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeControlRequest(r)
+	if err != nil {
+		writeControl(w, http.StatusBadRequest, false, err.Error())
+		return
+	}
+	if req.Direction == "" {
+		writeControl(w, http.StatusBadRequest, false, "direction required")
+		return
+	}
+	if hasPosition(req.Symbol, req.Direction) || hasPendingEntry(req.Symbol, req.Direction) {
+		writeControl(w, http.StatusConflict, false, fmt.Sprintf("%s %s already open or pending", req.Direction, req.Symbol))
+		return
+	}
+
+	ltp, err := quotes.GetLTP(r.Context(), "NSE", tokenFor(req.Symbol))
+	if err != nil {
+		writeControl(w, http.StatusBadGateway, false, fmt.Sprintf("LTP for %s: %v", req.Symbol, err))
+		return
+	}
+
+	entryMu.Lock()
+	defer entryMu.Unlock()
+	logTrade(fmt.Sprintf("MANUAL %s ENTRY %s @ %.2f via control API", req.Direction, req.Symbol, ltp))
+	leverage := getStrategy(req.Symbol).Leverage
+	src := entrySource{Strategy: strategyManual, Tags: req.Tags}
+	if req.Direction == models.Long {
+		enterLong(req.Symbol, src, ltp, leverage, 0)
+	} else {
+		enterShort(req.Symbol, src, ltp, leverage, 0)
+	}
+	writeControl(w, http.StatusOK, true, fmt.Sprintf("%s entry submitted for %s", req.Direction, req.Symbol))
+}
+
+func handleControlExit(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeControlRequest(r)
+	if err != nil {
+		writeControl(w, http.StatusBadRequest, false, err.Error())
+		return
+	}
+
+	defer lockExits(req.Symbol)()
+	mu.Lock()
+	long, hasLong := longPositions[req.Symbol]
+	short, hasShort := shortPositions[req.Symbol]
+	mu.Unlock()
+	hasLong = hasLong && req.Direction != models.Short
+	hasShort = hasShort && req.Direction != models.Long
+
+	if !hasLong && !hasShort {
+		writeControl(w, http.StatusNotFound, false, fmt.Sprintf("no open position in %s", req.Symbol))
+		return
+	}
+
+	ltp, err := quotes.GetLTP(r.Context(), "NSE", tokenFor(req.Symbol))
+	if err != nil {
+		writeControl(w, http.StatusBadGateway, false, fmt.Sprintf("LTP for %s: %v", req.Symbol, err))
+		return
+	}
+
+	if hasLong {
+		exitLong(req.Symbol, ltp, long.TotalQty, ReasonManual)
+	}
+	if hasShort {
+		exitShort(req.Symbol, ltp, short.TotalQty, ReasonManual)
+	}
+	writeControl(w, http.StatusOK, true, fmt.Sprintf("exit submitted for %s", req.Symbol))
+}
+
+func handleControlFlatten(w http.ResponseWriter, r *http.Request) {
+	logTrade("MANUAL flatten-all via control API")
+	flattenAll(ReasonFlatten)
+	writeControl(w, http.StatusOK, true, "all positions exited")
+}
+
+func handleControlHeartbeat(w http.ResponseWriter, r *http.Request) {
+	writeControl(w, http.StatusOK, true, "alive")
+}
+
+func handleControlPause(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setEntriesPaused(paused, "control API")
+		writeControl(w, http.StatusOK, true, pauseLabel(paused))
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+func TestConcurrentExitsBookOnce(t *testing.T) {
+	resetBooks(t)
+	oldQuotes := quotes
+	t.Cleanup(func() { quotes = oldQuotes })
+	quotes = priceQuotes{}
+	stockStrategies["TEST"] = models.StockStrategy{Class: "B", SL: 0.01, Target: 0.02, Leverage: 1}
+	seedLong("TEST", 100, 10)
+
+	// The loop's target exit, a control exit and a flatten race for the
+	// same position: exactly one of them closes it.
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() { checkLongExit("TEST", 103) })
+		wg.Go(func() {
+			defer lockExits("TEST")()
+			exitLong("TEST", 103, 10, ReasonManual)
+		})
+		wg.Go(func() { flattenAll(ReasonFlatten) })
+	}
+	wg.Wait()
+
+	if hasPosition("TEST", models.Long) {
+		t.Fatal("position still open")
+	}
+	if n := len(tradeHistory); n != 1 {
+		t.Errorf("booked %d exits, want 1", n)
+	}
+	if tr := lastTrade(t); tr.EntryPrice != 100 {
+		t.Errorf("exit booked with entry %v, want 100", tr.EntryPrice)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+// costModel is the per-order charges on an NSE equity trade. Rates are
+// fractions of turnover unless noted.
+type costModel struct {
+	BrokeragePerOrder float64 // flat brokerage per executed order
+	STTIntraday       float64 // on the sell leg of an intraday trade
+	STTDelivery       float64 // on both legs of a delivery trade
+	Exchange          float64 // exchange transaction charge, both legs
+	SEBI              float64 // SEBI turnover fee, both legs
+	StampIntraday     float64 // on the buy leg of an intraday trade
+	StampDelivery     float64 // on the buy leg of a delivery trade
+	GST               float64 // on brokerage, exchange and SEBI charges
+}
+
+var (
+	costs = costModel{
+		STTIntraday:   0.00025,
+		STTDelivery:   0.001,
+		Exchange:      0.0000297,
+		SEBI:          0.000001,
+		StampIntraday: 0.00003,
+		StampDelivery: 0.00015,
+		GST:           0.18,
+	}
+
+	// minNetProfit is the least a trade must make after costs if it
+	// reaches its target; entries whose target cannot clear it are
+	// refused. Negative disables the check.
+	minNetProfit = 0.0
+)
+
+// roundTrip is the total charges for buying and selling qty at buy and
+// sell under product.
+func (c costModel) roundTrip(product string, buy, sell float64, qty int) float64 {
+	buyValue, sellValue := buy*float64(qty), sell*float64(qty)
+	turnover := buyValue + sellValue
+
+	brokerage := 2 * c.BrokeragePerOrder
+	fees := turnover * (c.Exchange + c.SEBI)
+	total := brokerage + fees + (brokerage+fees)*c.GST
+	if product == client.ProductCNC {
+		return total + turnover*c.STTDelivery + buyValue*c.StampDelivery
+	}
+	return total + sellValue*c.STTIntraday + buyValue*c.StampIntraday
+}
+
+// minTarget is the smallest target, as a fraction of ltp, at which a dir
+// entry of qty sym nets minNetProfit after costs. Net profit is linear in
+// the move, so it is solved from the net at a zero and a full-ltp move.
+func minTarget(sym string, dir models.Direction, ltp float64, qty int) float64 {
+	product := productFor(sym, dir)
+	net := func(move float64) float64 {
+		buy, sell := ltp, ltp+move
+		if dir == models.Short {
+			buy, sell = ltp-move, ltp
+		}
+		return move*float64(qty) - costs.roundTrip(product, buy, sell, qty)
+	}
+	net0, net1 := net(0), net(ltp)
+	return (minNetProfit - net0) / (net1 - net0)
+}
+
+// targetClearsCosts reports whether sym's configured target is at least
+// minTarget for a dir entry of qty at ltp, logging the refusal when not.
+func targetClearsCosts(sym string, dir models.Direction, ltp float64, qty int) bool {
+	if minNetProfit < 0 {
+		return true
+	}
+	need := minTarget(sym, dir, ltp, qty)
+	target := getStrategy(sym).Target
+	if target >= need {
+		return true
+	}
+	logTrade(fmt.Sprintf("%s skipped %s - target %.2f%% does not clear costs, need %.2f%%",
+		dir, sym, target*100, need*100))
+	return false
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/notify"
+)
+
+var (
+	marketOpenAt = 9*60 + 15 // minutes past midnight IST
+	openReset    = true      // clear yesterday's state on the first tick after the open
+
+	tradingDay string // IST date the day state belongs to; guarded by mu
+)
+
+// initTradingDay marks a bot started after today's open as already in
+// today's session, so what it restored is not reset. One started before
+// the open resets at the open, dropping pre-open ticks.
+func initTradingDay(now time.Time) {
+	now = now.In(ist)
+	if minuteOfDay(now) >= marketOpenAt {
+		mu.Lock()
+		tradingDay = now.Format("2006-01-02")
+		mu.Unlock()
+	}
+}
+
+// startTradingDay runs the at-open reset on the first tick at or after
+// marketOpenAt on a date later than tradingDay. It does not depend on the
+// bot having been up for the 15:30 reset: yesterday's session range and
+// tick history, signal streaks, queued signals, order dedup keys, halts,
+// the day's P&L and trades all go. DAY orders expire at the close, so
+// pending entries and protected exits are dropped too, as are finished
+// paper orders. Open positions are kept, listed and reconciled against
+// the broker's position book.
+func startTradingDay(now time.Time) {
+	now = now.In(ist)
+	day := now.Format("2006-01-02")
+
+	mu.Lock()
+	if !openReset || minuteOfDay(now) < marketOpenAt || day <= tradingDay {
+		mu.Unlock()
+		return
+	}
+	tradingDay = day
+
+	clear(markets)
+	clear(signalStreaks)
+	clear(outOfBand)
+	clear(halted)
+	clear(recentOrders)
+	clear(ltpFailures)
+	signalQueue = nil
+	dropped := len(pendingEntries)
+	clear(pendingEntries)
+	clear(pendingExits)
+	prunePaperOrders()
+
+	var carried []string
+	for _, sym := range orderedSymbols(longPositions) {
+		carried = append(carried, fmt.Sprintf("LONG %s x%d", sym, longPositions[sym].TotalQty))
+	}
+	for _, sym := range orderedSymbols(shortPositions) {
+		carried = append(carried, fmt.Sprintf("SHORT %s x%d", sym, shortPositions[sym].TotalQty))
+	}
+	mu.Unlock()
+	resetDay()
+	reconcilePositions()
+
+	msg := fmt.Sprintf("New trading day %s: day state reset", day)
+	if dropped > 0 {
+		msg += fmt.Sprintf(", %d expired pending entries dropped", dropped)
+	}
+	if len(carried) > 0 {
+		msg += fmt.Sprintf(", carried over: %s", strings.Join(carried, ", "))
+	}
+	logTrade(msg)
+}
+
+// reconcilePositions checks the open positions against the broker's
+// position book. One the broker no longer holds, say closed by hand
+// overnight, is dropped, and one it holds less of is cut down to the
+// broker's quantity at the same average cost. A position the broker holds
+// more of is only reported: the rest was not opened by the bot. Paper
+// positions have no book to check against.
+func reconcilePositions() {
+	if paperTrading {
+		return
+	}
+	book, err := broker.GetPositions(context.Background())
+	if err != nil {
+		notifyTrade(notify.EventError, fmt.Sprintf("Position reconcile failed, carried positions unchecked: %v", err))
+		return
+	}
+	held := make(map[string]int) // net qty by trading symbol
+	for _, p := range book {
+		held[p.Tsym] += p.NetQty
+	}
+
+	mu.Lock()
+	var fixes []string
+	for _, dir := range []models.Direction{models.Long, models.Short} {
+		positions := positionsFor(dir)
+		for _, sym := range orderedSymbols(positions) {
+			pos := positions[sym]
+			net := held[cmp.Or(tradingSymbols[sym], sym+"-EQ")]
+			if dir == models.Short {
+				net = -net
+			}
+			switch {
+			case net <= 0:
+				delete(positions, sym)
+				fixes = append(fixes, fmt.Sprintf("%s %s x%d dropped, not held at the broker", dir, sym, pos.TotalQty))
+			case net < pos.TotalQty:
+				fixes = append(fixes, fmt.Sprintf("%s %s cut from %d to the broker's %d", dir, sym, pos.TotalQty, net))
+				pos.reduce(pos.TotalQty - net)
+				positions[sym] = pos
+			case net > pos.TotalQty:
+				fixes = append(fixes, fmt.Sprintf("%s %s x%d kept, broker holds %d", dir, sym, pos.TotalQty, net))
+			}
+		}
+	}
+	mu.Unlock()
+
+	if len(fixes) > 0 {
+		notifyTrade(notify.EventError, "Position reconcile: "+strings.Join(fixes, "; "))
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+func TestStartTradingDayResetsOnceAtOpen(t *testing.T) {
+	resetBooks(t)
+	oldDay := tradingDay
+	t.Cleanup(func() { tradingDay = oldDay })
+
+	initTradingDay(istAt(2, 11, 0, 0))
+	if tradingDay != "2026-03-02" {
+		t.Fatalf("started after the open: tradingDay = %q, want today", tradingDay)
+	}
+	startTradingDay(istAt(2, 11, 0, 10))
+
+	// Yesterday's state, as left by a bot that ran overnight.
+	seedLong("HELD", 100, 10)
+	markets["TEST"] = &state.MarketState{Symbol: "TEST", High: 120, Low: 90}
+	signalStreaks["TEST|breakout_long"] = 2
+	pendingEntries["1"] = pendingOrder{ID: "1", Symbol: "TEST", Direction: models.Long}
+	paperOrders["PAPER-1"] = &paperOrder{Status: client.OrderStatus{Status: client.StatusComplete}}
+	paperOrders["PAPER-2"] = &paperOrder{Status: client.OrderStatus{Status: client.StatusOpen}}
+	dailyPnL, tradeHistory = 250, []TradeRecord{{Symbol: "OLD"}}
+
+	startTradingDay(istAt(3, 9, 0, 0))
+	if len(markets) == 0 || dailyPnL == 0 {
+		t.Fatal("reset before the open")
+	}
+
+	startTradingDay(istAt(3, 9, 15, 4))
+	if len(markets) != 0 || len(signalStreaks) != 0 || len(pendingEntries) != 0 {
+		t.Errorf("day state left: %d markets, %d streaks, %d pending", len(markets), len(signalStreaks), len(pendingEntries))
+	}
+	if dailyPnL != 0 || len(tradeHistory) != 0 {
+		t.Errorf("P&L %.2f and %d trades left from yesterday", dailyPnL, len(tradeHistory))
+	}
+	if !hasPosition("HELD", models.Long) {
+		t.Error("open position dropped by the reset")
+	}
+	if _, ok := paperOrders["PAPER-2"]; len(paperOrders) != 1 || !ok {
+		t.Errorf("paper orders left: %v, want only the open one", slices.Collect(maps.Keys(paperOrders)))
+	}
+
+	// Later ticks the same day leave the new session alone.
+	markets["TEST"] = &state.MarketState{Symbol: "TEST", High: 101, Low: 99}
+	startTradingDay(istAt(3, 9, 15, 20))
+	if len(markets) != 1 {
+		t.Error("reset ran twice in one day")
+	}
+}
+
+func TestStartTradingDayBeforeOpenStart(t *testing.T) {
+	resetBooks(t)
+	oldDay := tradingDay
+	t.Cleanup(func() { tradingDay = oldDay })
+	tradingDay = ""
+
+	// Started pre-open: the pre-open ticks go at the open.
+	initTradingDay(istAt(2, 8, 30, 0))
+	markets["TEST"] = &state.MarketState{Symbol: "TEST", High: 101, Low: 99}
+	startTradingDay(istAt(2, 9, 15, 0))
+	if len(markets) != 0 {
+		t.Error("pre-open state kept past the open")
+	}
+}
+
+func TestStartTradingDayDisabled(t *testing.T) {
+	resetBooks(t)
+	oldDay, oldReset := tradingDay, openReset
+	t.Cleanup(func() { tradingDay, openReset = oldDay, oldReset })
+	tradingDay, openReset = "2026-03-02", false
+
+	markets["TEST"] = &state.MarketState{Symbol: "TEST"}
+	startTradingDay(istAt(3, 9, 30, 0))
+	if len(markets) != 1 {
+		t.Error("reset ran with -open-reset=false")
+	}
+}
+
+// bookBroker reports a fixed position book.
+type bookBroker struct {
+	client.Broker
+	positions []client.Position
+}
+
+func (b *bookBroker) GetPositions(ctx context.Context) ([]client.Position, error) {
+	return b.positions, nil
+}
+
+func TestStartTradingDayReconcilesPositions(t *testing.T) {
+	resetBooks(t)
+	oldDay, oldBroker, oldTsyms := tradingDay, broker, tradingSymbols
+	t.Cleanup(func() { tradingDay, broker, tradingSymbols = oldDay, oldBroker, oldTsyms })
+	tradingDay, paperTrading = "2026-03-02", false
+	tradingSymbols = map[string]string{"CUT": "CUT-BE"}
+	broker = &bookBroker{positions: []client.Position{
+		{Tsym: "KEEP-EQ", Product: "C", NetQty: 10},
+		{Tsym: "CUT-BE", Product: "C", NetQty: 4},
+		{Tsym: "MORE-EQ", Product: "C", NetQty: 8},
+		{Tsym: "GONE-EQ", Product: "I", NetQty: 0},
+	}}
+
+	seedLong("KEEP", 100, 10)
+	seedLong("CUT", 100, 10)
+	seedLong("MORE", 100, 5)
+	seedShort("GONE", 100, 5)
+
+	startTradingDay(istAt(3, 9, 15, 0))
+	for sym, want := range map[string]int{"KEEP": 10, "CUT": 4, "MORE": 5} {
+		if got := longQty(sym); got != want {
+			t.Errorf("%s qty = %d, want %d", sym, got, want)
+		}
+	}
+	if pos := longPositions["CUT"]; pos.AvgEntry() != 100 {
+		t.Errorf("CUT avg entry = %.2f, want 100 kept", pos.AvgEntry())
+	}
+	if hasPosition("GONE", models.Short) {
+		t.Error("position closed at the broker was kept")
+	}
+}
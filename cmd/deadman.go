@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/notify"
+)
+
+var (
+	// deadManAfter is how long the bot may go without an authenticated
+	// control request before it assumes the operator has lost contact,
+	// exits everything and pauses entries; 0 disables the switch.
+	deadManAfter time.Duration
+
+	controlSeen    atomic.Int64 // unix nanos of the last authenticated control request
+	deadManTripped atomic.Bool  // set when the switch fires, cleared by the next control request
+)
+
+// touchControl records contact from the controller and re-arms the
+// switch. Entries stay paused after a trip until /control/resume.
+func touchControl() {
+	controlSeen.Store(time.Now().UnixNano())
+	deadManTripped.Store(false)
+}
+
+// checkDeadMan fires the switch when no control request has arrived
+// within deadManAfter of now. While it stays tripped, every check flattens
+// whatever is still open, so an exit that failed is retried.
+func checkDeadMan(now time.Time) {
+	if deadManAfter <= 0 {
+		return
+	}
+	if deadManTripped.Load() {
+		if positionsOpen() {
+			flattenAll(ReasonDeadMan)
+		}
+		return
+	}
+	since := now.Sub(time.Unix(0, controlSeen.Load()))
+	if since <= deadManAfter {
+		return
+	}
+	deadManTripped.Store(true)
+
+	notifyTrade(notify.EventError, fmt.Sprintf("DEAD-MAN'S SWITCH: no control contact for %s (limit %s) - flattening all and pausing entries",
+		since.Round(time.Second), deadManAfter))
+	setEntriesPaused(true, "dead-man's switch")
+	flattenAll(ReasonDeadMan)
+}
+
+// watchDeadMan checks the switch every pollInterval until ctx is done.
+// The countdown starts when it is called, so the controller has a full
+// deadManAfter to make first contact.
+func watchDeadMan(ctx context.Context) {
+	if deadManAfter <= 0 {
+		return
+	}
+	touchControl()
+	check := time.NewTicker(min(pollInterval, deadManAfter))
+	defer check.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-check.C:
+			checkDeadMan(now)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/config"
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+func TestDeadManFlattensOnLapsedHeartbeat(t *testing.T) {
+	resetBooks(t)
+	oldAfter, oldQuotes := deadManAfter, quotes
+	t.Cleanup(func() {
+		deadManAfter, quotes = oldAfter, oldQuotes
+		deadManTripped.Store(false)
+	})
+	deadManAfter, quotes = time.Minute, priceQuotes{}
+
+	seedLong("AAA", 100, 10)
+	seedShort("BBB", 200, 5)
+	touchControl()
+
+	checkDeadMan(time.Now().Add(59 * time.Second))
+	if !hasPosition("AAA", models.Long) || entriesPaused {
+		t.Fatal("switch fired within the timeout")
+	}
+
+	checkDeadMan(time.Now().Add(2 * time.Minute))
+	if hasPosition("AAA", models.Long) || hasPosition("BBB", models.Short) {
+		t.Error("positions left open after the switch fired")
+	}
+	if !entriesPaused {
+		t.Error("entries not paused after the switch fired")
+	}
+	if r := lastTrade(t).Reason; r != ReasonDeadMan {
+		t.Errorf("exit reason = %s, want %s", r, ReasonDeadMan)
+	}
+
+	// While tripped, each check flattens whatever is still open, such as
+	// a position whose exit failed.
+	seedLong("CCC", 50, 10)
+	checkDeadMan(time.Now().Add(3 * time.Minute))
+	if hasPosition("CCC", models.Long) {
+		t.Error("position left open while the switch was tripped")
+	}
+}
+
+func TestControlRequestResetsDeadMan(t *testing.T) {
+	oldToken := config.C.ControlToken
+	t.Cleanup(func() { config.C.ControlToken = oldToken })
+	config.C.ControlToken = "secret"
+	controlSeen.Store(0)
+	deadManTripped.Store(true)
+
+	mux := http.NewServeMux()
+	registerControlHandlers(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/control/heartbeat", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+	if controlSeen.Load() != 0 {
+		t.Fatal("unauthenticated request counted as contact")
+	}
+
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("heartbeat status = %d", rec.Code)
+	}
+	if since := time.Since(time.Unix(0, controlSeen.Load())); since > time.Second || deadManTripped.Load() {
+		t.Errorf("heartbeat did not re-arm the switch (last contact %s ago)", since)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+const (
+	decideLast = "last" // the latest tick
+	decideSMA  = "sma"  // the average of the last decisionWindow ticks
+)
+
+// decisionPrice is what entry and exit thresholds are compared against.
+// The SMA filters single-tick spikes that would otherwise trigger an entry
+// or stop and reverse at once, at the cost of lag: a real move is acted on
+// a tick or two later and at a worse price. Orders and P&L always use the
+// raw tick.
+var (
+	decisionPrice  = decideLast
+	decisionWindow = 3
+)
+
+func setDecisionPrice(s string) error {
+	switch s {
+	case decideLast, decideSMA:
+		decisionPrice = s
+		return nil
+	}
+	return fmt.Errorf("unknown decision price %q (want %s or %s)", s, decideLast, decideSMA)
+}
+
+// decisionPriceOf is the decision price for ms given its raw tick ltp.
+func decisionPriceOf(ms *state.MarketState, ltp float64) float64 {
+	if decisionPrice != decideSMA {
+		return ltp
+	}
+	if sma := ms.SMA(decisionWindow); sma > 0 {
+		return sma
+	}
+	return ltp
+}
+
+// decisionLTP is decisionPriceOf for sym's market state.
+func decisionLTP(sym string, ltp float64) float64 {
+	if decisionPrice != decideSMA {
+		return ltp
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	ms, ok := markets[sym]
+	if !ok {
+		return ltp
+	}
+	return decisionPriceOf(ms, ltp)
+}
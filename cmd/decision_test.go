@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+func TestDecisionPriceEntries(t *testing.T) {
+	defer func(mode string, n int) { decisionPrice, decisionWindow = mode, n }(decisionPrice, decisionWindow)
+	decisionWindow = 3
+
+	// A one-tick spike through the 100 high: the raw tick breaks out, the
+	// 3-tick average (99.5) does not.
+	for _, tt := range []struct {
+		mode  string
+		enter bool
+	}{
+		{decideLast, true},
+		{decideSMA, false},
+	} {
+		resetBooks(t)
+		decisionPrice = tt.mode
+		stockStrategies["TEST"] = models.StockStrategy{Class: "B", BreakoutLong: 0.001, SL: 0.01, Target: 0.02, Leverage: 1}
+		markets["TEST"] = &state.MarketState{
+			Symbol: "TEST", High: 100, Low: 95, Ticks: warmupTicks, History: []float64{99, 99, 100.5},
+		}
+
+		checkAllEntries("TEST", 100.5)
+		if got := hasPosition("TEST", models.Long); got != tt.enter {
+			t.Errorf("%s: entered = %v, want %v", tt.mode, got, tt.enter)
+		}
+	}
+}
+
+func TestDecisionPriceExits(t *testing.T) {
+	defer func(mode string, n int) { decisionPrice, decisionWindow = mode, n }(decisionPrice, decisionWindow)
+	decisionWindow = 3
+	slippageBps["B"] = 0
+	t.Cleanup(func() { slippageBps["B"] = 5 })
+
+	series := []float64{100, 100, 98.8, 100.2, 98.5, 98.2}
+	for _, tt := range []struct {
+		mode   string
+		exitAt int // index into series of the exiting tick
+	}{
+		{decideLast, 2}, // the 98.8 wick is through the 99 SL
+		{decideSMA, 5},  // the average only crosses 99 at (100.2+98.5+98.2)/3
+	} {
+		resetBooks(t)
+		decisionPrice = tt.mode
+		stockStrategies["TEST"] = models.StockStrategy{Class: "B", SL: 0.01, Target: 0.05, Leverage: 1}
+		seedLong("TEST", 100, 10)
+		markets["TEST"] = &state.MarketState{Symbol: "TEST"}
+
+		exitAt := -1
+		for i, px := range series {
+			markets["TEST"].History = append(markets["TEST"].History, px)
+			checkLongExit("TEST", px)
+			if !hasPosition("TEST", models.Long) {
+				exitAt = i
+				break
+			}
+		}
+		if exitAt != tt.exitAt {
+			t.Errorf("%s: exited on tick %d, want %d", tt.mode, exitAt, tt.exitAt)
+			continue
+		}
+		// P&L is booked at the raw tick, not the average.
+		if tr := lastTrade(t); tr.ExitPrice != series[exitAt] {
+			t.Errorf("%s: exit price %v, want the raw tick %v", tt.mode, tr.ExitPrice, series[exitAt])
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/notify"
+)
+
+// Exit price types: how exit orders are priced.
+const (
+	exitMarket    = "market"    // MKT
+	exitProtected = "protected" // LMT a few ticks through the ltp
+)
+
+var (
+	exitPriceType       = exitMarket
+	exitProtectionTicks = 5                // band of a protected exit, in ticks
+	protectedExitTTL    = 30 * time.Second // a protected exit still open after this goes to market
+
+	pendingExits = make(map[string]pendingExit) // protected exits by order ID
+)
+
+// pendingExit is a protected exit resting at its limit. The position it
+// closes stays open, and no other exit is sent for it, until the order's
+// fills are booked.
+type pendingExit struct {
+	ID        string
+	Symbol    string
+	Direction models.Direction
+	Qty       int
+	Filled    int     // qty already booked
+	AvgPrice  float64 // the order's average price when Filled was booked
+	Limit     float64
+	Reason    ExitReason
+	Placed    time.Time
+}
+
+func setExitPriceType(v string) error {
+	switch v {
+	case exitMarket, exitProtected:
+		exitPriceType = v
+		return nil
+	}
+	return fmt.Errorf("unknown exit price type %q (want market or protected)", v)
+}
+
+// protectionPrice is the limit of a protected exit on side at ltp: ticks
+// ticks worse than ltp, rounded further out to the tick so the order
+// stays marketable.
+func protectionPrice(side client.Side, ltp, tick float64, ticks int) float64 {
+	band := float64(ticks) * tick
+	if side == client.Sell {
+		return client.FloorToTick(ltp-band, tick)
+	}
+	return client.CeilToTick(ltp+band, tick)
+}
+
+// exitOrder is the order closing qty of sym's dir position on side at
+// ltp under the symbol's exit price type. A protected exit is a DAY limit
+// at protectionPrice: it fills like a market order in normal conditions,
+// but in a fast move the unfilled remainder rests at the limit rather
+// than filling arbitrarily far away, until protectedExitTTL sends it at
+// market. pollPendingExits books it as it fills. Local exits fire once
+// the trigger has already traded, so a plain limit is used rather than
+// an SL-LMT, whose trigger would already be breached.
+func exitOrder(sym string, dir models.Direction, side client.Side, qty int, ltp float64) client.OrderParams {
+	p := marketOrder(sym, dir, side, qty)
+	p.Product = heldProduct(sym, dir)
+	strat := getStrategy(sym)
+	if strat.ExitPriceType != exitProtected || ltp <= 0 {
+		return p
+	}
+	p.PriceType = client.PriceLimit
+	p.Price = protectionPrice(side, ltp, tickSizeFor(sym), strat.ExitProtectionTicks)
+	return p
+}
+
+func trackExit(e pendingExit) {
+	mu.Lock()
+	pendingExits[e.ID] = e
+	mu.Unlock()
+	logTrade(fmt.Sprintf("PENDING EXIT %s %s LMT @ %.2f Qty: %d (order %s)", e.Direction, e.Symbol, e.Limit, e.Qty, e.ID))
+}
+
+func hasPendingExit(sym string, dir models.Direction) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, e := range pendingExits {
+		if e.Symbol == sym && e.Direction == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// pollPendingExits books protected exits as they fill, in symbol order.
+// One still open after protectedExitTTL is cancelled and its rest sent at
+// market; one the broker cancels or rejects puts the exchange legs back
+// on whatever is left of the position.
+func pollPendingExits() {
+	mu.Lock()
+	pending := slices.Collect(maps.Values(pendingExits))
+	mu.Unlock()
+	slices.SortFunc(pending, func(a, b pendingExit) int {
+		return cmp.Or(compareSymbols(a.Symbol, b.Symbol), strings.Compare(a.ID, b.ID))
+	})
+
+	for _, e := range pending {
+		pollPendingExit(e)
+	}
+}
+
+// pollPendingExit settles protected exit e from its order status. It
+// holds the symbol's exit lock throughout, so no other exit is sent
+// between the pending exit being dropped and its fills, or the market
+// order for its rest, being booked.
+func pollPendingExit(e pendingExit) {
+	defer lockExits(e.Symbol)()
+
+	st, err := orderStatus(e.ID)
+	if err != nil {
+		log.Printf("Exit status for %s (%s) failed: %v", e.ID, e.Symbol, err)
+		return
+	}
+
+	switch st.Status {
+	case client.StatusComplete:
+		removePendingExit(e.ID)
+		bookExitFill(e, cmp.Or(st.FilledQty, e.Qty), cmp.Or(st.AvgPrice, e.Limit))
+
+	case client.StatusRejected, client.StatusCancelled:
+		removePendingExit(e.ID)
+		bookExitFill(e, st.FilledQty, st.AvgPrice)
+		notifyTrade(notify.EventError, fmt.Sprintf("%s EXIT %s %s: order %s %s", e.Direction, st.Status, e.Symbol, e.ID, st.Reason))
+		restoreExchangeExits(e.Symbol, e.Direction)
+
+	default:
+		if st.FilledQty > e.Filled {
+			e = bookExitFill(e, st.FilledQty, st.AvgPrice)
+			mu.Lock()
+			pendingExits[e.ID] = e
+			mu.Unlock()
+		}
+		if time.Since(e.Placed) < protectedExitTTL {
+			return
+		}
+		if err := cancelOrder(e.ID); err != nil {
+			log.Printf("Cancel of protected exit %s (%s) failed: %v", e.ID, e.Symbol, err)
+			return
+		}
+		removePendingExit(e.ID)
+		// More may have filled before the cancel landed.
+		if st, err := orderStatus(e.ID); err == nil {
+			e = bookExitFill(e, st.FilledQty, st.AvgPrice)
+		}
+		escalateExit(e)
+	}
+}
+
+// bookExitFill books the part of protected exit e filled since it was
+// last seen, given the order's total filled qty and average price, and
+// returns e with Filled brought up to date.
+func bookExitFill(e pendingExit, filled int, avg float64) pendingExit {
+	n := min(filled, e.Qty) - e.Filled
+	if n <= 0 {
+		return e
+	}
+	fill := cmp.Or(incrementPrice(e.Filled, e.AvgPrice, min(filled, e.Qty), avg), e.Limit)
+	if e.Direction == models.Long {
+		bookLongExit(e.Symbol, fill, n, e.Reason)
+	} else {
+		bookShortExit(e.Symbol, fill, n, e.Reason)
+	}
+	e.Filled, e.AvgPrice = e.Filled+n, avg
+	return e
+}
+
+// escalateExit sends the unfilled rest of expired protected exit e at
+// market and books it.
+func escalateExit(e pendingExit) {
+	rest := e.Qty - e.Filled
+	if rest <= 0 {
+		return
+	}
+	side := client.Sell
+	if e.Direction == models.Short {
+		side = client.Buy
+	}
+	p := marketOrder(e.Symbol, e.Direction, side, rest)
+	p.Product = heldProduct(e.Symbol, e.Direction)
+	logTrade(fmt.Sprintf("EXIT ESCALATED %s %s: %d of %d unfilled at %.2f after %s - sending at market (order %s)",
+		e.Direction, e.Symbol, rest, e.Qty, e.Limit, protectedExitTTL, e.ID))
+	id, err := sendOrder(p)
+	if err != nil {
+		notifyTrade(notify.EventError, fmt.Sprintf("%s EXIT FAILED %s: %v", e.Direction, e.Symbol, err))
+		restoreExchangeExits(e.Symbol, e.Direction)
+		return
+	}
+	ltp := cmp.Or(symbolLTP(e.Symbol), e.Limit)
+	if e.Direction == models.Long {
+		bookLongExit(e.Symbol, fillPrice(e.Symbol, side, ltp, id), rest, e.Reason)
+	} else {
+		bookShortExit(e.Symbol, fillPrice(e.Symbol, side, ltp, id), rest, e.Reason)
+	}
+	restoreExchangeExits(e.Symbol, e.Direction)
+}
+
+func removePendingExit(id string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(pendingExits, id)
+}
+
+// incrementPrice is the average price of the fills taken between two
+// looks at an order: from qty0 filled at an average of avg0 to qty1 at
+// avg1. It is 0 when the order reports no average price, and avg1 when
+// the earlier look had none to go on.
+func incrementPrice(qty0 int, avg0 float64, qty1 int, avg1 float64) float64 {
+	if avg1 <= 0 || qty1 <= qty0 {
+		return 0
+	}
+	if qty0 == 0 || avg0 <= 0 {
+		return avg1
+	}
+	return (avg1*float64(qty1) - avg0*float64(qty0)) / float64(qty1-qty0)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+func TestProtectionPrice(t *testing.T) {
+	tests := []struct {
+		name  string
+		side  client.Side
+		ltp   float64
+		tick  float64
+		ticks int
+		want  float64
+	}{
+		{"sell below ltp", client.Sell, 100, 0.05, 5, 99.75},
+		{"buy above ltp", client.Buy, 100, 0.05, 5, 100.25},
+		{"sell rounds down off-tick ltp", client.Sell, 100.12, 0.05, 2, 100.00},
+		{"buy rounds up off-tick ltp", client.Buy, 100.12, 0.05, 2, 100.25},
+		{"coarse tick", client.Sell, 2500, 0.5, 3, 2498.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := protectionPrice(tt.side, tt.ltp, tt.tick, tt.ticks); got != tt.want {
+				t.Errorf("protectionPrice = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitOrderPriceType(t *testing.T) {
+	resetBooks(t)
+	defer func(typ string, ticks int) { exitPriceType, exitProtectionTicks = typ, ticks }(exitPriceType, exitProtectionTicks)
+
+	if p := exitOrder("ABC", models.Long, client.Sell, 10, 100); p.PriceType != client.PriceMarket {
+		t.Errorf("default exit = %s, want MKT", p.PriceType)
+	}
+
+	exitPriceType, exitProtectionTicks = exitProtected, 4
+	p := exitOrder("ABC", models.Long, client.Sell, 10, 100)
+	if p.PriceType != client.PriceLimit || p.Price != 99.8 {
+		t.Errorf("protected long exit = %s @ %v, want LMT @ 99.8", p.PriceType, p.Price)
+	}
+
+	stockStrategies["XYZ"] = models.StockStrategy{ExitPriceType: exitMarket}
+	if p := exitOrder("XYZ", models.Short, client.Buy, 10, 100); p.PriceType != client.PriceMarket {
+		t.Errorf("per-symbol market exit = %s, want MKT over the global protected", p.PriceType)
+	}
+	stockStrategies["XYZ"] = models.StockStrategy{ExitPriceType: exitProtected, ExitProtectionTicks: 10}
+	if p := exitOrder("XYZ", models.Short, client.Buy, 10, 100); p.Price != 100.5 {
+		t.Errorf("protected short exit limit = %v, want 100.5", p.Price)
+	}
+}
+
+func TestExitRepeatsEntryProduct(t *testing.T) {
+	resetBooks(t)
+	paperTrading = false
+	fb := &fakeBroker{}
+	oldBroker, oldQuotes := broker, quotes
+	t.Cleanup(func() { broker, quotes = oldBroker, oldQuotes })
+	broker, quotes = fb, priceQuotes{}
+
+	mis := models.StockStrategy{Class: "B", SL: 0.01, Target: 0.02, Leverage: 1, Product: client.ProductMIS}
+	cnc := models.StockStrategy{Class: "B", SL: 0.01, Target: 0.02, Leverage: 1}
+	for _, exit := range []func(){
+		func() { checkLongExit("TEST", 98) },
+		func() { squareOffAllPositions(time.Now()) },
+	} {
+		fb.orders = nil
+		clear(recentOrders) // the second pass enters again in the same minute
+		stockStrategies["TEST"] = mis
+		enterLong("TEST", entrySource{}, 100, 1, 1)
+		if !hasPosition("TEST", models.Long) {
+			t.Fatal("long not entered")
+		}
+
+		// A config reload between entry and exit must not change the
+		// exit's product: the broker would find no CNC position to sell.
+		stockStrategies["TEST"] = cnc
+		exit()
+
+		if len(fb.orders) != 2 {
+			t.Fatalf("sent %d orders, want an entry and an exit", len(fb.orders))
+		}
+		entry, exit := fb.orders[0], fb.orders[1]
+		if entry.Product != client.ProductMIS || exit.Product != entry.Product {
+			t.Errorf("entry prd %q, exit prd %q; want both %q", entry.Product, exit.Product, client.ProductMIS)
+		}
+	}
+}
+
+func TestProtectedExitBookedOnFill(t *testing.T) {
+	resetBooks(t)
+	defer func(typ string) { exitPriceType = typ }(exitPriceType)
+	exitPriceType = exitProtected
+	seedLong("ABC", 100, 10)
+
+	exitLong("ABC", 99, 10, ReasonManual)
+	if !hasPosition("ABC", models.Long) || !hasPendingExit("ABC", models.Long) {
+		t.Fatal("protected exit booked before it filled")
+	}
+	sent := len(paperOrders)
+	exitLong("ABC", 98, 10, ReasonFixedSL)
+	if len(paperOrders) != sent {
+		t.Error("second exit sent while a protected exit was working")
+	}
+
+	matchPaperOrders("ABC", 99)
+	pollPendingExits()
+	if hasPosition("ABC", models.Long) || hasPendingExit("ABC", models.Long) {
+		t.Fatal("position still open after its protected exit filled")
+	}
+	if tr := lastTrade(t); tr.Reason != ReasonManual || tr.ExitPrice != 98.75 || tr.Qty != 10 {
+		t.Errorf("trade = %s %d @ %v, want manual 10 @ 98.75", tr.Reason, tr.Qty, tr.ExitPrice)
+	}
+}
+
+func TestProtectedExitEscalates(t *testing.T) {
+	resetBooks(t)
+	defer func(typ string) { exitPriceType = typ }(exitPriceType)
+	exitPriceType = exitProtected
+	seedShort("XYZ", 100, 10)
+
+	exitShort("XYZ", 101, 10, ReasonFixedSL)
+	ids := slices.Collect(maps.Keys(pendingExits))
+	if len(ids) != 1 {
+		t.Fatalf("%d protected exits pending, want 1", len(ids))
+	}
+	id := ids[0]
+	// Four fill in two lots, then the rest sits past the TTL.
+	paperOrders[id].Status.FilledQty, paperOrders[id].Status.AvgPrice = 2, 101
+	pollPendingExits()
+	paperOrders[id].Status.FilledQty, paperOrders[id].Status.AvgPrice = 4, 101.5
+	e := pendingExits[id]
+	e.Placed = e.Placed.Add(-protectedExitTTL)
+	pendingExits[id] = e
+	pollPendingExits()
+
+	if hasPosition("XYZ", models.Short) || hasPendingExit("XYZ", models.Short) {
+		t.Fatal("expired protected exit left the position open")
+	}
+	if paperOrders[id].Status.Status != client.StatusCancelled {
+		t.Error("expired protected exit not cancelled")
+	}
+	var qty []int
+	var px []float64
+	for _, tr := range tradeHistory {
+		qty, px = append(qty, tr.Qty), append(px, tr.ExitPrice)
+	}
+	if !slices.Equal(qty, []int{2, 2, 6}) || px[0] != 101 || px[1] != 102 {
+		t.Errorf("booked %v @ %v, want 2 @ 101, 2 @ 102 and the 6 left at market", qty, px)
+	}
+}
+
+// escalationBroker leaves limit orders open until cancelled and holds
+// market orders until release is closed, signalling on sending.
+type escalationBroker struct {
+	client.Broker
+	mu        sync.Mutex
+	orders    []client.OrderParams
+	cancelled bool
+	sending   chan struct{}
+	release   chan struct{}
+}
+
+func (b *escalationBroker) PlaceOrder(ctx context.Context, p client.OrderParams) (string, error) {
+	b.mu.Lock()
+	b.orders = append(b.orders, p)
+	id := fmt.Sprintf("ORD-%d", len(b.orders))
+	b.mu.Unlock()
+	if p.PriceType == client.PriceMarket {
+		close(b.sending)
+		<-b.release
+	}
+	return id, nil
+}
+
+func (b *escalationBroker) GetOrderStatus(ctx context.Context, id string) (client.OrderStatus, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cancelled {
+		return client.OrderStatus{OrderNo: id, Status: client.StatusCancelled}, nil
+	}
+	return client.OrderStatus{OrderNo: id, Status: client.StatusOpen}, nil
+}
+
+func (b *escalationBroker) CancelOrder(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cancelled = true
+	return nil
+}
+
+func TestExitRefusedDuringEscalation(t *testing.T) {
+	resetBooks(t)
+	oldBroker := broker
+	defer func(typ string) { exitPriceType, broker = typ, oldBroker }(exitPriceType)
+	exitPriceType, paperTrading = exitProtected, false
+	eb := &escalationBroker{sending: make(chan struct{}), release: make(chan struct{})}
+	broker = eb
+	seedShort("XYZ", 100, 10)
+
+	exitShort("XYZ", 101, 10, ReasonFixedSL)
+	mu.Lock()
+	for id, e := range pendingExits {
+		e.Placed = e.Placed.Add(-protectedExitTTL)
+		pendingExits[id] = e
+	}
+	mu.Unlock()
+
+	polled := make(chan struct{})
+	go func() { pollPendingExits(); close(polled) }()
+	<-eb.sending
+
+	// A control exit arrives while the rest is on its way at market.
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		defer lockExits("XYZ")()
+		exitShort("XYZ", 101, 10, ReasonManual)
+	}()
+	select {
+	case <-exited:
+		t.Error("exit went ahead during the escalation")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(eb.release)
+	<-polled
+	<-exited
+
+	if len(eb.orders) != 2 {
+		t.Errorf("sent %d orders, want the protected exit and its escalation only", len(eb.orders))
+	}
+	if hasPosition("XYZ", models.Short) || len(tradeHistory) != 1 {
+		t.Errorf("position open = %v, %d exits booked; want flat with one exit", hasPosition("XYZ", models.Short), len(tradeHistory))
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+var tradeCSVHeader = []string{
+	"symbol", "direction", "entry_time", "entry_price",
+	"exit_time", "exit_price", "qty", "pnl", "reason",
+}
+
+// exportTradesCSV appends trades to trades-YYYY-MM-DD.csv in logDir, writing the
+// header only when the file is created.
+func exportTradesCSV(trades []TradeRecord, day time.Time) (string, error) {
+	path := filepath.Join(logDir, fmt.Sprintf("trades-%s.csv", day.Format("2006-01-02")))
+
+	_, statErr := os.Stat(path)
+	isNew := os.IsNotExist(statErr)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return path, err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if isNew {
+		w.Write(tradeCSVHeader)
+	}
+	for _, t := range trades {
+		w.Write([]string{
+			t.Symbol,
+			t.Direction,
+			t.EntryTime.Format(time.RFC3339),
+			plainMoney(t.EntryPrice),
+			t.ExitTime.Format(time.RFC3339),
+			plainMoney(t.ExitPrice),
+			strconv.Itoa(t.Qty),
+			plainMoney(t.PnL),
+			string(t.Reason),
+		})
+	}
+	w.Flush()
+	return path, w.Error()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/may-bach/Axiom/internal/client"
+)
+
+// Fill-price policies: the price recorded in positions and TradeRecords
+// for an order placed at the polling ltp.
+const (
+	fillLTP          = "ltp"          // the polled ltp
+	fillActual       = "actual"       // the order's average price from its status
+	fillConservative = "conservative" // ask for buys, bid for sells
+)
+
+var fillPolicy = fillLTP
+
+func setFillPolicy(v string) error {
+	switch v {
+	case fillLTP, fillActual, fillConservative:
+		fillPolicy = v
+		return nil
+	}
+	return fmt.Errorf("unknown fill-price policy %q (want ltp, actual or conservative)", v)
+}
+
+// fillPrice is the price recorded for orderID, placed on side at ltp,
+// under fillPolicy. "actual" falls back to "conservative" when the order
+// has no average price yet, or orderID is empty. Paper fills are never
+// recorded at the bare ltp: with no order to price them, "ltp" is
+// "conservative" there too.
+func fillPrice(sym string, side client.Side, ltp float64, orderID string) float64 {
+	switch fillPolicy {
+	case fillLTP:
+		if !paperTrading {
+			return ltp
+		}
+	case fillActual:
+		if orderID != "" {
+			st, err := orderStatus(orderID)
+			if err == nil && st.AvgPrice > 0 {
+				return st.AvgPrice
+			}
+			if err != nil {
+				log.Printf("Fill price for %s order %s unavailable: %v", sym, orderID, err)
+			}
+		}
+	}
+	return conservativePrice(sym, side, ltp)
+}
+
+// conservativePrice takes the ask for buys and the bid for sells when the
+// book is known, otherwise ltp slipped against us by the symbol class's
+// slippage.
+func conservativePrice(sym string, side client.Side, ltp float64) float64 {
+	mu.Lock()
+	var bid, ask float64
+	if ms, ok := markets[sym]; ok {
+		bid, ask = ms.Bid, ms.Ask
+	}
+	mu.Unlock()
+	if side == client.Buy && ask > 0 {
+		return ask
+	}
+	if side == client.Sell && bid > 0 {
+		return bid
+	}
+
+	bps, ok := slippageBps[getStrategy(sym).Class]
+	if !ok {
+		bps = defaultSlippageBps
+	}
+	if side == client.Buy {
+		return ltp * (1 + bps/10000)
+	}
+	return ltp * (1 - bps/10000)
+}
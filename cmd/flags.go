@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+)
+
+var (
+	stocksPath      = dataPath("stocks.json")
+	brainConfigPath = dataPath("config.json")
+	statusPort      = 0 // 0 disables the status server
+	squareOffOnExit = false
+	secretsPath     = "" // optional KEY=VALUE credentials file
+	accountName     = "" // credentials prefix: "" reads FLAT_*, "alt" reads FLAT_ALT_*
+)
+
+// parseFlags overrides the compiled-in defaults from the command line.
+// Trading is paper-only unless -live is given explicitly.
+func parseFlags() {
+	apply := registerFlags()
+	flag.Parse()
+	resolveDataFiles(flag.CommandLine)
+	apply()
+}
+
+// registerFlags defines every bot flag on flag.CommandLine. The returned
+// apply hands the parsed values that are not bound to a variable on to
+// their packages.
+func registerFlags() (apply func()) {
+	live := flag.Bool("live", false, "place real orders (default is paper trading)")
+	flag.Float64Var(&defaultBudget, "budget", defaultBudget, "capital per entry before leverage (ignored when -capital is set)")
+	flag.Float64Var(&tradingCapital, "capital", tradingCapital, "total capital shared by all positions; 0 gives every entry -budget with no overall limit")
+	flag.Func("alloc", "how -capital is split: slots (capital / -max-positions per entry, default) or dynamic (free capital / open slots left)", setAllocMode)
+	flag.IntVar(&defaultMaxPositions, "max-positions", defaultMaxPositions, "maximum open positions across both directions")
+	flag.IntVar(&defaultMaxLongs, "max-longs", defaultMaxLongs, "maximum open long positions")
+	flag.IntVar(&defaultMaxShorts, "max-shorts", defaultMaxShorts, "maximum open short positions")
+	flag.IntVar(&defaultMaxPerSector, "max-per-sector", defaultMaxPerSector, "maximum open positions in one sector (symbols with a sector tag only)")
+	dirFlags(flag.CommandLine)
+	flag.StringVar(&stocksPath, "stocks", stocksPath, "path to the watchlist JSON")
+	flag.StringVar(&symbolOverridesPath, "symbol-overrides", symbolOverridesPath, "JSON map of symbols to pinned tokens and trading symbols, bypassing SearchScrip")
+	flag.StringVar(&brainConfigPath, "config", brainConfigPath, "path to the per-symbol strategy config written by brain.py")
+	flag.StringVar(&performancePath, "performance", performancePath, "daily performance history read by the report subcommand")
+	flag.StringVar(&instrumentsURL, "instruments-url", instrumentsURL, "instrument master (CSV or zip) used to map symbols before falling back to SearchScrip")
+	flag.StringVar(&instrumentsPath, "instruments-cache", instrumentsPath, "where the day's instrument master is cached")
+	flag.StringVar(&marketSnapshotPath, "market-state", marketSnapshotPath, "where session high/low and tick history are snapshotted for same-day restarts")
+	flag.BoolVar(&apiDebug, "api-debug", apiDebug, "log every API request and response, secrets redacted, to api-debug.jsonl in -log-dir")
+	flag.BoolVar(&recordTicks, "record-ticks", recordTicks, "append every fetched quote to ticks-YYYY-MM-DD.jsonl in -data-dir for replay")
+	flag.DurationVar(&marketSnapshotEvery, "market-state-every", marketSnapshotEvery, "how often to snapshot market state (0 disables saving and restoring it)")
+	flag.StringVar(&accountName, "account", accountName, "trade the named account, whose credentials are FLAT_<NAME>_API_KEY etc. (default FLAT_*)")
+	flag.StringVar(&secretsPath, "secrets", secretsPath, "optional KEY=VALUE file with FLAT_* credentials (env and .env take precedence)")
+	flag.IntVar(&statusPort, "port", statusPort, "port for the HTTP status server (0 disables it)")
+	flag.DurationVar(&dedupWindow, "dedup-window", dedupWindow, "suppress a repeat entry on the same symbol and side accepted within this window (exits are never suppressed)")
+	flag.IntVar(&signalQueueSize, "signal-queue", signalQueueSize, "signals held while max positions is reached, entered when a slot frees (0 drops them)")
+	flag.DurationVar(&signalQueueTTL, "signal-queue-ttl", signalQueueTTL, "how long a queued signal stays valid")
+	flag.Float64Var(&maxLeverage, "max-leverage", maxLeverage, "cap on per-symbol leverage from the strategy config (0 disables)")
+	flag.Float64Var(&maxStrengthScale, "max-strength-scale", maxStrengthScale, "scale entry budgets by signal strength up to this multiple (1 disables)")
+	flag.Func("sizing", "entry sizing: fixed (budget / price, default) or atr (also risk at most -atr-risk over -atr-multiple ATRs)", setSizingMode)
+	flag.IntVar(&atrLookback, "atr-lookback", atrLookback, "ticks the ATR for -sizing atr is averaged over")
+	flag.Float64Var(&atrMultiple, "atr-multiple", atrMultiple, "ATRs of adverse move -sizing atr sizes for")
+	flag.Float64Var(&atrRisk, "atr-risk", atrRisk, "rupees an entry may lose over -atr-multiple ATRs under -sizing atr")
+	flag.Float64Var(&maxSymbolNotional, "max-symbol-notional", maxSymbolNotional, "cap on one symbol's open qty * price, overridden by max_notional in config.json (0 disables)")
+	flag.Float64Var(&maxTotalNotional, "max-total-notional", maxTotalNotional, "cap on the open qty * price of all positions and pending entries (0 disables)")
+	flag.IntVar(&selfTestSample, "selftest-sample", selfTestSample, "symbols whose LTP is checked at startup (0 skips the self-test)")
+	flag.Float64Var(&selfTestMinRatio, "selftest-min-ratio", selfTestMinRatio, "fraction of the self-test sample that must answer")
+	flag.IntVar(&selfTestRetries, "selftest-retries", selfTestRetries, "extra LTP attempts per self-test symbol")
+	flag.BoolVar(&selfTestWarnOnly, "selftest-warn-only", selfTestWarnOnly, "warn instead of aborting startup when the self-test fails")
+	flag.Float64Var(&costs.BrokeragePerOrder, "brokerage", costs.BrokeragePerOrder, "flat brokerage per executed order, used by the cost model")
+	flag.Float64Var(&minNetProfit, "min-net-profit", minNetProfit, "refuse entries whose target would net less than this after costs (negative disables)")
+	flag.Float64Var(&minEntryPrice, "min-price", minEntryPrice, "skip entries below this LTP (0 disables)")
+	flag.Float64Var(&maxEntryPrice, "max-price", maxEntryPrice, "skip entries above this LTP (0 disables)")
+	flag.Float64Var(&maxSpreadPercent, "max-spread", maxSpreadPercent, "skip entries when the bid-ask spread exceeds this percent of mid (0 disables)")
+	flag.Float64Var(&maxDayChangeLong, "max-day-change-long", maxDayChangeLong, "skip longs once a symbol is up more than this percent on the previous close (0 disables)")
+	flag.Float64Var(&maxDayChangeShort, "max-day-change-short", maxDayChangeShort, "skip shorts once a symbol is down more than this percent on the previous close (0 disables)")
+	flag.Float64Var(&maxVWAPExtension, "max-vwap-extension", maxVWAPExtension, "skip entries once LTP is more than this percent above the VWAP for longs, below it for shorts (0 disables)")
+	flag.Float64Var(&maxOpenExtension, "max-open-extension", maxOpenExtension, "skip entries once LTP is more than this percent above the day's open for longs, below it for shorts (0 disables)")
+	flag.IntVar(&maxConsecutiveLosses, "max-symbol-losses", maxConsecutiveLosses, "bench a symbol for the day after this many losing trades in a row on it, overridden by max_consecutive_losses in config.json (0 disables)")
+	flag.DurationVar(&maxQuoteAge, "max-quote-age", maxQuoteAge, "skip entries when the quote's feed time is older than this")
+	moneyFlags(flag.CommandLine)
+	flag.Func("output", "format of the daily summary and /status: text (log lines; /status stays JSON, default), json or yaml", setOutputFormat)
+	flag.Func("entry-match", "which of several strategies firing on one tick enters: first (in evaluation order, default) or strongest (highest signal strength)", setEntryMatch)
+	flag.Func("decision-price", "price compared with entry and exit thresholds: last (the latest tick, default) or sma (smoother but laggier average of -decision-window ticks)", setDecisionPrice)
+	flag.IntVar(&decisionWindow, "decision-window", decisionWindow, "ticks averaged when -decision-price is sma")
+	flag.Func("indicator-window", "ticks of history kept for strategies' SMA/EMA lookbacks, at least 1 (default 20)", setIndicatorWindow)
+	flag.Func("exit-type", "exit order pricing: market (default) or protected (limit -exit-protection-ticks through the ltp)", setExitPriceType)
+	flag.Func("stops", "stop-loss owner: bot (local exit rules, default) or exchange (resting SL-MKT and target orders, local exits off; never mix the two)", setStopMode)
+	flag.IntVar(&exitProtectionTicks, "exit-protection-ticks", exitProtectionTicks, "ticks between the ltp and a protected exit's limit")
+	flag.DurationVar(&protectedExitTTL, "exit-protection-ttl", protectedExitTTL, "how long a protected exit may rest unfilled before its rest is sent at market")
+	flag.Func("partial-fill", "rest of a partially filled entry: accept (keep it working until it fills or expires, default), cancel, or chase (re-send at market up to -partial-retries times); overridden by partial_fill in config.json", setPartialFillPolicy)
+	flag.IntVar(&partialChaseRetries, "partial-retries", partialChaseRetries, "fresh orders the chase policy sends for the rest of one entry")
+	flag.Func("fill-price", "price recorded for live fills: ltp (default), actual (order average price) or conservative (ask/bid); paper fills always take the ask/bid or -slippage-bps", setFillPolicy)
+	flag.Func("slippage-bps", "paper-fill slippage per class, e.g. A=2,B=5,C=10", parseSlippage)
+	flag.DurationVar(&reentryWindow, "reentry-window", reentryWindow, "time after a target exit in which a new high (low for shorts) re-enters symbols with allow_reentry")
+	flag.DurationVar(&pendingOrderTTL, "pending-order-ttl", pendingOrderTTL, "cancel limit entries still unfilled after this long")
+	flag.Func("square-off-at", "HH:MM (IST) to exit every open position (default 15:10)", func(v string) error {
+		m, err := parseClock(v)
+		squareOffAt = m
+		return err
+	})
+	flag.Func("summary-at", "HH:MM (IST) for the daily summary and reset (default 15:30)", func(v string) error {
+		m, err := parseClock(v)
+		summaryAt = m
+		return err
+	})
+	flag.Func("market-open", "HH:MM (IST) of the open; the first tick after it on a new day resets the day's state (default 09:15)", func(v string) error {
+		m, err := parseClock(v)
+		marketOpenAt = m
+		return err
+	})
+	flag.BoolVar(&openReset, "open-reset", openReset, "reset the day's state on the first tick after -market-open on a new day")
+	flag.DurationVar(&brainRefreshEvery, "brain-every", brainRefreshEvery, "time between brain.py config refreshes (0 disables)")
+	flag.Func("brain-cmd", "command run from -data-dir to refresh the config, e.g. \"python3 brain.py\" or \".venv/bin/python brain.py\" (default \"python brain.py\")", setBrainCmd)
+	flag.Func("brain-env", "KEY=VALUE added to the brain command's environment; repeatable", addBrainEnv)
+	flag.Func("entry-windows", "comma-separated HH:MM-HH:MM (IST) ranges entries are limited to, e.g. 09:30-11:30,13:00-14:30; overridden by entry_windows in config.json (default the whole session)", setEntryWindows)
+	flag.Func("no-entries-after", "HH:MM (IST) after which only exits are managed (default 14:50)", func(v string) error {
+		m, err := parseClock(v)
+		noEntriesAfter = m
+		return err
+	})
+	flag.Func("priority", "comma-separated symbols polled, squared off and checked first, in this order; the rest follow alphabetically", setSymbolPriority)
+	flag.IntVar(&pollWorkers, "workers", pollWorkers, "symbols fetched concurrently per tick")
+	flag.Func("shadow", "comma-separated strategies to run in shadow (signals logged, never ordered)", setShadow)
+	flag.DurationVar(&sessionRefreshAfter, "session-refresh-after", sessionRefreshAfter, "renew the session token once it is this old (0 refreshes only on expiry errors)")
+	flag.DurationVar(&sessionRetryAfter, "session-retry-after", sessionRetryAfter, "wait between failed proactive session refreshes")
+	flag.DurationVar(&pollInterval, "poll-interval", pollInterval, "time between polling ticks")
+	flag.DurationVar(&minRefetch, "min-refetch", minRefetch, "least time between two quotes for the same symbol, even one with an open position (0 disables)")
+	flag.Func("class-poll", "poll interval per class, e.g. A=5s,B=15s,C=15s (unlisted classes use -poll-interval)", parseClassPoll)
+	flag.Float64Var(&jitterPercent, "jitter", jitterPercent, "randomise the poll interval and spread requests by up to this percent (0 disables)")
+	flag.Int64Var(&randSeed, "seed", randSeed, "seed for jitter randomness (0 seeds from the clock)")
+	flag.DurationVar(&haltPollInterval, "halt-poll", haltPollInterval, "poll interval for symbols whose trading is halted or suspended")
+	flag.DurationVar(&deadManAfter, "dead-man", deadManAfter, "flatten everything and pause entries when no control API request arrives for this long (0 disables; needs -port and AXIOM_CONTROL_TOKEN)")
+	flag.DurationVar(&maxAPILatency, "max-latency", maxAPILatency, "p95 API latency above which flat symbols are polled less often and class C ones shed (0 disables)")
+	flag.DurationVar(&stallAfter, "stall-after", stallAfter, "alert when no tick completes for this long (0 = twice the poll interval)")
+	rateLimit := flag.Float64("rate-limit", client.DefaultRateLimit, "maximum API requests per second (0 disables limiting)")
+	circuitFailures := flag.Int("circuit-failures", client.DefaultCircuitFailures, "consecutive API failures that suspend requests (0 disables the circuit breaker)")
+	circuitCooldown := flag.Duration("circuit-cooldown", client.DefaultCircuitCooldown, "wait before probing the API after the circuit opens; doubles on each failed probe")
+	flag.IntVar(&remapAfterFailures, "remap-after", remapAfterFailures, "consecutive LTP errors before a symbol's token is searched again")
+	flag.BoolVar(&useBracketOrders, "bracket", useBracketOrders, "enter with bracket orders; target and SL are then managed by the exchange")
+	flag.DurationVar(&maxHoldTime, "max-hold", maxHoldTime, "exit positions held this long without SL or target (0 disables)")
+	flag.IntVar(&warmupTicks, "warmup-ticks", warmupTicks, "ticks per symbol before entries are allowed")
+	flag.DurationVar(&warmupPeriod, "warmup", warmupPeriod, "time per symbol since first tick before entries are allowed")
+	flag.BoolVar(&squareOffOnExit, "square-off-on-exit", squareOffOnExit, "square off open positions on SIGINT/SIGTERM")
+
+	return func() {
+		client.SetRateLimit(*rateLimit)
+		client.SetCircuitBreaker(*circuitFailures, *circuitCooldown)
+		seedJitter(randSeed)
+
+		paperTrading = !*live
+	}
+}
+
+// setEntryWindows reads the -entry-windows ranges into entryWindows.
+func setEntryWindows(v string) error {
+	ranges := strings.Split(v, ",")
+	if _, err := parseWindows(ranges); err != nil {
+		return err
+	}
+	entryWindows = ranges
+	return nil
+}
+
+// parseClassPoll reads "CLASS=duration" pairs into classPollIntervals.
+func parseClassPoll(v string) error {
+	for _, pair := range strings.Split(v, ",") {
+		class, d, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return fmt.Errorf("expected CLASS=duration, got %q", pair)
+		}
+		interval, err := time.ParseDuration(d)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid interval for class %s: %q", class, d)
+		}
+		classPollIntervals[strings.ToUpper(class)] = interval
+	}
+	return nil
+}
+
+// parseSlippage reads "CLASS=bps" pairs into slippageBps.
+func parseSlippage(v string) error {
+	for _, pair := range strings.Split(v, ",") {
+		class, bps, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return fmt.Errorf("expected CLASS=bps, got %q", pair)
+		}
+		f, err := strconv.ParseFloat(bps, 64)
+		if err != nil {
+			return fmt.Errorf("invalid bps for class %s: %v", class, err)
+		}
+		slippageBps[strings.ToUpper(class)] = f
+	}
+	return nil
+}
+
+// setIndicatorWindow sets indicatorWindow, which must be positive.
+func setIndicatorWindow(v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return fmt.Errorf("indicator window must be a positive number of ticks, got %q", v)
+	}
+	indicatorWindow = n
+	return nil
+}
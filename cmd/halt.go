@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/notify"
+)
+
+var (
+	// haltPollInterval is how often a halted symbol is polled, with or
+	// without an open position, to notice when trading resumes.
+	haltPollInterval = time.Minute
+
+	halted = make(map[string]time.Time) // halted symbols and when the halt was seen; guarded by mu
+)
+
+// markHalted records a halt quote for sym. The first one pushes its next
+// poll out to haltPollInterval, which duePolls keeps to from then on, and
+// is notified.
+func markHalted(sym string, err error, now time.Time) {
+	mu.Lock()
+	_, already := halted[sym]
+	if !already {
+		halted[sym] = now
+		nextPoll[sym] = now.Add(haltPollInterval)
+	}
+	_, long := longPositions[sym]
+	_, short := shortPositions[sym]
+	mu.Unlock()
+
+	if already {
+		return
+	}
+	msg := fmt.Sprintf("HALTED %s: %v - entries skipped, polling every %s", sym, err, haltPollInterval)
+	if long || short {
+		msg += " (position open, exit checks resume with trading)"
+	}
+	notifyTrade(notify.EventError, msg)
+}
+
+// clearHalted notes that sym is quoting again after a halt.
+func clearHalted(sym string, now time.Time) {
+	mu.Lock()
+	since, ok := halted[sym]
+	delete(halted, sym)
+	mu.Unlock()
+
+	if ok {
+		notifyTrade(notify.EventError, fmt.Sprintf("RESUMED %s: quoting again after %s halted", sym, now.Sub(since).Round(time.Second)))
+	}
+}
+
+// isHalted reports whether sym's last quote was a halt.
+func isHalted(sym string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	_, ok := halted[sym]
+	return ok
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+var (
+	// jitterPercent randomises the tick interval by up to ± this percent
+	// and spreads each tick's requests over up to this percent of the
+	// interval, so restarted or parallel instances drift apart instead of
+	// hitting the API in lockstep. 0 disables jitter.
+	jitterPercent = 5.0
+
+	// randSeed seeds the jitter source; 0 seeds from the clock. A fixed
+	// seed makes tick timing reproducible in tests and backtests.
+	randSeed int64
+
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0))
+)
+
+// seedJitter reseeds the jitter source with seed, or from the clock when
+// seed is 0.
+func seedJitter(seed int64) {
+	s := uint64(seed)
+	if seed == 0 {
+		s = uint64(time.Now().UnixNano())
+	}
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	jitterRand = rand.New(rand.NewPCG(s, 0))
+}
+
+// randFloat returns a value in [0, 1) from the jitter source.
+func randFloat() float64 {
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	return jitterRand.Float64()
+}
+
+// jittered returns d shifted by a random amount within ± jitterPercent.
+func jittered(d time.Duration) time.Duration {
+	if jitterPercent <= 0 {
+		return d
+	}
+	spread := float64(d) * jitterPercent / 100
+	return d + time.Duration((2*randFloat()-1)*spread)
+}
+
+// staggerDelay is the random pause between handing out two of a tick's n
+// requests, so the whole tick spreads over at most jitterPercent of the
+// tick interval.
+func staggerDelay(n int) time.Duration {
+	if jitterPercent <= 0 || n <= 1 {
+		return 0
+	}
+	window := float64(tickInterval()) * jitterPercent / 100
+	return time.Duration(randFloat() * window / float64(n))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitteredStaysWithinBand(t *testing.T) {
+	defer func(p float64) { jitterPercent = p }(jitterPercent)
+	jitterPercent = 10
+	seedJitter(42)
+
+	base := 10 * time.Second
+	for range 1000 {
+		d := jittered(base)
+		if d < 9*time.Second || d > 11*time.Second {
+			t.Fatalf("jittered(%s) = %s, outside ±10%%", base, d)
+		}
+	}
+
+	jitterPercent = 0
+	if d := jittered(base); d != base {
+		t.Errorf("jitter disabled: got %s, want %s", d, base)
+	}
+}
+
+func TestSeedJitterIsReproducible(t *testing.T) {
+	defer func(p float64) { jitterPercent = p }(jitterPercent)
+	jitterPercent = 5
+
+	run := func() []time.Duration {
+		seedJitter(7)
+		var out []time.Duration
+		for range 5 {
+			out = append(out, jittered(time.Second))
+		}
+		return out
+	}
+	a, b := run(), run()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("seeded runs diverge at %d: %s vs %s", i, a[i], b[i])
+		}
+	}
+}
+
+func TestStaggerDelaySpreadsTickWindow(t *testing.T) {
+	defer func(p float64, i time.Duration) { jitterPercent, pollInterval = p, i }(jitterPercent, pollInterval)
+	jitterPercent, pollInterval = 5, 10*time.Second
+	seedJitter(1)
+
+	// 20 requests share a 500ms window: each gap is below 25ms.
+	for range 100 {
+		if d := staggerDelay(20); d < 0 || d >= 25*time.Millisecond {
+			t.Fatalf("staggerDelay(20) = %s", d)
+		}
+	}
+	if d := staggerDelay(1); d != 0 {
+		t.Errorf("single request staggered by %s", d)
+	}
+}
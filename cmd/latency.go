@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/notify"
+)
+
+const (
+	latencySlowdown   = 2   // flat symbols are polled this many times less often while degraded
+	latencyShedClass  = "C" // flat symbols of this class are not polled at all while degraded
+	latencyRecovery   = 0.8 // degradation ends once p95 is under this fraction of maxAPILatency
+	minLatencySamples = 10  // requests needed before p95 is trusted
+)
+
+var (
+	// maxAPILatency is the p95 request latency above which polling
+	// degrades: the circuit breaker handles an API that fails, this one
+	// that is slow enough for the loop to fall behind and quotes to go
+	// stale. Held symbols are still polled every tick. 0, the default,
+	// disables it.
+	maxAPILatency time.Duration
+
+	apiLatency      = client.APILatency // replaced in tests
+	latencyDegraded atomic.Bool
+)
+
+// checkLatency degrades polling when the API's p95 latency passes
+// maxAPILatency and restores it once p95 has settled back under
+// latencyRecovery of it, notifying both changes.
+func checkLatency() {
+	stats := apiLatency()
+	if maxAPILatency <= 0 || stats.Samples < minLatencySamples {
+		return
+	}
+
+	degraded := latencyDegraded.Load()
+	switch {
+	case !degraded && stats.P95 > maxAPILatency:
+		latencyDegraded.Store(true)
+		notifyTrade(notify.EventError, fmt.Sprintf("API SLOW: p95 latency %s over %s - polling flat symbols %dx less often, class %s shed",
+			stats.P95.Round(time.Millisecond), maxAPILatency, latencySlowdown, latencyShedClass))
+	case degraded && float64(stats.P95) < latencyRecovery*float64(maxAPILatency):
+		latencyDegraded.Store(false)
+		notifyTrade(notify.EventError, fmt.Sprintf("API latency recovered: p95 %s - normal polling resumed", stats.P95.Round(time.Millisecond)))
+	}
+}
+
+// shedForLatency reports whether flat sym is skipped while the API is slow.
+func shedForLatency(sym string) bool {
+	return latencyDegraded.Load() && getStrategy(sym).Class == latencyShedClass
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+func TestLatencyDegradesPolling(t *testing.T) {
+	resetBooks(t)
+	defer func(f func() client.LatencyStats) { apiLatency = f }(apiLatency)
+	defer latencyDegraded.Store(false)
+	defer func(d, p time.Duration) { maxAPILatency, pollInterval = d, p }(maxAPILatency, pollInterval)
+	maxAPILatency, pollInterval = 500*time.Millisecond, 10*time.Second
+
+	stats := client.LatencyStats{P95: 200 * time.Millisecond, Samples: 50}
+	apiLatency = func() client.LatencyStats { return stats }
+	stockStrategies["LIQ"] = models.StockStrategy{Class: "A"}
+	stockStrategies["THIN"] = models.StockStrategy{Class: "C"}
+	stockStrategies["HELD"] = models.StockStrategy{Class: "C"}
+	seedLong("HELD", 100, 10)
+	tokens := map[string]string{"LIQ": "1", "THIN": "2", "HELD": "3"}
+
+	checkLatency()
+	if latencyDegraded.Load() || symbolPollInterval("LIQ") != 10*time.Second {
+		t.Fatal("degraded at normal latency")
+	}
+
+	// A slow p95 over too few requests is not trusted yet.
+	stats = client.LatencyStats{P95: 2 * time.Second, Samples: minLatencySamples - 1}
+	checkLatency()
+	if latencyDegraded.Load() {
+		t.Fatal("degraded on too few samples")
+	}
+
+	stats.Samples = 50
+	checkLatency()
+	if !latencyDegraded.Load() {
+		t.Fatal("not degraded with p95 over the limit")
+	}
+	if d := symbolPollInterval("LIQ"); d != 20*time.Second {
+		t.Errorf("degraded poll interval = %s, want 20s", d)
+	}
+	now := time.Now()
+	due := duePolls(tokens, now)
+	if _, ok := due["THIN"]; ok {
+		t.Error("flat class C symbol polled while degraded")
+	}
+	if _, ok := due["HELD"]; !ok {
+		t.Error("held class C symbol shed while degraded")
+	}
+	if _, ok := due["LIQ"]; !ok {
+		t.Error("class A symbol shed while degraded")
+	}
+
+	rec := httptest.NewRecorder()
+	handleMetrics(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "axiom_api_degraded 1\n") || !strings.Contains(body, `axiom_api_latency_seconds{quantile="0.95"} 2`) {
+		t.Errorf("metrics do not show the degradation:\n%s", body)
+	}
+
+	// Just under the limit is not yet a recovery.
+	stats.P95 = 450 * time.Millisecond
+	checkLatency()
+	if !latencyDegraded.Load() {
+		t.Fatal("recovered before latency settled")
+	}
+	stats.P95 = 300 * time.Millisecond
+	checkLatency()
+	if latencyDegraded.Load() {
+		t.Fatal("still degraded after latency recovered")
+	}
+	if d := symbolPollInterval("LIQ"); d != 10*time.Second {
+		t.Errorf("recovered poll interval = %s, want 10s", d)
+	}
+	if _, ok := duePolls(tokens, now.Add(time.Minute))["THIN"]; !ok {
+		t.Error("class C symbol not polled after recovery")
+	}
+}
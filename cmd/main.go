@@ -1,86 +1,183 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"math"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/may-bach/Axiom/internal/auth"
 	"github.com/may-bach/Axiom/internal/client"
 	"github.com/may-bach/Axiom/internal/config"
-	"github.com/may-bach/Axiom/internal/session"
+	"github.com/may-bach/Axiom/internal/instruments"
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/notify"
+	"github.com/may-bach/Axiom/internal/state"
 	"github.com/may-bach/Axiom/internal/stocks"
+	"github.com/may-bach/Axiom/internal/strategy"
 )
 
 var (
 	symbolToToken map[string]string
+	tickSizes     = make(map[string]float64) // from SearchScrip/GetQuotes "ti"
+	lotSizes      = make(map[string]int)     // from SearchScrip "ls"; 1 when unknown
 	mu            sync.Mutex
-	highLow       = make(map[string]struct{ High, Low float64 })
-	ltpHistory    = make(map[string][]float64)
-	longPositions = make(map[string]struct {
-		EntryPrice, HighestPrice float64
-		Qty                      int
-		EntryTime                time.Time // added for better P&L tracking
-	})
-	shortPositions = make(map[string]struct {
-		EntryPrice, LowestPrice float64
-		Qty                     int
-		EntryTime               time.Time // added
-	})
-	stockStrategies = make(map[string]StockStrategy)
+	entryMu       sync.Mutex // held across an entry decision, never while holding mu
+	markets                  = make(map[string]*state.MarketState)
+	// Consecutive ticks each strategy's condition has held, by symbol|strategy
+	signalStreaks   = make(map[string]int)
+	longPositions   = make(map[string]position)
+	shortPositions  = make(map[string]position)
+	stockStrategies = make(map[string]models.StockStrategy)
+
+	notifier notify.Notifier = notify.Log{}
+
+	// Symbols that take no new entries; open positions are still managed.
+	disabledSymbols      = make(map[string]bool)
+	entriesPaused   bool // set from the control API; exits keep running
+
+	// Symbols that may be sold short, from stocks.json; nil allows all.
+	shortableSymbols map[string]bool
+
+	// account is the Flattrade login this run trades, chosen by -account.
+	account *client.Account
+
+	// Market data and order routing can come from different vendors; both
+	// default to account's Client at startup.
+	quotes client.QuoteProvider
+	broker client.Broker
 
 	defaultBudget          = 100000.0
 	defaultMaxPositions    = 8
+	defaultMaxLongs        = 5
+	defaultMaxShorts       = 5
+	defaultMaxPerSector    = 3 // only applies to symbols with a sector tag
 	defaultBuffer          = 0.002
 	defaultBounceRebound   = 0.008
 	defaultQuickDrop       = 0.012
 	defaultFixedSLPercent  = 1.0
 	defaultTargetPercent   = 2.0
 	defaultTrailingPercent = 1.0
+	defaultTrailActivate   = 0.0 // profit % before the trailing SL arms; 0 trails from entry
 	defaultLeverage        = 1.0
+	maxLeverage            = 5.0 // caps per-symbol leverage from config.json; 0 disables
+	maxStrengthScale       = 1.0 // budget multiplier cap for strong signals; 1 sizes every signal equally
+	maxSymbolNotional      = 0.0 // cap on one symbol's open qty * price; 0 disables
+	maxTotalNotional       = 0.0 // cap on the whole book's open qty * price; 0 disables
 	historyWindow          = 3
+	indicatorWindow        = 20 // longest SMA/EMA lookback strategies may ask for
+
+	// Entry strategies in evaluation order; entryMatch decides which of
+	// several firing on one tick opens the position.
+	entryStrategies = []registeredStrategy{
+		{Strategy: strategy.BreakoutLong{}},
+		{Strategy: strategy.BounceBack{}},
+		{Strategy: strategy.BreakdownShort{}},
+		{Strategy: strategy.QuickDrop{}},
+		{Strategy: strategy.GapUp{}},
+		{Strategy: strategy.GapDown{}},
+	}
 
 	// ────────────────────────────────────────────────
 	// NEW FEATURES
 	// ────────────────────────────────────────────────
-	paperTrading   = true // ← Set to false for LIVE trading
-	tradeLogFile   *os.File
-	dailyPnL       float64
-	lastDailyReset time.Time
-	tradeHistory   []TradeRecord
+	paperTrading = true // ← cleared by the -live flag
+	tradeLogFile *os.File
+	dailyPnL     float64
+	tradeHistory []TradeRecord
+
+	// Recently placed orders keyed by symbol+side+minute, used to suppress
+	// duplicate submissions of the same signal.
+	recentOrders = make(map[string]time.Time)
+	dedupWindow  = 60 * time.Second
+
+	maxQuoteAge = 30 * time.Second // entries are skipped on older quotes
+
+	maxSpreadPercent = 0.5 // entries are skipped above this bid-ask spread; 0 disables
+
+	// Longs are skipped once a symbol is up more than maxDayChangeLong
+	// percent on the previous close, shorts once it is down more than
+	// maxDayChangeShort; 0 disables.
+	maxDayChangeLong  = 0.0
+	maxDayChangeShort = 0.0
+
+	// Entries are skipped once LTP is more than maxVWAPExtension percent
+	// beyond the VWAP, or maxOpenExtension percent beyond the open, in the
+	// entry's direction; 0 disables.
+	maxVWAPExtension = 0.0
+	maxOpenExtension = 0.0
+
+	// Entries are skipped outside this LTP band; 0 disables either bound.
+	// Open positions outside it are still managed to exit.
+	minEntryPrice = 20.0
+	maxEntryPrice = 0.0
+	outOfBand     = make(map[string]bool) // symbols already logged as outside the band
+
+	noEntriesAfter = 14*60 + 50 // minutes past midnight IST
+	entryWindows   []string     // "HH:MM-HH:MM" IST ranges entries are limited to; empty allows the whole session
+
+	// Entries wait until a symbol has been observed for both of these so
+	// the session high/low and history mean something.
+	warmupTicks  = 6
+	warmupPeriod = 0 * time.Minute
+
+	closeOnly bool
+
+	maxHoldTime time.Duration // 0 disables the holding-time exit
+
+	// useBracketOrders enters with bracket orders whose target and SL legs
+	// are managed by the exchange. Such positions skip the local exit rules
+	// (SL, break-even, target, trailing, max hold); the two modes never
+	// both manage one position. Square-off and manual exits still close
+	// them, through the broker's bracket exit.
+	useBracketOrders bool
+
+	// Paper-fill slippage in basis points by StockStrategy.Class; classes
+	// not listed use defaultSlippageBps.
+	slippageBps        = map[string]float64{"A": 2, "B": 5, "C": 10}
+	defaultSlippageBps = 5.0
 )
 
-type StockStrategy struct {
-	Class         string  `json:"class"`
-	AllowShort    bool    `json:"allow_short"`
-	BreakoutLong  float64 `json:"breakout_long"`
-	BreakoutShort float64 `json:"breakout_short"`
-	Target        float64 `json:"target"`
-	SL            float64 `json:"sl"`
-	Leverage      float64 `json:"leverage"`
+type TradeRecord struct {
+	Symbol     string     `json:"symbol"`
+	Direction  string     `json:"direction"` // LONG / SHORT
+	EntryTime  time.Time  `json:"entry_time"`
+	EntryPrice float64    `json:"entry_price"`
+	ExitTime   time.Time  `json:"exit_time"`
+	ExitPrice  float64    `json:"exit_price"`
+	Qty        int        `json:"qty"`
+	PnL        float64    `json:"pnl"`
+	Reason     ExitReason `json:"reason"`
+	Strategy   string     `json:"strategy,omitempty"` // entry strategy that opened the trade
+	Tags       []string   `json:"tags,omitempty"`
 }
 
-type TradeRecord struct {
-	Symbol     string
-	Direction  string // LONG / SHORT
-	EntryTime  time.Time
-	EntryPrice float64
-	ExitTime   time.Time
-	ExitPrice  float64
-	Qty        int
-	PnL        float64
-	Reason     string
-}
-
-func init() {
-	// Create logs directory and open trade log file
-	logDir := "logs"
+// entrySource is what opened a position: the entry strategy and any tags
+// (e.g. "gap-play", "earnings") carried through to its TradeRecord.
+type entrySource struct {
+	Strategy string
+	Tags     []string
+}
+
+func signalSource(sig models.Signal) entrySource {
+	return entrySource{Strategy: sig.Strategy, Tags: sig.Tags}
+}
+
+// openTradeLog creates the logs directory and opens the trade log file.
+func openTradeLog() {
 	os.MkdirAll(logDir, 0755)
 	var err error
 	tradeLogFile, err = os.OpenFile(filepath.Join(logDir, "trades.log"),
@@ -88,10 +185,20 @@ func init() {
 	if err != nil {
 		log.Fatalf("Failed to open trade log file: %v", err)
 	}
+}
 
-	// Initialize daily reset
-	lastDailyReset = time.Now().Truncate(24 * time.Hour)
-	dailyPnL = 0
+// apiDebug logs every API request and response, secrets redacted, to
+// api-debug.jsonl in logDir.
+var apiDebug = false
+
+func openAPIDebugLog() {
+	path := filepath.Join(logDir, "api-debug.jsonl")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Fatalf("Failed to open API debug log: %v", err)
+	}
+	client.SetDebugLog(f)
+	fmt.Printf("Logging API requests to %s\n", path)
 }
 
 func logTrade(msg string) {
@@ -106,29 +213,107 @@ func logTrade(msg string) {
 	}
 }
 
+// notifyTrade logs msg to the trade log and forwards it to the notifier.
+func notifyTrade(event notify.Event, msg string) {
+	logTrade(msg)
+	notifier.Notify(event, msg)
+}
+
 func logTradeRecord(trade TradeRecord) {
+	mu.Lock()
 	tradeHistory = append(tradeHistory, trade)
 	dailyPnL += trade.PnL
+	mu.Unlock()
+
+	recordStreak(trade)
+
+	appendTradeJSONL(trade)
+}
+
+// appendTradeJSONL appends trade to trades.jsonl in logDir, the input of the
+// `analyze` subcommand.
+func appendTradeJSONL(trade TradeRecord) {
+	line, err := json.Marshal(trade)
+	if err != nil {
+		log.Printf("Trade record encode failed: %v", err)
+		return
+	}
+
+	f, err := os.OpenFile(filepath.Join(logDir, "trades.jsonl"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("Trade record log open failed: %v", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
 }
 
 func main() {
-	config.Load()
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		if err := runReport(os.Args[2:]); err != nil {
+			log.Fatalf("Report: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfig(os.Args[2:]); err != nil {
+			log.Fatalf("Config: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "analyze" {
+		if err := runAnalyze(os.Args[2:]); err != nil {
+			log.Fatalf("Analyze: %v", err)
+		}
+		return
+	}
+
+	parseFlags()
+	openTradeLog()
+	if apiDebug {
+		openAPIDebugLog()
+	}
+	if err := config.Load(secretsPath); err != nil {
+		log.Fatalf("Config: %v", err)
+	}
+	creds, err := config.Account(accountName)
+	if err != nil {
+		log.Fatalf("Config: %v", err)
+	}
+	account = client.NewAccount(accountName, creds)
+	quotes, broker = account.Client, account.Client
+	if url := config.C.WebhookURL; url != "" {
+		var events []notify.Event
+		for _, e := range config.C.WebhookEvents {
+			events = append(events, notify.Event(e))
+		}
+		notifier = notify.Multi{notify.Log{}, notify.NewWebhook(url, events...)}
+	}
+	client.OnCircuitChange(onCircuitChange)
 	fmt.Println("Axiom Protocol Initializing...")
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Authenticate
-	token, err := auth.GetSessionToken(config.C.APIKey, config.C.RequestCode, config.C.SecretKey)
-	if err != nil {
+	if err := authenticate(ctx); err != nil {
 		log.Fatalf("Auth failed: %v", err)
 	}
-	session.Set(token)
-	fmt.Println("Session token set globally")
+	fmt.Println("Session token set")
 
 	// Load watchlist
-	if err := stocks.Load("data/stocks.json"); err != nil {
+	if err := stocks.Load(stocksPath); err != nil {
 		log.Printf("Warning: Could not load stocks.json - %v", err)
 	}
+	for _, sym := range stocks.Disabled {
+		setSymbolEnabled(sym, false)
+	}
+	setShortable(stocks.Shortable)
 
-	// Symbol → Token mapping
+	// Symbol → Token mapping; overrides pin problem symbols first
+	if symbolOverrides, err = loadSymbolOverrides(symbolOverridesPath); err != nil {
+		log.Fatalf("Symbol overrides: %v", err)
+	}
 	symbolToToken = make(map[string]string)
 	fmt.Println("Mapping symbols to tokens...")
 
@@ -136,256 +321,745 @@ func main() {
 		fmt.Println("Loaded existing token map from file")
 	} else {
 		fmt.Println("Token map not found or expired — re-authenticating...")
-		newToken, err := auth.GetSessionToken(config.C.APIKey, config.C.RequestCode, config.C.SecretKey)
-		if err != nil {
+		if err := authenticate(ctx); err != nil {
 			log.Fatalf("Re-auth failed during mapping: %v", err)
 		}
-		session.Set(newToken)
 		fmt.Println("Re-authenticated — fresh session token set")
 
+		master, err := instruments.Load(ctx, instrumentsURL, instrumentsPath)
+		if err != nil {
+			log.Printf("Instrument master: %v - falling back to SearchScrip", err)
+		}
 		for _, sym := range stocks.Tickers {
-			respBytes, err := client.SearchScrip("NSE", sym+"-EQ")
+			sc, err := lookupScrip(ctx, master, sym)
 			if err != nil {
-				log.Printf("Search failed for %s: %v", sym, err)
-				continue
-			}
-
-			var sr client.SearchResult
-			if err := json.Unmarshal(respBytes, &sr); err != nil {
-				log.Printf("JSON parse error for %s: %v", sym, err)
-				continue
-			}
-
-			if sr.Stat == "Ok" {
-				found := false
-				for _, v := range sr.Values {
-					if strings.Contains(v.Tsym, "-EQ") {
-						symbolToToken[sym] = v.Token
-						fmt.Printf("Mapped %s → %s\n", sym, v.Token)
-						found = true
-						break
-					}
-				}
-				if !found {
-					fmt.Printf("No -EQ token found for %s\n", sym)
-				}
+				log.Printf("Mapping %s: %v", sym, err)
 			} else {
-				fmt.Printf("Search failed for %s: %s\n", sym)
+				mu.Lock()
+				storeScrip(sym, sc)
+				mu.Unlock()
+				fmt.Printf("Mapped %s → %s\n", sym, sc.Token)
 			}
-
-			time.Sleep(300 * time.Millisecond)
 		}
 		saveTokenMap()
 	}
+	applySymbolOverrides()
 
-	fmt.Printf("Mapped symbols successfully\n", len(symbolToToken), len(stocks.Tickers))
+	fmt.Printf("Mapped %d/%d symbols successfully\n", len(symbolToToken), len(stocks.Tickers))
+
+	if brainRefreshEvery > 0 {
+		if err := checkBrainCommand(); err != nil {
+			log.Fatalf("Brain: %v", err)
+		}
+	}
 
 	// Load brain config
 	if err := loadBrainConfig(); err != nil {
 		log.Printf("Warning: Could not load config.json - using defaults: %v", err)
 	} else {
-		fmt.Printf("Loaded strategies from config\n", len(stockStrategies))
+		fmt.Printf("Loaded %d strategies from config\n", len(stockStrategies))
 	}
 
-	if len(symbolToToken) > 0 {
-		var firstSym, firstToken string
-		for s, t := range symbolToToken {
-			firstSym = s
-			firstToken = t
-			break
-		}
-		ltp, err := client.GetLTP("NSE", firstToken)
-		if err != nil {
-			log.Printf("Immediate LTP test for %s failed: %v", firstSym, err)
-		} else {
-			fmt.Printf("Immediate LTP test for %s OK: %.2f\n", firstSym, ltp)
+	if err := startupSelfTest(ctx, symbolToToken); err != nil {
+		if !selfTestWarnOnly {
+			log.Fatalf("Startup self-test failed: %v", err)
 		}
+		notifyTrade(notify.EventError, fmt.Sprintf("STARTUP SELF-TEST FAILED: %v - trading anyway", err))
 	}
 
 	fmt.Println("Axiom Protocol Online")
 	if paperTrading {
 		fmt.Println("Mode selected - Paper Trading")
+	} else {
+		fmt.Println("Mode selected - LIVE Trading")
+	}
+	if tradingCapital > 0 {
+		fmt.Printf("Capital: %.2f (%s allocation) | Max positions: %d\n", tradingCapital, allocMode, defaultMaxPositions)
+	} else {
+		fmt.Printf("Budget: %.2f | Max positions: %d\n", defaultBudget, defaultMaxPositions)
 	}
 
-	// Main polling loop
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
+	if marketSnapshotEvery > 0 {
+		restoreMarketSnapshot(nowIST())
+	}
+	initTradingDay(nowIST())
+	if recordTicks {
+		ticks = newTickRecorder(dataDir)
+		fmt.Printf("Recording ticks to %s\n", dataPath("ticks-YYYY-MM-DD.jsonl"))
+	}
+
+	if statusPort > 0 {
+		startStatusServer(statusPort)
+	}
+	if deadManAfter > 0 {
+		// Without the control API nothing could ever reset the switch.
+		if statusPort == 0 || config.C.ControlToken == "" {
+			log.Fatalf("-dead-man needs the control API: set -port and AXIOM_CONTROL_TOKEN")
+		}
+		fmt.Printf("Dead-man's switch armed: flattening if the controller is silent for %s\n", deadManAfter)
+	}
 
-	lastBrainUpdate := time.Now()
+	// Main polling loop. Each wait is re-jittered so instances started
+	// together do not stay in step.
+	timer := time.NewTimer(jittered(tickInterval()))
+	defer timer.Stop()
+
+	beat()
+	go watchLoop(ctx)
+	go watchDeadMan(ctx)
+	watchPauseSignal(ctx)
+
+	schedule := dailySchedule(nowIST())
+	fmt.Print("Scheduled jobs:\n", schedule)
+
+	for {
+		select {
+		case <-ctx.Done():
+			shutdown()
+			return
+		case <-timer.C:
+			timer.Reset(jittered(tickInterval()))
+		}
 
-	for range ticker.C {
-		now := time.Now().In(time.FixedZone("IST", 5*60*60+30*60))
+		now := nowIST()
 
-		// Daily summary ~15:30 after square-off
-		if now.Hour() == 15 && now.Minute() >= 30 && now.Sub(lastDailyReset) >= 24*time.Hour {
-			printDailySummary()
+		// Close-only window: exits continue, no fresh entries
+		if isCloseOnly := minuteOfDay(now) >= noEntriesAfter; isCloseOnly != closeOnly {
+			closeOnly = isCloseOnly
+			if closeOnly {
+				logTrade(fmt.Sprintf("Close-only mode from %s - no new entries", formatClock(noEntriesAfter)))
+			}
 		}
 
-		// Auto square-off at 15:10 IST
-		if now.Hour() == 15 && now.Minute() >= 10 {
+		startTradingDay(now)
+		if squareOffDue(now) {
 			squareOffAllPositions(now)
 		}
+		schedule.tick(now)
+		maybeRefreshSession(ctx)
 
-		// Refresh brain.py config every 15 minutes
-		if time.Since(lastBrainUpdate) >= 15*time.Minute {
-			runBrainAndReload()
-			lastBrainUpdate = time.Now()
+		fmt.Printf("\nPolling LTP at %s\n", now.Format("15:04:05"))
+
+		mu.Lock()
+		tokens := maps.Clone(symbolToToken)
+		mu.Unlock()
+
+		checkLatency()
+		due := duePolls(tokens, now)
+		successCount := pollSymbols(ctx, due)
+		pollPendingOrders()
+		pollPendingExits()
+		pollExchangeStops()
+		pollBracketPositions()
+		drainSignalQueue()
+		maybeSaveMarketSnapshot(now)
+		if ticks != nil {
+			ticks.flush()
 		}
 
-		fmt.Printf("\nPolling LTP at %s\n", now.Format("15:04:05"))
+		fmt.Printf("Successfully fetched LTP for %d/%d due symbols (%d watched)\n", successCount, len(due), len(tokens))
+		fmt.Println("---")
+		beat()
+	}
+}
 
-		successCount := 0
-		for sym, token := range symbolToToken {
-			if sym == "TATAMOTORS" {
-				continue
-			}
+// authenticate logs account in with its configured request_code. If
+// Flattrade reports the code as expired, it asks for a fresh one on stdin
+// instead of giving up.
+func authenticate(ctx context.Context) error {
+	for {
+		err := account.Login(ctx)
+		if !errors.Is(err, auth.ErrRequestCodeExpired) {
+			return err
+		}
 
-			ltp, err := client.GetLTP("NSE", token)
-			if err != nil {
-				log.Printf("%s LTP error: %v", sym, err)
-				if strings.Contains(err.Error(), "exceeds Limit") {
-					time.Sleep(2 * time.Second)
-				}
-				continue
+		fmt.Printf("%v\nEnter a fresh request_code: ", err)
+		line, readErr := bufio.NewReader(os.Stdin).ReadString('\n')
+		code := strings.TrimSpace(line)
+		if code == "" {
+			if readErr != nil {
+				return err
 			}
+			continue
+		}
+		account.Creds.RequestCode = code
+	}
+}
 
-			successCount++
+// shutdown runs once the loop has been cancelled: it optionally flattens
+// open positions and closes the trade log.
+func shutdown() {
+	fmt.Println("Shutdown requested - stopping polling loop")
 
-			updateHighLow(sym, ltp)
-			updateLTPHistory(sym, ltp)
-			checkAllEntries(sym, ltp)
-			checkLongExit(sym, ltp)
-			checkShortExit(sym, ltp)
+	if squareOffOnExit {
+		squareOffAllPositions(nowIST())
+	}
 
-			time.Sleep(200 * time.Millisecond)
+	if marketSnapshotEvery > 0 {
+		if err := saveMarketSnapshot(marketSnapshotPath, nowIST()); err != nil {
+			log.Printf("Market snapshot failed: %v", err)
 		}
+	}
 
-		fmt.Printf("Successfully fetched LTP")
-		fmt.Println("---")
+	if ticks != nil {
+		ticks.close()
+	}
+
+	if tradeLogFile != nil {
+		tradeLogFile.Close()
+		tradeLogFile = nil
+	}
+	fmt.Println("Axiom Protocol Offline")
+}
+
+// placeOrder sends an entry order, suppressing one that repeats a symbol
+// and side already entered within dedupWindow. Only accepted orders count
+// as entered, so a failed entry can be retried at once.
+func placeOrder(p client.OrderParams) (string, error) {
+	now := time.Now()
+	if isDuplicateOrder(p.Symbol, p.Side, now) {
+		logTrade(fmt.Sprintf("duplicate order suppressed: %s %s Qty:%d", p.Side, p.Symbol, p.Qty))
+		return "", fmt.Errorf("duplicate order suppressed")
+	}
+	id, err := sendOrder(p)
+	if err == nil {
+		recordOrder(p.Symbol, p.Side, now)
 	}
+	return id, err
 }
 
-// Paper + real order wrapper
-func placeOrder(sym, token, side, orderType string, qty int) error {
+// sendOrder is the paper + real order wrapper, without the duplicate
+// check: exits, protective legs and corrected resubmissions go straight
+// through. Orders are not tied to the loop context so a shutdown never
+// aborts one mid-flight.
+func sendOrder(p client.OrderParams) (string, error) {
+	tick := tickSizeFor(p.Symbol)
+	if p.Price > 0 {
+		p.Price = client.RoundToTick(p.Price, tick)
+	}
+	if p.Trigger > 0 {
+		p.Trigger = client.RoundToTick(p.Trigger, tick)
+	}
+
 	if paperTrading {
-		logTrade(fmt.Sprintf("PAPER %s %s Qty:%d %s (token:%s)", side, orderType, qty, sym, token))
-		return nil
+		logTrade(fmt.Sprintf("PAPER %s %s %s %s Qty:%d %s (token:%s)", p.Side, p.PriceType, p.Validity, p.Product, p.Qty, p.Symbol, p.Token))
+		return placePaperOrder(p), nil
+	}
+	return broker.PlaceOrder(context.Background(), p)
+}
+
+// marketOrder returns a DAY market order on side for qty of sym's dir
+// position, with the symbol's token and product filled in.
+func marketOrder(sym string, dir models.Direction, side client.Side, qty int) client.OrderParams {
+	return client.OrderParams{
+		Symbol: sym, Tsym: tsymFor(sym), Token: tokenFor(sym), Side: side, Qty: qty,
+		Product: productFor(sym, dir), PriceType: client.PriceMarket, Validity: client.ValidityDay,
+	}
+}
+
+// entryOrder is marketOrder opening a dir position, with the symbol's
+// configured entry validity.
+func entryOrder(sym string, dir models.Direction, qty int) client.OrderParams {
+	side := client.Buy
+	if dir == models.Short {
+		side = client.Sell
+	}
+	p := marketOrder(sym, dir, side, qty)
+	if v := getStrategy(sym).EntryValidity; v != "" {
+		p.Validity = v
+	}
+	return p
+}
+
+// marginAvailable reports whether the account has the margin a leveraged
+// entry of notional needs. Unleveraged and paper entries are not checked;
+// a failed limits request skips the entry rather than risk a rejection.
+func marginAvailable(sym string, notional, leverage float64) bool {
+	if leverage <= 1 || paperTrading {
+		return true
+	}
+	limits, err := broker.GetLimits(context.Background())
+	if err != nil {
+		logTrade(fmt.Sprintf("ENTRY skipped %s - margin check failed: %v", sym, err))
+		return false
 	}
-	// Real order (your actual implementation)
-	return client.PlaceOrder(sym, token, side, orderType, qty)
+	need := notional / leverage
+	if avail := limits.Available(); avail < need {
+		logTrade(fmt.Sprintf("ENTRY skipped %s - margin %.2f below required %.2f (lev %.1f)", sym, avail, need, leverage))
+		return false
+	}
+	return true
+}
+
+// capNotional trims qty of sym at ltp, in whole lots, so that neither the
+// symbol's MaxNotional nor maxTotalNotional is exceeded by the open
+// positions and pending entries plus this one. It returns 0 when no lot
+// fits; either outcome is logged.
+func capNotional(sym string, qty int, ltp float64) int {
+	limit := getStrategy(sym).MaxNotional
+
+	mu.Lock()
+	lot := max(lotSizes[sym], 1)
+	var symExposure, totalExposure float64
+	for s, pos := range longPositions {
+		totalExposure += pos.TotalCost
+		if s == sym {
+			symExposure += pos.TotalCost
+		}
+	}
+	for s, pos := range shortPositions {
+		totalExposure += pos.TotalCost
+		if s == sym {
+			symExposure += pos.TotalCost
+		}
+	}
+	for _, p := range pendingEntries {
+		rest := p.restNotional()
+		totalExposure += rest
+		if p.Symbol == sym {
+			symExposure += rest
+		}
+	}
+	mu.Unlock()
+
+	room, capName := math.Inf(1), ""
+	if limit > 0 {
+		room, capName = limit-symExposure, "symbol"
+	}
+	if maxTotalNotional > 0 && maxTotalNotional-totalExposure < room {
+		room, capName = maxTotalNotional-totalExposure, "portfolio"
+	}
+	if float64(qty)*ltp <= room {
+		return qty
+	}
+
+	capped := max(int(room/ltp)/lot*lot, 0)
+	if capped < 1 {
+		logTrade(fmt.Sprintf("ENTRY skipped %s - %s notional cap reached (room %.2f)", sym, capName, max(room, 0)))
+		return 0
+	}
+	logTrade(fmt.Sprintf("ENTRY %s trimmed %d -> %d by %s notional cap (room %.2f)", sym, qty, capped, capName, room))
+	return capped
+}
+
+// strengthScale is the budget multiplier for a signal of the given
+// strength: the strength itself, clamped to [1, maxStrengthScale].
+func strengthScale(strength float64) float64 {
+	return min(max(strength, 1), max(maxStrengthScale, 1))
+}
+
+// tokenFor returns sym's instrument token; the map is re-mapped concurrently.
+func tokenFor(sym string) string {
+	mu.Lock()
+	defer mu.Unlock()
+	return symbolToToken[sym]
+}
+
+// entryQty sizes an entry of budget at ltp, rounded down to whole lots of
+// sym. It returns 0 when even one lot costs more than budget.
+func entryQty(sym string, budget, ltp float64) int {
+	mu.Lock()
+	lot := max(lotSizes[sym], 1)
+	mu.Unlock()
+
+	qty := int(budget / ltp)
+	if capped, ok := atrQty(sym); ok && capped < qty {
+		if capped < lot {
+			logTrade(fmt.Sprintf("%s: ATR sizing allows %d, under one lot of %d - skipping", sym, capped, lot))
+			return 0
+		}
+		qty = capped
+	}
+	if qty >= lot {
+		return qty / lot * lot
+	}
+
+	// Less than one lot: apply the symbol's UnderBudget policy.
+	strat := getStrategy(sym)
+	switch strat.UnderBudget {
+	case models.UnderBudgetMinQty:
+		qty = (max(strat.MinQty, 1) + lot - 1) / lot * lot
+		logTrade(fmt.Sprintf("%s: budget %.2f buys under one lot at %.2f - entering min qty %d", sym, budget, ltp, qty))
+		return qty
+	case models.UnderBudgetStretch:
+		if cost := float64(lot) * ltp; cost <= strat.MaxBudget {
+			logTrade(fmt.Sprintf("%s: budget %.2f stretched to %.2f for one lot of %d", sym, budget, cost, lot))
+			return lot
+		}
+	}
+	return 0
+}
+
+// placeBracket is placeOrder for bracket entries.
+func placeBracket(p client.BracketParams) (string, error) {
+	now := time.Now()
+	if isDuplicateOrder(p.Symbol, p.Side, now) {
+		logTrade(fmt.Sprintf("duplicate order suppressed: %s %s Qty:%d", p.Side, p.Symbol, p.Qty))
+		return "", fmt.Errorf("duplicate order suppressed")
+	}
+
+	if paperTrading {
+		logTrade(fmt.Sprintf("PAPER %s BRACKET %s Qty:%d target +%.2f SL -%.2f", p.Side, p.Symbol, p.Qty, p.TargetPoints, p.StopPoints))
+		recordOrder(p.Symbol, p.Side, now)
+		return placePaperOrder(p.OrderParams), nil
+	}
+	id, err := broker.PlaceBracketOrder(context.Background(), p)
+	if err == nil {
+		recordOrder(p.Symbol, p.Side, now)
+	}
+	return id, err
+}
+
+// sendExit closes qty of sym's dir position with an exitOrder at ltp and
+// returns the exit order's ID and the order sent. Bracket positions go
+// through the broker's bracket exit so their legs are cancelled too; that
+// exit has no order ID or params of its own. An exchange stop and target
+// taken down for the exit are put back if the exit cannot be sent.
+func sendExit(sym string, dir models.Direction, qty int, ltp float64) (string, client.OrderParams, error) {
+	side := client.Sell
+	mu.Lock()
+	bracket := longPositions[sym].BracketOrder
+	if dir == models.Short {
+		side = client.Buy
+		bracket = shortPositions[sym].BracketOrder
+	}
+	mu.Unlock()
+
+	if bracket == "" {
+		if err := cancelExchangeExits(sym, dir); err != nil {
+			return "", client.OrderParams{}, err
+		}
+		p := exitOrder(sym, dir, side, qty, ltp)
+		id, err := sendOrder(p)
+		if err != nil {
+			restoreExchangeExits(sym, dir)
+		}
+		return id, p, err
+	}
+	if paperTrading {
+		logTrade(fmt.Sprintf("PAPER EXIT BRACKET %s %s (order %s)", dir, sym, bracket))
+		cancelPaperBracket(bracket)
+		return "", client.OrderParams{}, nil
+	}
+	return "", client.OrderParams{}, broker.ExitBracketOrder(context.Background(), bracket)
+}
+
+func tickSizeFor(sym string) float64 {
+	mu.Lock()
+	defer mu.Unlock()
+	if ti, ok := tickSizes[sym]; ok {
+		return ti
+	}
+	return client.DefaultTickSize
+}
+
+// orderKey is the dedup key of an order on sym and side placed at now.
+func orderKey(sym string, side client.Side, now time.Time) string {
+	return fmt.Sprintf("%s|%s|%s", sym, side, now.Truncate(time.Minute).Format("15:04"))
+}
+
+// isDuplicateOrder reports whether an entry on sym and side was accepted
+// in now's minute, within dedupWindow.
+func isDuplicateOrder(sym string, side client.Side, now time.Time) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for k, t := range recentOrders {
+		if now.Sub(t) >= dedupWindow {
+			delete(recentOrders, k)
+		}
+	}
+	_, seen := recentOrders[orderKey(sym, side, now)]
+	return seen
+}
+
+// recordOrder marks an entry on sym and side, placed at now, as accepted.
+func recordOrder(sym string, side client.Side, now time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+	recentOrders[orderKey(sym, side, now)] = now
 }
 
 // ──────────────────────────────────────────────────────────────────────────────
 // Entry functions with logging
 // ──────────────────────────────────────────────────────────────────────────────
 
-func enterLong(sym string, ltp float64, leverage float64) {
-	effectiveBudget := defaultBudget * leverage
-	qty := int(effectiveBudget / ltp)
+// enterLong buys sym at ltp with the budget scaled by leverage and by
+// strengthScale(strength), attributing the position to src; manual
+// entries pass a strength of 0.
+func enterLong(sym string, src entrySource, ltp, leverage, strength float64) {
+	effectiveBudget := entryBudget(strength) * leverage
+	qty := entryQty(sym, effectiveBudget, ltp)
 	if qty < 1 {
 		logTrade(fmt.Sprintf("LONG skipped - insufficient budget %s (lev %.1f)", sym, leverage))
 		return
 	}
+	if qty = capNotional(sym, qty, ltp); qty < 1 {
+		return
+	}
+	if !targetClearsCosts(sym, models.Long, ltp, qty) {
+		return
+	}
+	if !marginAvailable(sym, float64(qty)*ltp, leverage) {
+		return
+	}
+
+	if useBracketOrders {
+		submitBracketEntry(sym, src, models.Long, qty, ltp, leverage)
+		return
+	}
 
-	err := placeOrder(sym, symbolToToken[sym], "BUY", "MKT", qty)
+	if offset := getStrategy(sym).EntryLimitOffset; offset > 0 {
+		submitLimitEntry(sym, src, models.Long, qty, ltp*(1-offset), leverage)
+		return
+	}
+
+	id, p, err := placeEntry(entryOrder(sym, models.Long, qty))
 	if err != nil {
-		logTrade(fmt.Sprintf("LONG ENTRY FAILED %s: %v", sym, err))
+		notifyTrade(notify.EventError, fmt.Sprintf("LONG ENTRY FAILED %s: %v", sym, err))
+		return
+	}
+	filled, avg, working := entryFilled(id, p.Qty)
+	if filled < 1 {
+		logTrade(fmt.Sprintf("LONG ENTRY %s: order %s filled nothing", sym, id))
 		return
 	}
+	openLong(sym, src, fillPrice(sym, client.Buy, ltp, id), ltp, filled, leverage, p.Product)
+	if filled < p.Qty {
+		settlePartial(pendingOrder{
+			ID: id, Symbol: sym, Direction: models.Long, Qty: p.Qty, Filled: filled, AvgPrice: avg,
+			Leverage: leverage, Product: p.Product, Source: src, Placed: time.Now(),
+		}, working)
+	}
+}
 
+// openLong records a filled long entry placed with product.
+func openLong(sym string, src entrySource, fill, ltp float64, qty int, leverage float64, product string) {
 	mu.Lock()
-	longPositions[sym] = struct {
-		EntryPrice, HighestPrice float64
-		Qty                      int
-		EntryTime                time.Time
-	}{ltp, ltp, qty, time.Now()}
+	pos, exists := longPositions[sym]
+	pos.addLot(fill, qty, leverage, src)
+	if !exists {
+		pos.Product = product
+	}
+	if !exists || ltp > pos.HighestPrice {
+		pos.HighestPrice = ltp
+	}
+	longPositions[sym] = pos
 	mu.Unlock()
 
-	logTrade(fmt.Sprintf("ENTRY LONG %s @ %.2f Qty: %d Leverage: %.1f", sym, ltp, qty, leverage))
+	notifyTrade(notify.EventEntry, fmt.Sprintf("ENTRY LONG %s @ %.2f Qty: %d Leverage: %.1f", sym, fill, qty, leverage))
+	if stopMode == stopsExchange {
+		placeExchangeStop(sym, models.Long, ltp)
+		placeExchangeTarget(sym, models.Long)
+	}
 }
 
-func enterShort(sym string, ltp float64, leverage float64) {
-	effectiveBudget := defaultBudget * leverage
-	qty := int(effectiveBudget / ltp)
+func enterShort(sym string, src entrySource, ltp, leverage, strength float64) {
+	if !shortable(sym) {
+		logTrade(fmt.Sprintf("SHORT refused - %s is not on the shortable list", sym))
+		return
+	}
+	effectiveBudget := entryBudget(strength) * leverage
+	qty := entryQty(sym, effectiveBudget, ltp)
 	if qty < 1 {
 		logTrade(fmt.Sprintf("SHORT skipped - insufficient budget %s (lev %.1f)", sym, leverage))
 		return
 	}
+	if qty = capNotional(sym, qty, ltp); qty < 1 {
+		return
+	}
+	if !targetClearsCosts(sym, models.Short, ltp, qty) {
+		return
+	}
+	if !marginAvailable(sym, float64(qty)*ltp, leverage) {
+		return
+	}
+
+	if useBracketOrders {
+		submitBracketEntry(sym, src, models.Short, qty, ltp, leverage)
+		return
+	}
 
-	err := placeOrder(sym, symbolToToken[sym], "SELL", "MKT", qty)
+	if offset := getStrategy(sym).EntryLimitOffset; offset > 0 {
+		submitLimitEntry(sym, src, models.Short, qty, ltp*(1+offset), leverage)
+		return
+	}
+
+	id, p, err := placeEntry(entryOrder(sym, models.Short, qty))
 	if err != nil {
-		logTrade(fmt.Sprintf("SHORT ENTRY FAILED %s: %v", sym, err))
+		notifyTrade(notify.EventError, fmt.Sprintf("SHORT ENTRY FAILED %s: %v", sym, err))
+		return
+	}
+	filled, avg, working := entryFilled(id, p.Qty)
+	if filled < 1 {
+		logTrade(fmt.Sprintf("SHORT ENTRY %s: order %s filled nothing", sym, id))
 		return
 	}
+	openShort(sym, src, fillPrice(sym, client.Sell, ltp, id), ltp, filled, leverage, p.Product)
+	if filled < p.Qty {
+		settlePartial(pendingOrder{
+			ID: id, Symbol: sym, Direction: models.Short, Qty: p.Qty, Filled: filled, AvgPrice: avg,
+			Leverage: leverage, Product: p.Product, Source: src, Placed: time.Now(),
+		}, working)
+	}
+}
 
+// openShort records a filled short entry placed with product.
+func openShort(sym string, src entrySource, fill, ltp float64, qty int, leverage float64, product string) {
 	mu.Lock()
-	shortPositions[sym] = struct {
-		EntryPrice, LowestPrice float64
-		Qty                     int
-		EntryTime               time.Time
-	}{ltp, ltp, qty, time.Now()}
+	pos, exists := shortPositions[sym]
+	pos.addLot(fill, qty, leverage, src)
+	if !exists {
+		pos.Product = product
+	}
+	if !exists || ltp < pos.LowestPrice {
+		pos.LowestPrice = ltp
+	}
+	shortPositions[sym] = pos
 	mu.Unlock()
 
-	logTrade(fmt.Sprintf("ENTRY SHORT %s @ %.2f Qty: %d Leverage: %.1f", sym, ltp, qty, leverage))
+	notifyTrade(notify.EventEntry, fmt.Sprintf("ENTRY SHORT %s @ %.2f Qty: %d Leverage: %.1f", sym, fill, qty, leverage))
+	if stopMode == stopsExchange {
+		placeExchangeStop(sym, models.Short, ltp)
+		placeExchangeTarget(sym, models.Short)
+	}
 }
 
 // ──────────────────────────────────────────────────────────────────────────────
 // Exit functions with P&L calculation
 // ──────────────────────────────────────────────────────────────────────────────
 
-func exitLong(sym string, ltp float64, qty int, reason string) {
-	err := placeOrder(sym, symbolToToken[sym], "SELL", "MKT", qty)
+// exitLong closes qty of sym's long at ltp. Callers hold sym's exit lock
+// (see lockExits); the position is re-read here, so one closed by another
+// exit path since the caller looked is not exited twice.
+func exitLong(sym string, ltp float64, qty int, reason ExitReason) {
+	mu.Lock()
+	pos, ok := longPositions[sym]
+	mu.Unlock()
+	if !ok {
+		return
+	}
+	qty = min(qty, pos.TotalQty)
+	if hasPendingExit(sym, models.Long) {
+		return // a protected exit is already working
+	}
+	id, p, err := sendExit(sym, models.Long, qty, ltp)
 	if err != nil {
-		logTrade(fmt.Sprintf("LONG EXIT FAILED %s: %v", sym, err))
+		notifyTrade(notify.EventError, fmt.Sprintf("LONG EXIT FAILED %s: %v", sym, err))
+		return
+	}
+	if p.PriceType == client.PriceLimit {
+		trackExit(pendingExit{ID: id, Symbol: sym, Direction: models.Long, Qty: qty, Limit: p.Price, Reason: reason, Placed: time.Now()})
 		return
 	}
+	bookLongExit(sym, fillPrice(sym, client.Sell, ltp, id), qty, reason)
+	restoreExchangeExits(sym, models.Long)
+}
 
+// bookLongExit records qty of sym's long as closed at fill.
+func bookLongExit(sym string, fill float64, qty int, reason ExitReason) {
 	mu.Lock()
-	pos := longPositions[sym]
-	delete(longPositions, sym)
+	pos, ok := longPositions[sym]
+	if !ok {
+		mu.Unlock()
+		log.Printf("LONG EXIT %s not booked: no open position", sym)
+		return
+	}
+	entry := pos.AvgEntry()
+	closed := pos.reduce(qty)
+	if closed {
+		delete(longPositions, sym)
+	} else {
+		longPositions[sym] = pos
+	}
 	mu.Unlock()
 
-	pnl := float64(qty) * (ltp - pos.EntryPrice)
-	logTrade(fmt.Sprintf("EXIT LONG %s @ %.2f Qty: %d P&L: ₹%.2f Reason: %s", sym, ltp, qty, pnl, reason))
+	if closed {
+		armReentry(sym, models.Long, pos, max(pos.HighestPrice, fill), reason)
+	}
+
+	pnl := float64(qty) * (fill - entry)
+	notifyTrade(notify.EventExit, fmt.Sprintf("EXIT LONG %s @ %.2f Qty: %d P&L: %s Reason: %s", sym, fill, qty, money(pnl), reason))
 
 	logTradeRecord(TradeRecord{
 		Symbol:     sym,
 		Direction:  "LONG",
 		EntryTime:  pos.EntryTime,
-		EntryPrice: pos.EntryPrice,
+		EntryPrice: entry,
 		ExitTime:   time.Now(),
-		ExitPrice:  ltp,
+		ExitPrice:  fill,
 		Qty:        qty,
 		PnL:        pnl,
 		Reason:     reason,
+		Strategy:   pos.Strategy,
+		Tags:       pos.Tags,
 	})
 }
 
-func exitShort(sym string, ltp float64, qty int, reason string) {
-	err := placeOrder(sym, symbolToToken[sym], "BUY", "MKT", qty)
+// exitShort closes qty of sym's short at ltp. Callers hold sym's exit lock
+// (see lockExits); the position is re-read here, so one closed by another
+// exit path since the caller looked is not exited twice.
+func exitShort(sym string, ltp float64, qty int, reason ExitReason) {
+	mu.Lock()
+	pos, ok := shortPositions[sym]
+	mu.Unlock()
+	if !ok {
+		return
+	}
+	qty = min(qty, pos.TotalQty)
+	if hasPendingExit(sym, models.Short) {
+		return // a protected exit is already working
+	}
+	id, p, err := sendExit(sym, models.Short, qty, ltp)
 	if err != nil {
-		logTrade(fmt.Sprintf("SHORT EXIT FAILED %s: %v", sym, err))
+		notifyTrade(notify.EventError, fmt.Sprintf("SHORT EXIT FAILED %s: %v", sym, err))
 		return
 	}
+	if p.PriceType == client.PriceLimit {
+		trackExit(pendingExit{ID: id, Symbol: sym, Direction: models.Short, Qty: qty, Limit: p.Price, Reason: reason, Placed: time.Now()})
+		return
+	}
+	bookShortExit(sym, fillPrice(sym, client.Buy, ltp, id), qty, reason)
+	restoreExchangeExits(sym, models.Short)
+}
 
+// bookShortExit records qty of sym's short as closed at fill.
+func bookShortExit(sym string, fill float64, qty int, reason ExitReason) {
 	mu.Lock()
-	pos := shortPositions[sym]
-	delete(shortPositions, sym)
+	pos, ok := shortPositions[sym]
+	if !ok {
+		mu.Unlock()
+		log.Printf("SHORT EXIT %s not booked: no open position", sym)
+		return
+	}
+	entry := pos.AvgEntry()
+	closed := pos.reduce(qty)
+	if closed {
+		delete(shortPositions, sym)
+	} else {
+		shortPositions[sym] = pos
+	}
 	mu.Unlock()
 
-	pnl := float64(qty) * (pos.EntryPrice - ltp)
-	logTrade(fmt.Sprintf("EXIT SHORT %s @ %.2f Qty: %d P&L: ₹%.2f Reason: %s", sym, ltp, qty, pnl, reason))
+	if closed {
+		armReentry(sym, models.Short, pos, min(pos.LowestPrice, fill), reason)
+	}
+
+	pnl := float64(qty) * (entry - fill)
+	notifyTrade(notify.EventExit, fmt.Sprintf("EXIT SHORT %s @ %.2f Qty: %d P&L: %s Reason: %s", sym, fill, qty, money(pnl), reason))
 
 	logTradeRecord(TradeRecord{
 		Symbol:     sym,
 		Direction:  "SHORT",
 		EntryTime:  pos.EntryTime,
-		EntryPrice: pos.EntryPrice,
+		EntryPrice: entry,
 		ExitTime:   time.Now(),
-		ExitPrice:  ltp,
+		ExitPrice:  fill,
 		Qty:        qty,
 		PnL:        pnl,
 		Reason:     reason,
+		Strategy:   pos.Strategy,
+		Tags:       pos.Tags,
 	})
 }
 
@@ -394,71 +1068,148 @@ func exitShort(sym string, ltp float64, qty int, reason string) {
 // ──────────────────────────────────────────────────────────────────────────────
 
 func checkLongExit(sym string, ltp float64) {
+	defer lockExits(sym)()
+	strat := getStrategy(sym)
+	px := decisionLTP(sym, ltp)
+
 	mu.Lock()
 	pos, exists := longPositions[sym]
+	if exists {
+		pos.HighestPrice = max(pos.HighestPrice, px)
+		longPositions[sym] = pos
+	}
 	mu.Unlock()
 
 	if !exists {
 		return
 	}
 
-	strat := getStrategy(sym)
+	// Bracket and local exits are mutually exclusive: a bracket position's
+	// target and SL legs live at the exchange, and pollBracketPositions
+	// books their fills.
+	if pos.BracketOrder != "" {
+		return
+	}
+	// Likewise an exchange-managed stop and target: pollExchangeStops books
+	// their fills.
+	if stopMode == stopsExchange {
+		return
+	}
 
-	mu.Lock()
-	pos.HighestPrice = max(pos.HighestPrice, ltp)
-	longPositions[sym] = pos
-	mu.Unlock()
+	fixedSL := pos.AvgEntry() * (1 - strat.SL)
+	if px <= fixedSL {
+		exitLong(sym, ltp, pos.TotalQty, ReasonFixedSL)
+		return
+	}
+
+	if beStop, armed := breakEvenStopLong(pos.AvgEntry(), pos.HighestPrice, strat); armed && px <= max(fixedSL, beStop) {
+		exitLong(sym, ltp, pos.TotalQty, ReasonBreakEven)
+		return
+	}
 
-	fixedSL := pos.EntryPrice * (1 - strat.SL)
-	if ltp <= fixedSL {
-		exitLong(sym, ltp, pos.Qty, fmt.Sprintf("Fixed SL %.1f%%", strat.SL*100))
+	target := pos.AvgEntry() * (1 + strat.Target)
+	if px >= target {
+		exitLong(sym, ltp, pos.TotalQty, ReasonTarget)
 		return
 	}
 
-	target := pos.EntryPrice * (1 + strat.Target)
-	if ltp >= target {
-		exitLong(sym, ltp, pos.Qty, fmt.Sprintf("Target %.1f%%", strat.Target*100))
+	if trailingSL, armed := trailingStopLong(pos.AvgEntry(), pos.HighestPrice, strat); armed && px <= trailingSL {
+		exitLong(sym, ltp, pos.TotalQty, ReasonTrailingSL)
 		return
 	}
 
-	trailingSL := pos.HighestPrice * (1 - defaultTrailingPercent/100)
-	if ltp <= trailingSL {
-		exitLong(sym, ltp, pos.Qty, "Trailing SL")
+	if holdExpired(pos.EntryTime, strat) {
+		exitLong(sym, ltp, pos.TotalQty, ReasonMaxHold)
 	}
 }
 
 func checkShortExit(sym string, ltp float64) {
+	defer lockExits(sym)()
+	strat := getStrategy(sym)
+	px := decisionLTP(sym, ltp)
+
 	mu.Lock()
 	pos, exists := shortPositions[sym]
+	if exists {
+		pos.LowestPrice = min(pos.LowestPrice, px)
+		shortPositions[sym] = pos
+	}
 	mu.Unlock()
 
 	if !exists {
 		return
 	}
 
-	strat := getStrategy(sym)
+	if pos.BracketOrder != "" {
+		return
+	}
+	if stopMode == stopsExchange {
+		return
+	}
 
-	mu.Lock()
-	pos.LowestPrice = min(pos.LowestPrice, ltp)
-	shortPositions[sym] = pos
-	mu.Unlock()
+	fixedSL := pos.AvgEntry() * (1 + strat.SL)
+	if px >= fixedSL {
+		exitShort(sym, ltp, pos.TotalQty, ReasonFixedSL)
+		return
+	}
+
+	if beStop, armed := breakEvenStopShort(pos.AvgEntry(), pos.LowestPrice, strat); armed && px >= min(fixedSL, beStop) {
+		exitShort(sym, ltp, pos.TotalQty, ReasonBreakEven)
+		return
+	}
 
-	fixedSL := pos.EntryPrice * (1 + strat.SL)
-	if ltp >= fixedSL {
-		exitShort(sym, ltp, pos.Qty, fmt.Sprintf("Fixed SL %.1f%%", strat.SL*100))
+	target := pos.AvgEntry() * (1 - strat.Target)
+	if px <= target {
+		exitShort(sym, ltp, pos.TotalQty, ReasonTarget)
 		return
 	}
 
-	target := pos.EntryPrice * (1 - strat.Target)
-	if ltp <= target {
-		exitShort(sym, ltp, pos.Qty, fmt.Sprintf("Target %.1f%%", strat.Target*100))
+	if trailingSL, armed := trailingStopShort(pos.AvgEntry(), pos.LowestPrice, strat); armed && px >= trailingSL {
+		exitShort(sym, ltp, pos.TotalQty, ReasonTrailingSL)
 		return
 	}
 
-	trailingSL := pos.LowestPrice * (1 + defaultTrailingPercent/100)
-	if ltp >= trailingSL {
-		exitShort(sym, ltp, pos.Qty, "Trailing SL")
+	if holdExpired(pos.EntryTime, strat) {
+		exitShort(sym, ltp, pos.TotalQty, ReasonMaxHold)
+	}
+}
+
+// trailingStopLong returns the trailing stop for a long whose best price so
+// far is highest. The stop only arms once highest is TrailActivate above
+// entry; until then armed is false and only the fixed SL applies.
+func trailingStopLong(entry, highest float64, strat models.StockStrategy) (stop float64, armed bool) {
+	if highest < entry*(1+strat.TrailActivate) {
+		return 0, false
+	}
+	return highest * (1 - strat.TrailPercent), true
+}
+
+// trailingStopShort mirrors trailingStopLong for a short whose best price
+// so far is lowest.
+func trailingStopShort(entry, lowest float64, strat models.StockStrategy) (stop float64, armed bool) {
+	if lowest > entry*(1-strat.TrailActivate) {
+		return 0, false
+	}
+	return lowest * (1 + strat.TrailPercent), true
+}
+
+// breakEvenStopLong returns the break-even stop for a long whose best price
+// so far is highest. It arms once highest is BreakEvenTrigger above entry
+// and then stays armed; a zero trigger disables it.
+func breakEvenStopLong(entry, highest float64, strat models.StockStrategy) (stop float64, armed bool) {
+	if strat.BreakEvenTrigger <= 0 || highest < entry*(1+strat.BreakEvenTrigger) {
+		return 0, false
+	}
+	return entry * (1 + strat.BreakEvenBuffer), true
+}
+
+// breakEvenStopShort mirrors breakEvenStopLong for a short whose best
+// price so far is lowest.
+func breakEvenStopShort(entry, lowest float64, strat models.StockStrategy) (stop float64, armed bool) {
+	if strat.BreakEvenTrigger <= 0 || lowest > entry*(1-strat.BreakEvenTrigger) {
+		return 0, false
 	}
+	return entry * (1 - strat.BreakEvenBuffer), true
 }
 
 // ──────────────────────────────────────────────────────────────────────────────
@@ -466,44 +1217,58 @@ func checkShortExit(sym string, ltp float64) {
 // ──────────────────────────────────────────────────────────────────────────────
 
 func printDailySummary() {
+	sum := buildDailySummary(tradeHistory, dailyPnL, shadowCounts, time.Now())
+	if outputFormat != outputText {
+		writeSummary(sum)
+	}
+
 	if len(tradeHistory) == 0 {
-		logTrade("Daily Summary: No trades executed today")
+		if outputFormat == outputText {
+			logTrade("Daily Summary: No trades executed today")
+		}
+		notifier.Notify(notify.EventSummary, "No trades executed today")
 		return
 	}
 
-	logTrade("═══════════════════════════════════════════════════════")
-	logTrade("DAILY TRADE & P&L SUMMARY")
-	logTrade(fmt.Sprintf("Date: %s", time.Now().Format("2006-01-02")))
-	logTrade(fmt.Sprintf("Total Trades: %d", len(tradeHistory)))
-	logTrade(fmt.Sprintf("Net P&L: ₹%.2f", dailyPnL))
+	if outputFormat == outputText {
+		logSummary(sum)
+	}
+	notifier.Notify(notify.EventSummary, fmt.Sprintf("%s: %d trades, net P&L %s (long %s, short %s)",
+		sum.Date, sum.Trades, money(sum.NetPnL), money(sum.LongPnL), money(sum.ShortPnL)))
 
-	var longPnL, shortPnL float64
-	for _, t := range tradeHistory {
-		if t.Direction == "LONG" {
-			longPnL += t.PnL
-		} else {
-			shortPnL += t.PnL
-		}
+	if path, err := exportTradesCSV(tradeHistory, time.Now()); err != nil {
+		log.Printf("CSV export to %s failed: %v", path, err)
+	} else {
+		fmt.Printf("Trades exported to %s\n", path)
+	}
+	if err := recordPerformance(tradeHistory, time.Now()); err != nil {
+		log.Printf("Performance history update failed: %v", err)
 	}
-	logTrade(fmt.Sprintf("Long Trades P&L: ₹%.2f", longPnL))
-	logTrade(fmt.Sprintf("Short Trades P&L: ₹%.2f", shortPnL))
-	logTrade("═══════════════════════════════════════════════════════")
+}
 
-	// Reset for next day
+// resetDay clears the day's trades, P&L and per-day counters for the next
+// session.
+func resetDay() {
+	mu.Lock()
+	defer mu.Unlock()
 	tradeHistory = nil
 	dailyPnL = 0
-	lastDailyReset = time.Now().Truncate(24 * time.Hour)
+	clear(shadowCounts)
+	clear(reentryArms)
+	clear(reentryCounts)
+	clear(lossStreaks)
+	clear(benched)
 }
 
 func runBrainAndReload() {
-	brainPath := filepath.Join("data", "brain.py")
-
-	cmd := exec.Command("python", brainPath)
-	cmd.Dir = filepath.Dir(brainPath)
+	args := brainCommand()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dataDir
+	cmd.Env = append(os.Environ(), brainEnv...)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		log.Printf("Failed to run brain.py: %v\nOutput: %s", err, string(output))
+		log.Printf("Failed to run brain.py (%s): %v\nOutput: %s", strings.Join(args, " "), err, string(output))
 		return
 	}
 
@@ -516,16 +1281,26 @@ func runBrainAndReload() {
 }
 
 func loadBrainConfig() error {
-	dataPath := filepath.Join("data", "config.json")
-	data, err := os.ReadFile(dataPath)
+	data, err := os.ReadFile(brainConfigPath)
 	if err != nil {
 		return err
 	}
 
-	var configs map[string]StockStrategy
+	var configs map[string]models.StockStrategy
 	if err := json.Unmarshal(data, &configs); err != nil {
 		return err
 	}
+	for sym, strat := range configs {
+		if _, err := parseWindows(strat.EntryWindows); err != nil {
+			return fmt.Errorf("%s entry_windows: %v", sym, err)
+		}
+		switch strat.DirectionBias {
+		case "", models.BiasLong, models.BiasShort, models.BiasBoth, models.BiasNone:
+		default:
+			return fmt.Errorf("%s: unknown direction_bias %q (want %s, %s, %s or %s)",
+				sym, strat.DirectionBias, models.BiasLong, models.BiasShort, models.BiasBoth, models.BiasNone)
+		}
+	}
 
 	mu.Lock()
 	stockStrategies = configs
@@ -534,173 +1309,584 @@ func loadBrainConfig() error {
 	return nil
 }
 
-func getStrategy(sym string) StockStrategy {
+// productFor picks the order product for sym's dir position: always MIS
+// for shorts, which cannot be carried overnight; otherwise the strategy's
+// explicit Product if set, MIS for leveraged trades and CNC for the rest.
+// Exits use heldProduct instead, as the strategy may have changed since
+// the entry.
+func productFor(sym string, dir models.Direction) string {
+	if dir == models.Short {
+		return client.ProductMIS
+	}
+	strat := getStrategy(sym)
+	if strat.Product != "" {
+		return strat.Product
+	}
+	if strat.Leverage > 1 {
+		return client.ProductMIS
+	}
+	return client.ProductCNC
+}
+
+// heldProduct is the product sym's dir position was opened with, which
+// exits and stops must repeat or the broker finds no position to close.
+// Positions without one recorded fall back to productFor.
+func heldProduct(sym string, dir models.Direction) string {
+	mu.Lock()
+	product := positionsFor(dir)[sym].Product
+	mu.Unlock()
+	if product != "" {
+		return product
+	}
+	return productFor(sym, dir)
+}
+
+// holdExpired reports whether a position opened at entry has been held
+// past its max holding time.
+func holdExpired(entry time.Time, strat models.StockStrategy) bool {
+	limit := maxHoldTime
+	if strat.MaxHoldMinutes > 0 {
+		limit = time.Duration(strat.MaxHoldMinutes * float64(time.Minute))
+	}
+	return limit > 0 && time.Since(entry) >= limit
+}
+
+func getStrategy(sym string) models.StockStrategy {
 	mu.Lock()
 	defer mu.Unlock()
 
 	if strat, ok := stockStrategies[sym]; ok {
+		// brain.py may not emit the newer fields; fall back to defaults.
+		if strat.TrailPercent == 0 {
+			strat.TrailPercent = defaultTrailingPercent / 100
+		}
+		if strat.BounceRebound == 0 {
+			strat.BounceRebound = defaultBounceRebound
+		}
+		if strat.QuickDrop == 0 {
+			strat.QuickDrop = defaultQuickDrop
+		}
+		if strat.MinPrice == 0 {
+			strat.MinPrice = minEntryPrice
+		}
+		if strat.MaxPrice == 0 {
+			strat.MaxPrice = maxEntryPrice
+		}
+		if strat.MaxNotional == 0 {
+			strat.MaxNotional = maxSymbolNotional
+		}
+		if strat.MaxDayChangeLong == 0 {
+			strat.MaxDayChangeLong = maxDayChangeLong / 100
+		}
+		if strat.MaxDayChangeShort == 0 {
+			strat.MaxDayChangeShort = maxDayChangeShort / 100
+		}
+		if strat.MaxVWAPExtension == 0 {
+			strat.MaxVWAPExtension = maxVWAPExtension / 100
+		}
+		if strat.MaxOpenExtension == 0 {
+			strat.MaxOpenExtension = maxOpenExtension / 100
+		}
+		if strat.MaxConsecutiveLosses == 0 {
+			strat.MaxConsecutiveLosses = maxConsecutiveLosses
+		}
+		if len(strat.EntryWindows) == 0 {
+			strat.EntryWindows = entryWindows
+		}
+		if strat.PartialFill == "" {
+			strat.PartialFill = partialFillPolicy
+		}
+		if strat.ExitPriceType == "" {
+			strat.ExitPriceType = exitPriceType
+		}
+		if strat.ExitProtectionTicks == 0 {
+			strat.ExitProtectionTicks = exitProtectionTicks
+		}
+		if maxLeverage > 0 && strat.Leverage > maxLeverage {
+			strat.Leverage = maxLeverage
+		}
 		return strat
 	}
 
-	return StockStrategy{
+	return models.StockStrategy{
 		Class:         "B",
 		AllowShort:    true,
 		BreakoutLong:  defaultBuffer,
 		BreakoutShort: defaultBuffer,
+		BounceRebound: defaultBounceRebound,
+		QuickDrop:     defaultQuickDrop,
 		Target:        defaultTargetPercent / 100,
 		SL:            defaultFixedSLPercent / 100,
 		Leverage:      defaultLeverage,
+		TrailActivate: defaultTrailActivate / 100,
+		TrailPercent:  defaultTrailingPercent / 100,
+		MinPrice:      minEntryPrice,
+		MaxPrice:      maxEntryPrice,
+		MaxNotional:   maxSymbolNotional,
+
+		MaxDayChangeLong:  maxDayChangeLong / 100,
+		MaxDayChangeShort: maxDayChangeShort / 100,
+		MaxVWAPExtension:  maxVWAPExtension / 100,
+		MaxOpenExtension:  maxOpenExtension / 100,
+
+		MaxConsecutiveLosses: maxConsecutiveLosses,
+		EntryWindows:         entryWindows,
+		PartialFill:          partialFillPolicy,
+
+		ExitPriceType:       exitPriceType,
+		ExitProtectionTicks: exitProtectionTicks,
+	}
+}
+
+// marketState returns the state for sym, creating it on first use.
+// Callers must hold mu.
+func marketState(sym string) *state.MarketState {
+	ms, ok := markets[sym]
+	if !ok {
+		ms = &state.MarketState{Symbol: sym}
+		markets[sym] = ms
 	}
+	return ms
 }
 
 func updateHighLow(sym string, ltp float64) {
 	mu.Lock()
 	defer mu.Unlock()
 
-	hl, exists := highLow[sym]
-	if !exists {
-		hl = struct{ High, Low float64 }{0, 0}
-	}
-	if hl.High == 0 || ltp > hl.High {
-		hl.High = ltp
-	}
-	if hl.Low == 0 || ltp < hl.Low {
-		hl.Low = ltp
-	}
-	highLow[sym] = hl
+	marketState(sym).UpdateRange(ltp)
 }
 
-func updateLTPHistory(sym string, ltp float64) {
+func updateLTPHistory(sym string, q client.Quote) {
 	mu.Lock()
 	defer mu.Unlock()
 
-	hist, exists := ltpHistory[sym]
-	if !exists {
-		hist = []float64{}
+	ms := marketState(sym)
+	ms.AddTick(q.LTP, q.Volume, max(historyWindow, indicatorWindow, decisionWindow, atrLookback+1))
+	ms.FeedTime = q.FeedTime
+	ms.Bid, ms.Ask = q.Bid, q.Ask
+	if q.Open > 0 {
+		ms.Open = q.Open
 	}
-	hist = append(hist, ltp)
-	if len(hist) > historyWindow {
-		hist = hist[1:]
+	if q.Close > 0 {
+		ms.PrevClose = q.Close
 	}
-	ltpHistory[sym] = hist
 }
 
 func checkAllEntries(sym string, ltp float64) {
+	// Serialise entries so concurrent workers cannot both pass the
+	// position caps before either position is booked.
+	entryMu.Lock()
+	defer entryMu.Unlock()
+
+	if closeOnly {
+		return
+	}
+
 	mu.Lock()
+	disabled := disabledSymbols[sym] || benched[sym] || entriesPaused
 	totalOpen := len(longPositions) + len(shortPositions)
 	mu.Unlock()
 
-	if totalOpen >= defaultMaxPositions {
-		fmt.Printf("Max positions (%d/%d) reached - skipping %s\n", totalOpen, defaultMaxPositions, sym)
+	if disabled || client.Circuit() != client.CircuitClosed {
 		return
 	}
 
 	strat := getStrategy(sym)
-
-	checkBreakoutLong(sym, ltp, strat.BreakoutLong)
-	checkBounceBackBuy(sym, ltp)
-	if strat.AllowShort {
-		checkBreakdownShort(sym, ltp, strat.BreakoutShort)
-		checkQuickDropShort(sym, ltp)
+	if strat.DirectionBias == models.BiasNone || !inPriceBand(sym, ltp, strat) {
+		return
+	}
+	if !inEntryWindow(strat, nowIST()) {
+		return
 	}
-}
 
-func checkBreakoutLong(sym string, ltp, threshold float64) {
 	mu.Lock()
-	hl := highLow[sym]
-	pos := longPositions[sym]
+	ms := marketState(sym).Snapshot()
 	mu.Unlock()
+	ms.LTP = decisionPriceOf(ms, ltp)
+
+	if ms.Ticks < warmupTicks || time.Since(ms.FirstSeen) < warmupPeriod {
+		return
+	}
+
+	if !ms.FeedTime.IsZero() {
+		if age := time.Since(ms.FeedTime); age > maxQuoteAge {
+			fmt.Printf("Stale quote for %s (%s old) - skipping entries\n", sym, age.Round(time.Second))
+			return
+		}
+	}
+
+	if spread := ms.Spread(); maxSpreadPercent > 0 && spread*100 > maxSpreadPercent {
+		fmt.Printf("Wide spread for %s (%.2f%% > %.2f%%) - skipping entries\n", sym, spread*100, maxSpreadPercent)
+		return
+	}
 
-	if pos.EntryPrice > 0 {
+	// Shadow strategies are not bound by the live position caps.
+	evaluateShadow(ms, strat)
+
+	// With every slot taken, signals are only queued (if enabled) for
+	// drainSignalQueue to enter once a slot frees up.
+	full := totalOpen >= defaultMaxPositions
+	if full && signalQueueSize <= 0 {
+		fmt.Printf("Max positions (%d/%d) reached - skipping %s\n", totalOpen, defaultMaxPositions, sym)
+		return
+	}
+	if !full {
+		checkReentry(sym, ltp, strat)
+	}
+
+	var sigs []models.Signal
+	for _, s := range entryStrategies {
+		if s.Shadow {
+			continue
+		}
+		if s.Direction() == models.Short && (!strat.AllowShort || !shortable(sym)) {
+			continue
+		}
+		if !strat.Allows(s.Direction()) {
+			continue
+		}
+		if hasPosition(sym, s.Direction()) || hasPendingEntry(sym, s.Direction()) {
+			continue
+		}
+
+		sig, ok := s.Evaluate(ms, strat)
+		if !confirmed(sym, s.Name(), ok, strat.ConfirmTicks) {
+			continue
+		}
+		if chased := dayChangeExceeded(ms, sig.Direction, strat); chased != "" {
+			fmt.Printf("%s - skipping %s %s\n", chased, sig.Direction, sym)
+			continue
+		}
+		if stretched := overExtended(ms, sig.Direction, strat); stretched != "" {
+			fmt.Printf("%s - skipping %s %s\n", stretched, sig.Direction, sym)
+			continue
+		}
+		if !full {
+			if blocked := positionCapReached(sig.Direction, strat.Sector); blocked != "" {
+				fmt.Printf("%s - skipping %s %s\n", blocked, sig.Direction, sym)
+				continue
+			}
+		}
+
+		sigs = append(sigs, sig)
+		if entryMatch == matchFirst {
+			break
+		}
+	}
+
+	sig, ok := pickSignal(sigs)
+	if !ok {
+		return
+	}
+	if len(sigs) > 1 {
+		fmt.Printf("%d signals for %s - taking %s (strongest)\n", len(sigs), sym, sig.Strategy)
+	}
+
+	if full {
+		queueSignal(queuedSignal{Signal: sig, Leverage: strat.Leverage, Queued: time.Now()})
+		fmt.Printf("Max positions (%d/%d) reached - queued %s %s\n", totalOpen, defaultMaxPositions, sig.Direction, sym)
 		return
 	}
 
-	if hl.High > 0 && ltp > hl.High*(1+threshold) {
-		fmt.Printf("BREAKOUT LONG BUY %s @ %.2f (threshold %.3f)\n", sym, ltp, threshold)
-		enterLong(sym, ltp, getStrategy(sym).Leverage)
+	fmt.Printf("%s strength %.2f\n", sig.Reason, sig.Strength)
+	if sig.Direction == models.Long {
+		enterLong(sym, signalSource(sig), ltp, strat.Leverage, sig.Strength)
+	} else {
+		enterShort(sym, signalSource(sig), ltp, strat.Leverage, sig.Strength)
+	}
+}
+
+// dayChangeExceeded describes why a dir entry in ms's symbol would chase a
+// move that has already happened on the day, or returns "" when strat's
+// day-change limit allows it or the previous close is unknown.
+func dayChangeExceeded(ms *state.MarketState, dir models.Direction, strat models.StockStrategy) string {
+	change, ok := ms.DayChange()
+	if !ok {
+		return ""
+	}
+	if dir == models.Long && strat.MaxDayChangeLong > 0 && change > strat.MaxDayChangeLong {
+		return fmt.Sprintf("Up %.2f%% on the day (limit %.2f%%)", change*100, strat.MaxDayChangeLong*100)
+	}
+	if dir == models.Short && strat.MaxDayChangeShort > 0 && -change > strat.MaxDayChangeShort {
+		return fmt.Sprintf("Down %.2f%% on the day (limit %.2f%%)", -change*100, strat.MaxDayChangeShort*100)
+	}
+	return ""
+}
+
+// inEntryWindow reports whether strat allows entries at now: always when
+// it has no entry windows, otherwise only inside one of them. Windows were
+// checked when they were loaded.
+func inEntryWindow(strat models.StockStrategy, now time.Time) bool {
+	if len(strat.EntryWindows) == 0 {
+		return true
+	}
+	windows, _ := parseWindows(strat.EntryWindows)
+	for _, w := range windows {
+		if w.contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// overExtended describes why a dir entry in ms's symbol would come at a
+// stretched extreme - too far beyond the session VWAP or the day's open in
+// the entry's direction - or returns "" when strat's extension limits
+// allow it or the reference price is unknown.
+func overExtended(ms *state.MarketState, dir models.Direction, strat models.StockStrategy) string {
+	for _, ref := range []struct {
+		name  string
+		price float64
+		limit float64
+	}{
+		{"VWAP", ms.VWAP(), strat.MaxVWAPExtension},
+		{"open", ms.Open, strat.MaxOpenExtension},
+	} {
+		if ref.limit <= 0 || ref.price <= 0 || ms.LTP <= 0 {
+			continue
+		}
+		ext := (ms.LTP - ref.price) / ref.price
+		if dir == models.Short {
+			ext = -ext
+		}
+		if ext > ref.limit {
+			side := "above"
+			if dir == models.Short {
+				side = "below"
+			}
+			return fmt.Sprintf("%.2f%% %s the %s (limit %.2f%%)", ext*100, side, ref.name, ref.limit*100)
+		}
 	}
+	return ""
 }
 
-func checkBounceBackBuy(sym string, ltp float64) {
+// inPriceBand reports whether ltp lies within strat's entry price band,
+// logging the first time sym falls outside it.
+func inPriceBand(sym string, ltp float64, strat models.StockStrategy) bool {
+	in := ltp >= strat.MinPrice && (strat.MaxPrice <= 0 || ltp <= strat.MaxPrice)
+
 	mu.Lock()
-	hl := highLow[sym]
-	hist := ltpHistory[sym]
-	pos := longPositions[sym]
+	logged := outOfBand[sym]
+	if in {
+		delete(outOfBand, sym)
+	} else {
+		outOfBand[sym] = true
+	}
 	mu.Unlock()
 
-	if pos.EntryPrice > 0 || len(hist) < 2 {
-		return
+	if !in && !logged {
+		fmt.Printf("%s @ %.2f outside entry price band [%.2f, %.2f] - skipping entries\n", sym, ltp, strat.MinPrice, strat.MaxPrice)
 	}
+	return in
+}
+
+// positionCapReached reports which per-direction or per-sector cap would be
+// exceeded by a new entry, or "" if the entry is allowed.
+func positionCapReached(dir models.Direction, sector string) string {
+	mu.Lock()
+	defer mu.Unlock()
 
-	prev := hist[len(hist)-2]
-	if prev <= hl.Low*1.005 && ltp >= prev*(1+defaultBounceRebound) {
-		fmt.Printf("BOUNCE BACK BUY %s @ %.2f (prev %.2f, low %.2f)\n", sym, ltp, prev, hl.Low)
-		enterLong(sym, ltp, getStrategy(sym).Leverage)
+	if dir == models.Long && len(longPositions) >= defaultMaxLongs {
+		return fmt.Sprintf("Max longs (%d/%d) reached", len(longPositions), defaultMaxLongs)
+	}
+	if dir == models.Short && len(shortPositions) >= defaultMaxShorts {
+		return fmt.Sprintf("Max shorts (%d/%d) reached", len(shortPositions), defaultMaxShorts)
+	}
+
+	if sector == "" {
+		return ""
+	}
+	inSector := 0
+	for sym := range longPositions {
+		if stockStrategies[sym].Sector == sector {
+			inSector++
+		}
+	}
+	for sym := range shortPositions {
+		if stockStrategies[sym].Sector == sector {
+			inSector++
+		}
+	}
+	if inSector >= defaultMaxPerSector {
+		return fmt.Sprintf("Max positions in sector %s (%d/%d) reached", sector, inSector, defaultMaxPerSector)
 	}
+	return ""
 }
 
-func checkBreakdownShort(sym string, ltp, threshold float64) {
+// confirmed tracks how many consecutive ticks a strategy's condition has
+// held for sym and reports whether it has now held for need ticks. The
+// streak resets whenever the condition breaks and after it fires.
+func confirmed(sym, strategyName string, ok bool, need int) bool {
 	mu.Lock()
-	hl := highLow[sym]
-	pos := shortPositions[sym]
-	mu.Unlock()
+	defer mu.Unlock()
 
-	if pos.EntryPrice > 0 {
-		return
+	key := sym + "|" + strategyName
+	if !ok {
+		delete(signalStreaks, key)
+		return false
 	}
 
-	if hl.Low > 0 && ltp < hl.Low*(1-threshold) {
-		fmt.Printf("BREAKDOWN SHORT SELL %s @ %.2f (threshold %.3f)\n", sym, ltp, threshold)
-		enterShort(sym, ltp, getStrategy(sym).Leverage)
+	signalStreaks[key]++
+	if signalStreaks[key] < need {
+		return false
 	}
+	delete(signalStreaks, key)
+	return true
 }
 
-func checkQuickDropShort(sym string, ltp float64) {
+// setSymbolEnabled allows or blocks new entries for sym.
+func setSymbolEnabled(sym string, enabled bool) {
 	mu.Lock()
-	hist := ltpHistory[sym]
-	pos := shortPositions[sym]
-	mu.Unlock()
+	defer mu.Unlock()
+	if enabled {
+		delete(disabledSymbols, sym)
+	} else {
+		disabledSymbols[sym] = true
+	}
+}
 
-	if pos.EntryPrice > 0 || len(hist) < 2 {
-		return
+// setShortable restricts shorts to syms; an empty list allows every symbol.
+func setShortable(syms []string) {
+	mu.Lock()
+	defer mu.Unlock()
+	shortableSymbols = nil
+	if len(syms) > 0 {
+		shortableSymbols = make(map[string]bool, len(syms))
+		for _, sym := range syms {
+			shortableSymbols[sym] = true
+		}
 	}
+}
+
+// shortable reports whether sym may be sold short.
+func shortable(sym string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return shortableSymbols == nil || shortableSymbols[sym]
+}
 
-	prev := hist[len(hist)-2]
-	drop := (prev - ltp) / prev
-	if drop >= defaultQuickDrop {
-		fmt.Printf("QUICK DROP SHORT SELL %s @ %.2f (drop %.2f%%)\n", sym, ltp, drop*100)
-		enterShort(sym, ltp, getStrategy(sym).Leverage)
+func hasPosition(sym string, dir models.Direction) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if dir == models.Long {
+		_, ok := longPositions[sym]
+		return ok
 	}
+	_, ok := shortPositions[sym]
+	return ok
 }
 
-func squareOffAllPositions(now time.Time) {
-	fmt.Printf("Square-off time (%s) - exiting all\n", now.Format("15:04"))
+// positionsOpen reports whether any position is open.
+func positionsOpen() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return len(longPositions) > 0 || len(shortPositions) > 0
+}
 
+// squareOffDue reports whether now is past squareOffAt with a position or
+// pending entry still open. Square-off repeats on every such tick, so an
+// exit that failed, or a fill that arrived late, is closed on the next.
+func squareOffDue(now time.Time) bool {
+	if minuteOfDay(now) < squareOffAt {
+		return false
+	}
 	mu.Lock()
 	defer mu.Unlock()
+	return len(longPositions) > 0 || len(shortPositions) > 0 || len(pendingEntries) > 0
+}
 
-	for sym, pos := range longPositions {
-		ltp, _ := client.GetLTP("NSE", symbolToToken[sym])
-		exitLong(sym, ltp, pos.Qty, "EOD Square-off")
+// squareOffAllPositions cancels every pending entry and exits every open
+// position. A cancelled entry's fills are booked by pollPendingOrders and
+// squared off on a later tick.
+func squareOffAllPositions(now time.Time) {
+	fmt.Printf("Square-off time (%s) - exiting all\n", now.Format("15:04"))
+	mu.Lock()
+	pending := slices.Collect(maps.Keys(pendingEntries))
+	mu.Unlock()
+	for _, id := range pending {
+		if err := cancelOrder(id); err != nil {
+			log.Printf("Square-off cancel of pending entry %s failed: %v", id, err)
+		}
 	}
+	flattenAll(ReasonEOD)
+	fmt.Println("All positions squared off.")
+}
 
+// flattenAll exits every open position at market with the given reason,
+// longs then shorts, each in symbol order.
+func flattenAll(reason ExitReason) {
+	// Copy the books first: exitLong/exitShort take mu themselves.
+	mu.Lock()
+	longs := make(map[string]int, len(longPositions))
+	for sym, pos := range longPositions {
+		longs[sym] = pos.TotalQty
+	}
+	shorts := make(map[string]int, len(shortPositions))
 	for sym, pos := range shortPositions {
-		ltp, _ := client.GetLTP("NSE", symbolToToken[sym])
-		exitShort(sym, ltp, pos.Qty, "EOD Square-off")
+		shorts[sym] = pos.TotalQty
 	}
+	mu.Unlock()
 
-	fmt.Println("All positions squared off.")
+	ctx := context.Background()
+	for _, sym := range orderedSymbols(longs) {
+		unlock := lockExits(sym)
+		exitLong(sym, flattenPrice(ctx, sym), longs[sym], reason)
+		unlock()
+	}
+
+	for _, sym := range orderedSymbols(shorts) {
+		unlock := lockExits(sym)
+		exitShort(sym, flattenPrice(ctx, sym), shorts[sym], reason)
+		unlock()
+	}
+}
+
+var exitLocks = make(map[string]*sync.Mutex) // by symbol; the map is guarded by mu
+
+// lockExits takes sym's exit lock and returns its release. The polling
+// loop, the control API and the dead-man's switch all decide and send
+// exits under it, so two of them never exit the same position. It is
+// never taken while holding mu.
+func lockExits(sym string) (unlock func()) {
+	mu.Lock()
+	l, ok := exitLocks[sym]
+	if !ok {
+		l = new(sync.Mutex)
+		exitLocks[sym] = l
+	}
+	mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+// flattenPrice is sym's current LTP for booking a forced exit. When the
+// quote fails it falls back to the last polled LTP rather than booking the
+// exit at 0.
+func flattenPrice(ctx context.Context, sym string) float64 {
+	ltp, err := quotes.GetLTP(ctx, "NSE", tokenFor(sym))
+	if err == nil && ltp > 0 {
+		return ltp
+	}
+	mu.Lock()
+	var last float64
+	if ms, ok := markets[sym]; ok {
+		last = ms.LTP
+	}
+	mu.Unlock()
+	log.Printf("Quote for %s unavailable (%v), booking exit at last LTP %.2f", sym, err, last)
+	return last
 }
 
 func loadSavedTokenMap() bool {
-	path := filepath.Join("data", "token_map.json")
-	data, err := os.ReadFile(path)
+	data, err := os.ReadFile(dataPath("token_map.json"))
 	if err != nil {
 		return false
 	}
 
 	var saved struct {
-		Map map[string]string `json:"map"`
+		Map  map[string]string `json:"map"`
+		Lots map[string]int    `json:"lots"`
 	}
 	if err := json.Unmarshal(data, &saved); err != nil {
 		return false
@@ -711,30 +1897,24 @@ func loadSavedTokenMap() bool {
 	}
 
 	symbolToToken = saved.Map
+	for sym, ls := range saved.Lots {
+		lotSizes[sym] = ls
+	}
 	return true
 }
 
 func saveTokenMap() {
-	data, _ := json.MarshalIndent(struct {
-		Map map[string]string `json:"map"`
-	}{Map: symbolToToken}, "", "  ")
-
-	path := filepath.Join("data", "token_map.json")
-	os.MkdirAll(filepath.Dir(path), 0755)
-	os.WriteFile(path, data, 0644)
-	fmt.Println("Token map saved to data/token_map.json")
-}
-
-func max(a, b float64) float64 {
-	if a > b {
-		return a
-	}
-	return b
-}
+	mu.Lock()
+	saved := struct {
+		Map  map[string]string `json:"map"`
+		Lots map[string]int    `json:"lots,omitempty"`
+	}{Map: maps.Clone(symbolToToken), Lots: maps.Clone(lotSizes)}
+	mu.Unlock()
 
-func min(a, b float64) float64 {
-	if a < b {
-		return a
+	path := dataPath("token_map.json")
+	if err := writeJSONAtomic(path, saved); err != nil {
+		log.Printf("Token map not saved: %v", err)
+		return
 	}
-	return b
+	fmt.Printf("Token map saved to %s\n", path)
 }
@@ -0,0 +1,764 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+// resetBooks clears the package-level trading state between tests.
+func resetBooks(t *testing.T) {
+	t.Helper()
+	mu.Lock()
+	defer mu.Unlock()
+
+	paperTrading = true
+	longPositions = make(map[string]position)
+	shortPositions = make(map[string]position)
+	stockStrategies = make(map[string]models.StockStrategy)
+	recentOrders = make(map[string]time.Time)
+	markets = make(map[string]*state.MarketState)
+	signalStreaks = make(map[string]int)
+	pendingEntries = make(map[string]pendingOrder)
+	pendingExits = make(map[string]pendingExit)
+	paperOrders = make(map[string]*paperOrder)
+	paperBrackets = make(map[string]paperBracket)
+	closeOnly = false
+	disabledSymbols = make(map[string]bool)
+	entriesPaused = false
+	shortableSymbols = nil
+	shadowCounts = make(map[string]int)
+	shadowLast = make(map[string]time.Time)
+	outOfBand = make(map[string]bool)
+	jitterPercent = 0
+	signalQueue = nil
+	nextPoll = make(map[string]time.Time)
+	halted = make(map[string]time.Time)
+	lastPolled = make(map[string]time.Time)
+	reentryArms = make(map[string]reentryArm)
+	reentryCounts = make(map[string]int)
+	lossStreaks = make(map[string]int)
+	benched = make(map[string]bool)
+	oldLogDir := logDir
+	logDir = t.TempDir()
+	t.Cleanup(func() { logDir = oldLogDir })
+	tradeHistory = nil
+	dailyPnL = 0
+}
+
+func seedLong(sym string, entry float64, qty int) {
+	mu.Lock()
+	defer mu.Unlock()
+	longPositions[sym] = position{
+		TotalCost: entry * float64(qty), TotalQty: qty,
+		HighestPrice: entry, EntryTime: time.Now(),
+	}
+}
+
+func seedShort(sym string, entry float64, qty int) {
+	mu.Lock()
+	defer mu.Unlock()
+	shortPositions[sym] = position{
+		TotalCost: entry * float64(qty), TotalQty: qty,
+		LowestPrice: entry, EntryTime: time.Now(),
+	}
+}
+
+func lastTrade(t *testing.T) TradeRecord {
+	t.Helper()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(tradeHistory) == 0 {
+		t.Fatal("no trade recorded")
+	}
+	return tradeHistory[len(tradeHistory)-1]
+}
+
+func TestTrailingStopActivation(t *testing.T) {
+	strat := models.StockStrategy{TrailActivate: 0.01, TrailPercent: 0.005}
+
+	tests := []struct {
+		name      string
+		fn        func(entry, best float64, s models.StockStrategy) (float64, bool)
+		best      float64
+		wantArmed bool
+		wantStop  float64
+	}{
+		{"long below activation", trailingStopLong, 100.9, false, 0},
+		{"long at activation", trailingStopLong, 101, true, 101 * 0.995},
+		{"long above activation", trailingStopLong, 104, true, 104 * 0.995},
+		{"short above activation", trailingStopShort, 99.1, false, 0},
+		{"short at activation", trailingStopShort, 99, true, 99 * 1.005},
+		{"short below activation", trailingStopShort, 96, true, 96 * 1.005},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stop, armed := tt.fn(100, tt.best, strat)
+			if armed != tt.wantArmed {
+				t.Fatalf("armed = %v, want %v", armed, tt.wantArmed)
+			}
+			if armed && math.Abs(stop-tt.wantStop) > 1e-9 {
+				t.Errorf("stop = %.4f, want %.4f", stop, tt.wantStop)
+			}
+		})
+	}
+}
+
+func TestTrailingStopZeroActivationTrailsFromEntry(t *testing.T) {
+	strat := models.StockStrategy{TrailPercent: 0.01}
+	if _, armed := trailingStopLong(100, 100, strat); !armed {
+		t.Error("long trailing stop should be armed from entry with zero activation")
+	}
+	if _, armed := trailingStopShort(100, 100, strat); !armed {
+		t.Error("short trailing stop should be armed from entry with zero activation")
+	}
+}
+
+func TestCheckLongExitTrailsOnlyAfterActivation(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", SL: 0.01, Target: 0.05, TrailActivate: 0.01, TrailPercent: 0.003,
+	}
+	seedLong("TEST", 100, 10)
+
+	// A pullback before activation must not trail out; only the fixed SL applies.
+	checkLongExit("TEST", 100.5)
+	checkLongExit("TEST", 100.1)
+	if !hasPosition("TEST", models.Long) {
+		t.Fatal("position exited before the trailing stop was armed")
+	}
+
+	checkLongExit("TEST", 101.5)
+	checkLongExit("TEST", 101.1)
+	if hasPosition("TEST", models.Long) {
+		t.Fatal("position should have exited on the armed trailing stop")
+	}
+	if got := lastTrade(t).Reason; got != ReasonTrailingSL {
+		t.Errorf("reason = %q, want Trailing SL", got)
+	}
+}
+
+func TestCheckLongExitFixedSLBeforeActivation(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", SL: 0.01, Target: 0.05, TrailActivate: 0.01, TrailPercent: 0.003,
+	}
+	seedLong("TEST", 100, 10)
+
+	checkLongExit("TEST", 98.9)
+	if hasPosition("TEST", models.Long) {
+		t.Fatal("fixed SL should still apply before activation")
+	}
+	if got := lastTrade(t).Reason; got != ReasonFixedSL {
+		t.Errorf("reason = %q, want Fixed SL 1.0%%", got)
+	}
+}
+
+func TestCheckShortExitTrailsOnlyAfterActivation(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", SL: 0.01, Target: 0.05, TrailActivate: 0.01, TrailPercent: 0.003,
+	}
+	seedShort("TEST", 100, 10)
+
+	checkShortExit("TEST", 99.5)
+	checkShortExit("TEST", 99.9)
+	if !hasPosition("TEST", models.Short) {
+		t.Fatal("position exited before the trailing stop was armed")
+	}
+
+	checkShortExit("TEST", 98.5)
+	checkShortExit("TEST", 98.9)
+	if hasPosition("TEST", models.Short) {
+		t.Fatal("position should have exited on the armed trailing stop")
+	}
+	if got := lastTrade(t).Reason; got != ReasonTrailingSL {
+		t.Errorf("reason = %q, want Trailing SL", got)
+	}
+}
+
+func TestCheckExitMaxHoldTime(t *testing.T) {
+	resetBooks(t)
+	maxHoldTime = 90 * time.Minute
+	t.Cleanup(func() { maxHoldTime = 0 })
+	stockStrategies["TEST"] = models.StockStrategy{Class: "B", SL: 0.01, Target: 0.05}
+	stockStrategies["SLOW"] = models.StockStrategy{Class: "B", SL: 0.01, Target: 0.05, MaxHoldMinutes: 30}
+
+	seedLong("TEST", 100, 10)
+	checkLongExit("TEST", 100.2)
+	if !hasPosition("TEST", models.Long) {
+		t.Fatal("fresh position exited on max hold time")
+	}
+
+	mu.Lock()
+	pos := longPositions["TEST"]
+	pos.EntryTime = time.Now().Add(-91 * time.Minute)
+	longPositions["TEST"] = pos
+	mu.Unlock()
+	checkLongExit("TEST", 100.2)
+	if hasPosition("TEST", models.Long) {
+		t.Fatal("position held past the global max hold time did not exit")
+	}
+	if got := lastTrade(t).Reason; got != ReasonMaxHold {
+		t.Errorf("reason = %q, want Max hold time", got)
+	}
+
+	// The per-symbol limit overrides the global one.
+	seedShort("SLOW", 100, 10)
+	mu.Lock()
+	spos := shortPositions["SLOW"]
+	spos.EntryTime = time.Now().Add(-31 * time.Minute)
+	shortPositions["SLOW"] = spos
+	mu.Unlock()
+	checkShortExit("SLOW", 99.8)
+	if hasPosition("SLOW", models.Short) {
+		t.Fatal("short held past its per-symbol max hold time did not exit")
+	}
+}
+
+func TestConfirmedStreak(t *testing.T) {
+	resetBooks(t)
+
+	// Builds to the threshold and fires exactly on the 3rd tick.
+	for i, want := range []bool{false, false, true} {
+		if got := confirmed("TEST", "breakout_long", true, 3); got != want {
+			t.Fatalf("tick %d: confirmed = %v, want %v", i+1, got, want)
+		}
+	}
+
+	// Streak restarts after firing.
+	if confirmed("TEST", "breakout_long", true, 3) {
+		t.Fatal("fired on the first tick after a confirmed signal")
+	}
+
+	// A broken condition resets the streak.
+	confirmed("TEST", "breakout_long", true, 3)
+	confirmed("TEST", "breakout_long", false, 3)
+	for i, want := range []bool{false, false, true} {
+		if got := confirmed("TEST", "breakout_long", true, 3); got != want {
+			t.Fatalf("after reset, tick %d: confirmed = %v, want %v", i+1, got, want)
+		}
+	}
+}
+
+func TestConfirmedStreaksAreIndependent(t *testing.T) {
+	resetBooks(t)
+
+	confirmed("TEST", "breakout_long", true, 2)
+	if confirmed("TEST", "bounce_back", true, 2) {
+		t.Error("bounce_back fired on its first tick because of another strategy's streak")
+	}
+	if confirmed("OTHER", "breakout_long", true, 2) {
+		t.Error("OTHER fired on its first tick because of another symbol's streak")
+	}
+	if !confirmed("TEST", "breakout_long", true, 2) {
+		t.Error("breakout_long did not fire on its 2nd tick")
+	}
+}
+
+func TestConfirmedWithoutConfirmationFiresImmediately(t *testing.T) {
+	resetBooks(t)
+	for _, need := range []int{0, 1} {
+		if !confirmed("TEST", "breakout_long", true, need) {
+			t.Errorf("need=%d: did not fire on the first tick", need)
+		}
+	}
+}
+
+func TestCheckAllEntriesWaitsForConfirmTicks(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", BreakoutLong: 0.001, SL: 0.01, Target: 0.02, Leverage: 1, ConfirmTicks: 3,
+	}
+	markets["TEST"] = &state.MarketState{Symbol: "TEST", High: 100, Low: 95, Ticks: warmupTicks}
+
+	for i := 1; i <= 2; i++ {
+		checkAllEntries("TEST", 101)
+		if hasPosition("TEST", models.Long) {
+			t.Fatalf("entered on tick %d, want tick 3", i)
+		}
+	}
+	checkAllEntries("TEST", 101)
+	if !hasPosition("TEST", models.Long) {
+		t.Fatal("did not enter on the 3rd confirming tick")
+	}
+}
+
+func TestCheckAllEntriesSkipsDuringWarmup(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", BreakoutLong: 0.001, SL: 0.01, Target: 0.02, Leverage: 1,
+	}
+	markets["TEST"] = &state.MarketState{Symbol: "TEST", High: 100, Low: 95, Ticks: warmupTicks - 1}
+
+	checkAllEntries("TEST", 101)
+	if hasPosition("TEST", models.Long) {
+		t.Fatal("entered before warm-up completed")
+	}
+
+	markets["TEST"].Ticks = warmupTicks
+	checkAllEntries("TEST", 101)
+	if !hasPosition("TEST", models.Long) {
+		t.Fatal("did not enter after warm-up completed")
+	}
+}
+
+func TestShadowStrategyRecordsWithoutOrdering(t *testing.T) {
+	resetBooks(t)
+	oldLogDir := logDir
+	logDir = t.TempDir()
+	t.Cleanup(func() { logDir = oldLogDir })
+	old := entryStrategies
+	entryStrategies = slices.Clone(entryStrategies)
+	t.Cleanup(func() { entryStrategies = old })
+	if err := setShadow("breakout_long"); err != nil {
+		t.Fatal(err)
+	}
+
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", BreakoutLong: 0.001, SL: 0.01, Target: 0.02, Leverage: 1,
+	}
+	markets["TEST"] = &state.MarketState{Symbol: "TEST", High: 100, Low: 95, Ticks: warmupTicks}
+
+	checkAllEntries("TEST", 101)
+	checkAllEntries("TEST", 101)
+	if hasPosition("TEST", models.Long) {
+		t.Fatal("shadow strategy opened a position")
+	}
+	if n := shadowCounts["breakout_long"]; n != 1 {
+		t.Errorf("shadow count = %d, want 1 (repeat within dedup window dropped)", n)
+	}
+
+	data, err := os.ReadFile(filepath.Join(logDir, "shadow.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sig shadowSignal
+	if err := json.Unmarshal(bytes.TrimSpace(data), &sig); err != nil {
+		t.Fatalf("shadow.jsonl: %v", err)
+	}
+	if sig.Symbol != "TEST" || sig.Strategy != "breakout_long" || sig.Direction != models.Long {
+		t.Errorf("recorded %+v", sig)
+	}
+}
+
+func TestSetShadowUnknownStrategy(t *testing.T) {
+	if err := setShadow("nope"); err == nil {
+		t.Error("setShadow accepted an unknown strategy")
+	}
+}
+
+func TestCheckAllEntriesSkipsWideSpread(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", BreakoutLong: 0.001, SL: 0.01, Target: 0.02, Leverage: 1,
+	}
+	markets["TEST"] = &state.MarketState{
+		Symbol: "TEST", High: 100, Low: 95, Ticks: warmupTicks, Bid: 100, Ask: 102,
+	}
+
+	checkAllEntries("TEST", 101)
+	if hasPosition("TEST", models.Long) {
+		t.Fatal("entered with a ~2% spread")
+	}
+
+	markets["TEST"].Bid, markets["TEST"].Ask = 100.95, 101.05
+	checkAllEntries("TEST", 101)
+	if !hasPosition("TEST", models.Long) {
+		t.Fatal("did not enter with a tight spread")
+	}
+}
+
+func TestCheckAllEntriesSkipsOutsidePriceBand(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", BreakoutLong: 0.001, SL: 0.01, Target: 0.02, Leverage: 1, MinPrice: 150,
+	}
+	markets["TEST"] = &state.MarketState{Symbol: "TEST", High: 100, Low: 95, Ticks: warmupTicks}
+
+	checkAllEntries("TEST", 101)
+	if hasPosition("TEST", models.Long) {
+		t.Fatal("entered below the symbol's minimum price")
+	}
+	if !outOfBand["TEST"] {
+		t.Error("out-of-band symbol not recorded")
+	}
+
+	// A held position outside the band is still managed to exit.
+	seedLong("HELD", 10, 100)
+	stockStrategies["HELD"] = models.StockStrategy{Class: "B", SL: 0.01, Target: 0.02, Leverage: 1}
+	checkLongExit("HELD", 9)
+	if hasPosition("HELD", models.Long) {
+		t.Error("position below the band was not exited at its SL")
+	}
+
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", BreakoutLong: 0.001, SL: 0.01, Target: 0.02, Leverage: 1, MaxPrice: 150,
+	}
+	checkAllEntries("TEST", 101)
+	if !hasPosition("TEST", models.Long) {
+		t.Fatal("did not enter inside the band")
+	}
+	if outOfBand["TEST"] {
+		t.Error("band flag not cleared once back inside")
+	}
+}
+
+func TestCheckAllEntriesHonoursDirectionBias(t *testing.T) {
+	for _, tt := range []struct {
+		bias  string
+		enter bool
+	}{
+		{"", true},
+		{models.BiasBoth, true},
+		{models.BiasLong, true},
+		{models.BiasShort, false},
+		{models.BiasNone, false},
+	} {
+		resetBooks(t)
+		stockStrategies["TEST"] = models.StockStrategy{
+			Class: "B", BreakoutLong: 0.001, SL: 0.01, Target: 0.02, Leverage: 1, DirectionBias: tt.bias,
+		}
+		markets["TEST"] = &state.MarketState{Symbol: "TEST", High: 100, Low: 95, Ticks: warmupTicks}
+
+		checkAllEntries("TEST", 101)
+		if got := hasPosition("TEST", models.Long); got != tt.enter {
+			t.Errorf("bias %q: long breakout entered = %v, want %v", tt.bias, got, tt.enter)
+		}
+	}
+}
+
+func TestLoadBrainConfigRejectsUnknownBias(t *testing.T) {
+	resetBooks(t)
+	oldConfig := brainConfigPath
+	t.Cleanup(func() { brainConfigPath = oldConfig })
+	brainConfigPath = filepath.Join(t.TempDir(), "config.json")
+
+	if err := os.WriteFile(brainConfigPath, []byte(`{"TEST":{"class":"B","direction_bias":"lon"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadBrainConfig(); err == nil {
+		t.Fatal("loaded an unknown direction_bias")
+	}
+
+	if err := os.WriteFile(brainConfigPath, []byte(`{"TEST":{"class":"B","direction_bias":"long"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadBrainConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if got := getStrategy("TEST").DirectionBias; got != models.BiasLong {
+		t.Errorf("direction_bias = %q, want long", got)
+	}
+}
+
+func TestCheckAllEntriesDayChangeGate(t *testing.T) {
+	tests := []struct {
+		name      string
+		dir       models.Direction
+		prevClose float64
+		limit     float64
+		enter     bool
+	}{
+		{"long within limit", models.Long, 95, 0.08, true},
+		{"long chasing", models.Long, 95, 0.05, false},
+		{"long without prev close", models.Long, 0, 0.05, true},
+		{"long gate off", models.Long, 80, 0, true},
+		{"short within limit", models.Short, 100, 0.08, true},
+		{"short chasing", models.Short, 100, 0.05, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetBooks(t)
+			strat := models.StockStrategy{
+				Class: "B", SL: 0.01, Target: 0.02, Leverage: 1, AllowShort: true,
+				BreakoutLong: 0.001, BreakoutShort: 0.001,
+			}
+			ltp := 101.0
+			if tt.dir == models.Short {
+				ltp = 94
+				strat.MaxDayChangeShort = tt.limit
+			} else {
+				strat.MaxDayChangeLong = tt.limit
+			}
+			stockStrategies["TEST"] = strat
+			markets["TEST"] = &state.MarketState{Symbol: "TEST", High: 100, Low: 95, PrevClose: tt.prevClose, Ticks: warmupTicks}
+
+			checkAllEntries("TEST", ltp)
+			if got := hasPosition("TEST", tt.dir); got != tt.enter {
+				t.Errorf("%s entered = %v, want %v", tt.dir, got, tt.enter)
+			}
+		})
+	}
+}
+
+func TestCheckAllEntriesExtensionGate(t *testing.T) {
+	tests := []struct {
+		name       string
+		dir        models.Direction
+		vwap, open float64
+		vwapLimit  float64
+		openLimit  float64
+		enter      bool
+	}{
+		{"long near vwap", models.Long, 99.5, 0, 0.02, 0, true},
+		{"long stretched above vwap", models.Long, 97, 0, 0.02, 0, false},
+		{"long stretched above open", models.Long, 0, 96, 0, 0.03, false},
+		{"long within both", models.Long, 100, 99, 0.02, 0.03, true},
+		{"long gate off", models.Long, 90, 90, 0, 0, true},
+		{"long without vwap or open", models.Long, 0, 0, 0.01, 0.01, true},
+		{"short stretched below vwap", models.Short, 98, 0, 0.02, 0, false},
+		{"short stretched below open", models.Short, 0, 99, 0, 0.03, false},
+		{"short near open", models.Short, 0, 95, 0, 0.03, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetBooks(t)
+			strat := models.StockStrategy{
+				Class: "B", SL: 0.01, Target: 0.02, Leverage: 1, AllowShort: true,
+				BreakoutLong: 0.001, BreakoutShort: 0.001,
+				MaxVWAPExtension: tt.vwapLimit, MaxOpenExtension: tt.openLimit,
+			}
+			ltp := 101.0
+			if tt.dir == models.Short {
+				ltp = 94
+			}
+			stockStrategies["TEST"] = strat
+			markets["TEST"] = &state.MarketState{
+				Symbol: "TEST", High: 100, Low: 95, Open: tt.open, Ticks: warmupTicks,
+			}
+			if tt.vwap > 0 {
+				markets["TEST"].SumPV, markets["TEST"].SumVolume = tt.vwap*1000, 1000
+			}
+
+			checkAllEntries("TEST", ltp)
+			if got := hasPosition("TEST", tt.dir); got != tt.enter {
+				t.Errorf("%s entered = %v, want %v", tt.dir, got, tt.enter)
+			}
+		})
+	}
+}
+
+func TestFillPriceUsesBookInPaper(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{Class: "B"}
+	markets["TEST"] = &state.MarketState{Symbol: "TEST", Bid: 99.9, Ask: 100.1}
+
+	if got := fillPrice("TEST", client.Buy, 100, ""); got != 100.1 {
+		t.Errorf("buy fill = %.2f, want ask 100.10", got)
+	}
+	if got := fillPrice("TEST", client.Sell, 100, ""); got != 99.9 {
+		t.Errorf("sell fill = %.2f, want bid 99.90", got)
+	}
+
+	// Without a book, paper fills fall back to slippage.
+	if got, want := fillPrice("OTHER", client.Buy, 100, ""), 100*(1+slippageBps["B"]/10000); math.Abs(got-want) > 1e-9 {
+		t.Errorf("buy fill without book = %.4f, want %.4f", got, want)
+	}
+}
+
+func TestPaperEntryDefaultFillIsSlipped(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["BOOK"] = models.StockStrategy{Class: "B", SL: 0.01, Target: 0.02, Leverage: 1}
+	stockStrategies["BARE"] = models.StockStrategy{Class: "B", SL: 0.01, Target: 0.02, Leverage: 1}
+	markets["BOOK"] = &state.MarketState{Symbol: "BOOK", LTP: 100, Bid: 99.9, Ask: 100.1}
+
+	enterLong("BOOK", entrySource{}, 100, 1, 0)
+	enterShort("BARE", entrySource{}, 100, 1, 0)
+
+	mu.Lock()
+	long, short := longPositions["BOOK"], shortPositions["BARE"]
+	mu.Unlock()
+	if long.AvgEntry() != 100.1 {
+		t.Errorf("long entry = %.2f, want the ask 100.10", long.AvgEntry())
+	}
+	if want := 100 * (1 - slippageBps["B"]/10000); math.Abs(short.AvgEntry()-want) > 1e-9 {
+		t.Errorf("short entry = %.4f, want ltp slipped to %.4f", short.AvgEntry(), want)
+	}
+}
+
+func TestFillPricePolicies(t *testing.T) {
+	resetBooks(t)
+	t.Cleanup(func() { fillPolicy = fillLTP })
+	stockStrategies["TEST"] = models.StockStrategy{Class: "B"}
+	markets["TEST"] = &state.MarketState{Symbol: "TEST", Bid: 99.9, Ask: 100.1}
+	paperOrders["PAPER-9"] = &paperOrder{Status: client.OrderStatus{OrderNo: "PAPER-9", Status: client.StatusComplete, AvgPrice: 100.05}}
+
+	tests := []struct {
+		policy string
+		id     string
+		want   float64
+	}{
+		{fillLTP, "PAPER-9", 100.1}, // paper: conservative
+		{fillConservative, "PAPER-9", 100.1},
+		{fillActual, "PAPER-9", 100.05},
+		{fillActual, "", 100.1},          // no order: conservative
+		{fillActual, "PAPER-404", 100.1}, // unknown order: conservative
+	}
+	for _, tt := range tests {
+		if err := setFillPolicy(tt.policy); err != nil {
+			t.Fatal(err)
+		}
+		if got := fillPrice("TEST", client.Buy, 100, tt.id); got != tt.want {
+			t.Errorf("%s fill for %q = %.2f, want %.2f", tt.policy, tt.id, got, tt.want)
+		}
+	}
+
+	paperTrading = false
+	fillPolicy = fillLTP
+	if got := fillPrice("TEST", client.Buy, 100, "1"); got != 100 {
+		t.Errorf("live ltp fill = %.2f, want the ltp 100", got)
+	}
+
+	if err := setFillPolicy("mid"); err == nil {
+		t.Error("setFillPolicy accepted an unknown policy")
+	}
+}
+
+func TestResolveDataFiles(t *testing.T) {
+	oldDir, oldPaths := dataDir, make([]string, len(dataFiles))
+	for i, f := range dataFiles {
+		oldPaths[i] = *f.path
+	}
+	t.Cleanup(func() {
+		dataDir = oldDir
+		for i, f := range dataFiles {
+			*f.path = oldPaths[i]
+		}
+	})
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	dirFlags(fs)
+	fs.StringVar(&stocksPath, "stocks", stocksPath, "")
+	fs.StringVar(&brainConfigPath, "config", brainConfigPath, "")
+	if err := fs.Parse([]string{"-data-dir", "/srv/paper", "-config", "/etc/axiom.json"}); err != nil {
+		t.Fatal(err)
+	}
+	resolveDataFiles(fs)
+
+	if want := filepath.Join("/srv/paper", "stocks.json"); stocksPath != want {
+		t.Errorf("stocksPath = %q, want %q", stocksPath, want)
+	}
+	if brainConfigPath != "/etc/axiom.json" {
+		t.Errorf("brainConfigPath = %q, want the explicit -config", brainConfigPath)
+	}
+}
+
+func TestWriteJSONAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state", "token_map.json")
+
+	if err := writeJSONAtomic(path, map[string]string{"SBIN": "3045"}); err != nil {
+		t.Fatal(err)
+	}
+	// A value that cannot be encoded must leave the old file untouched.
+	if err := writeJSONAtomic(path, map[string]any{"bad": make(chan int)}); err == nil {
+		t.Fatal("encoded a channel")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(data, &got); err != nil || got["SBIN"] != "3045" {
+		t.Errorf("file = %s (%v), want the first write intact", data, err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("%d files in the directory, want no temp files left behind", len(entries))
+	}
+}
+
+// fixedSignal is a strategy that always fires with a fixed strength.
+type fixedSignal struct {
+	name     string
+	dir      models.Direction
+	strength float64
+}
+
+func (f fixedSignal) Name() string                { return f.name }
+func (f fixedSignal) Direction() models.Direction { return f.dir }
+func (f fixedSignal) Evaluate(ms *state.MarketState, cfg models.StockStrategy) (models.Signal, bool) {
+	return models.Signal{Symbol: ms.Symbol, Direction: f.dir, Price: ms.LTP, Strategy: f.name, Strength: f.strength}, true
+}
+
+func TestCheckAllEntriesMatchMode(t *testing.T) {
+	old, oldMatch := entryStrategies, entryMatch
+	t.Cleanup(func() { entryStrategies, entryMatch = old, oldMatch })
+	entryStrategies = []registeredStrategy{
+		{Strategy: fixedSignal{"weak_long", models.Long, 1.2}},
+		{Strategy: fixedSignal{"strong_short", models.Short, 3}},
+	}
+
+	for _, tt := range []struct {
+		mode string
+		want models.Direction
+	}{
+		{matchFirst, models.Long},
+		{matchStrongest, models.Short},
+	} {
+		resetBooks(t)
+		if err := setEntryMatch(tt.mode); err != nil {
+			t.Fatal(err)
+		}
+		stockStrategies["TEST"] = models.StockStrategy{Class: "B", SL: 0.01, Target: 0.02, Leverage: 1, AllowShort: true}
+		markets["TEST"] = &state.MarketState{Symbol: "TEST", High: 100, Low: 95, Ticks: warmupTicks}
+
+		checkAllEntries("TEST", 100)
+		other := models.Short
+		if tt.want == models.Short {
+			other = models.Long
+		}
+		if !hasPosition("TEST", tt.want) || hasPosition("TEST", other) {
+			t.Errorf("%s match: long %v, short %v; want only %s", tt.mode,
+				hasPosition("TEST", models.Long), hasPosition("TEST", models.Short), tt.want)
+		}
+	}
+
+	if err := setEntryMatch("all"); err == nil {
+		t.Error("setEntryMatch accepted an unknown mode")
+	}
+}
+
+func TestDedupEntriesOnly(t *testing.T) {
+	resetBooks(t)
+	paperTrading = false
+	fb := &flakyBroker{fails: 1}
+	oldBroker := broker
+	t.Cleanup(func() { broker = oldBroker })
+	broker = fb
+
+	// A failed entry is not counted, so its retry goes out.
+	p := entryOrder("TEST", models.Long, 10)
+	if _, err := placeOrder(p); err == nil {
+		t.Fatal("first entry did not fail")
+	}
+	if _, err := placeOrder(p); err != nil {
+		t.Fatalf("retry of a failed entry: %v", err)
+	}
+	if _, err := placeOrder(p); err == nil {
+		t.Error("repeat of an accepted entry was not suppressed")
+	}
+
+	// Exits are never suppressed, even on the side just entered.
+	seedShort("TEST", 100, 10)
+	exitShort("TEST", 99, 5, ReasonTarget)
+	exitShort("TEST", 99, 5, ReasonTarget)
+	if hasPosition("TEST", models.Short) {
+		t.Error("second exit in the same minute was suppressed")
+	}
+	if len(fb.orders) != 3 {
+		t.Errorf("sent %d orders, want an entry and two exits", len(fb.orders))
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+const (
+	matchFirst     = "first"     // act on the first strategy to fire, in entryStrategies order
+	matchStrongest = "strongest" // evaluate every strategy and act on the highest Strength
+)
+
+// entryMatch decides which signal is acted on when several strategies
+// fire for a symbol on the same tick. Either way at most one entry (or
+// queued signal) results per symbol per tick.
+var entryMatch = matchFirst
+
+func setEntryMatch(s string) error {
+	switch s {
+	case matchFirst, matchStrongest:
+		entryMatch = s
+		return nil
+	}
+	return fmt.Errorf("unknown entry match %q (want %s or %s)", s, matchFirst, matchStrongest)
+}
+
+// pickSignal chooses the signal to act on from sigs, which are in
+// evaluation order. Strongest-match ties go to the earlier strategy.
+func pickSignal(sigs []models.Signal) (models.Signal, bool) {
+	if len(sigs) == 0 {
+		return models.Signal{}, false
+	}
+	best := sigs[0]
+	if entryMatch == matchStrongest {
+		for _, sig := range sigs[1:] {
+			if sig.Strength > best.Strength {
+				best = sig
+			}
+		}
+	}
+	return best, true
+}
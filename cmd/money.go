@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+)
+
+var (
+	currencySymbol = "₹" // prefixed to amounts in logs and reports; "" omits it
+	moneyDecimals  = 2
+)
+
+// money formats an amount for logs and reports.
+func money(v float64) string {
+	return currencySymbol + plainMoney(v)
+}
+
+// plainMoney formats an amount without the currency symbol, for
+// machine-readable output such as CSV.
+func plainMoney(v float64) string {
+	return strconv.FormatFloat(v, 'f', moneyDecimals, 64)
+}
+
+// roundMoney rounds v to moneyDecimals, the precision amounts are shown
+// at, so a trade that prints as 0.00 is not counted as a win or a loss.
+func roundMoney(v float64) float64 {
+	r, _ := strconv.ParseFloat(plainMoney(v), 64)
+	return r
+}
+
+// moneyFlags registers the amount-formatting flags on fs, so the trading
+// loop and the report subcommands share them.
+func moneyFlags(fs *flag.FlagSet) {
+	fs.StringVar(&currencySymbol, "currency", currencySymbol, `currency symbol for amounts in logs and reports ("" omits it)`)
+	fs.IntVar(&moneyDecimals, "decimals", moneyDecimals, "decimal places for amounts")
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/notify"
+)
+
+// pendingOrder is a resting entry order waiting to be filled.
+type pendingOrder struct {
+	ID        string
+	Symbol    string
+	Direction models.Direction
+	Qty       int
+	Limit     float64
+	Leverage  float64
+	Product   string
+	Source    entrySource
+	Placed    time.Time
+	Filled    int     // qty already opened as a position
+	AvgPrice  float64 // the order's average price when Filled was opened
+	Chases    int     // fresh orders sent for the rest by the chase policy
+}
+
+// restNotional is the notional of p's unfilled rest, at its limit or, for
+// a market remainder, the last price. Callers hold mu.
+func (p pendingOrder) restNotional() float64 {
+	px := p.Limit
+	if ms, ok := markets[p.Symbol]; ok && px == 0 {
+		px = ms.LTP
+	}
+	return float64(p.Qty-p.Filled) * px
+}
+
+// paperOrder is an order held by the simulated paper-trading book.
+type paperOrder struct {
+	Symbol  string
+	Side    client.Side
+	Type    string
+	Qty     int
+	Limit   float64
+	Trigger float64
+	Status  client.OrderStatus
+}
+
+// paperBracket is the target and stop legs of a paper bracket order.
+type paperBracket struct {
+	Target string
+	Stop   string
+}
+
+var (
+	pendingEntries  = make(map[string]pendingOrder) // by order ID
+	pendingOrderTTL = 2 * time.Minute               // unfilled entries are cancelled after this
+
+	paperOrders   = make(map[string]*paperOrder)
+	paperBrackets = make(map[string]paperBracket) // by entry order ID
+	paperOrderSeq int
+)
+
+// placePaperOrder books a paper order. Market orders complete at once;
+// limit and stop orders rest until matchPaperOrders sees the price cross,
+// except IOC limits, which fill against the last price or are cancelled.
+func placePaperOrder(p client.OrderParams) string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	paperOrderSeq++
+	id := fmt.Sprintf("PAPER-%d", paperOrderSeq)
+
+	o := &paperOrder{Symbol: p.Symbol, Side: p.Side, Type: p.PriceType, Qty: p.Qty, Limit: p.Price, Trigger: p.Trigger}
+	o.Status = client.OrderStatus{OrderNo: id, Status: client.StatusOpen}
+	switch {
+	case p.PriceType == "" || p.PriceType == client.PriceMarket:
+		o.Status.Status = client.StatusComplete
+		o.Status.FilledQty = p.Qty
+	case p.Validity == client.ValidityIOC:
+		o.Status.Status = client.StatusCancelled
+		if ms, ok := markets[p.Symbol]; ok && limitCrossed(p.Side, ms.LTP, p.Price) {
+			o.Status.Status = client.StatusComplete
+			o.Status.FilledQty = p.Qty
+			o.Status.AvgPrice = p.Price
+		}
+	}
+	paperOrders[id] = o
+	return id
+}
+
+// limitCrossed reports whether ltp has reached a limit on side: buys at or
+// below it, sells at or above it.
+func limitCrossed(side client.Side, ltp, limit float64) bool {
+	return (side == client.Buy && ltp <= limit) || (side == client.Sell && ltp >= limit)
+}
+
+// stopTriggered reports whether ltp has reached a stop trigger on side:
+// buys at or above it, sells at or below it.
+func stopTriggered(side client.Side, ltp, trigger float64) bool {
+	return (side == client.Buy && ltp >= trigger) || (side == client.Sell && ltp <= trigger)
+}
+
+// matchPaperOrders fills resting paper orders for sym that ltp has
+// reached. Limits fill at the limit: buys at or below it, sells at or
+// above it. A triggered SL-MKT fills at ltp; a triggered SL-LMT becomes a
+// plain limit.
+func matchPaperOrders(sym string, ltp float64) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, o := range paperOrders {
+		if o.Symbol != sym || o.Status.Status != client.StatusOpen {
+			continue
+		}
+		switch o.Type {
+		case client.PriceSLMarket:
+			if stopTriggered(o.Side, ltp, o.Trigger) {
+				o.Status.Status = client.StatusComplete
+				o.Status.FilledQty = o.Qty
+				o.Status.AvgPrice = ltp
+			}
+			continue
+		case client.PriceSLLimit:
+			if !stopTriggered(o.Side, ltp, o.Trigger) {
+				continue
+			}
+			o.Type = client.PriceLimit
+		}
+		if limitCrossed(o.Side, ltp, o.Limit) {
+			o.Status.Status = client.StatusComplete
+			o.Status.FilledQty = o.Qty
+			o.Status.AvgPrice = o.Limit
+		}
+	}
+
+	// As at the exchange, a bracket leg that fills cancels its sibling.
+	for _, b := range paperBrackets {
+		target, stop := paperOrders[b.Target], paperOrders[b.Stop]
+		switch {
+		case target.Status.Status == client.StatusComplete && stop.Status.Status == client.StatusOpen:
+			stop.Status.Status = client.StatusCancelled
+		case stop.Status.Status == client.StatusComplete && target.Status.Status == client.StatusOpen:
+			target.Status.Status = client.StatusCancelled
+		}
+	}
+}
+
+// placePaperBracketLegs rests the target and stop legs of paper bracket
+// entry id, filled for qty, as the exchange does once the entry fills.
+func placePaperBracketLegs(id, sym string, dir models.Direction, qty int, stop, target float64) {
+	side := client.Sell
+	if dir == models.Short {
+		side = client.Buy
+	}
+	b := paperBracket{
+		Target: placePaperOrder(client.OrderParams{Symbol: sym, Side: side, Qty: qty, PriceType: client.PriceLimit, Price: target}),
+		Stop:   placePaperOrder(client.OrderParams{Symbol: sym, Side: side, Qty: qty, PriceType: client.PriceSLMarket, Trigger: stop}),
+	}
+	mu.Lock()
+	paperBrackets[id] = b
+	mu.Unlock()
+}
+
+// cancelPaperBracket cancels the legs of paper bracket entry id that are
+// still open, as the broker's bracket exit does.
+func cancelPaperBracket(id string) {
+	mu.Lock()
+	b, ok := paperBrackets[id]
+	mu.Unlock()
+	if ok {
+		cancelOrder(b.Target)
+		cancelOrder(b.Stop)
+	}
+}
+
+// prunePaperOrders drops paper orders that have reached a terminal status,
+// and paper brackets with no leg still open. It runs at the open, once
+// the previous session's fills have all been booked. Callers hold mu.
+func prunePaperOrders() {
+	live := make(map[string]bool) // legs of brackets still working
+	for id, b := range paperBrackets {
+		if paperOrders[b.Target].Status.Status == client.StatusOpen || paperOrders[b.Stop].Status.Status == client.StatusOpen {
+			live[b.Target], live[b.Stop] = true, true
+			continue
+		}
+		delete(paperBrackets, id)
+	}
+	maps.DeleteFunc(paperOrders, func(id string, o *paperOrder) bool {
+		return o.Status.Status != client.StatusOpen && !live[id]
+	})
+}
+
+// bracketLegs returns the current state of the target and stop legs of
+// bracket entry id from the paper book or the broker.
+func bracketLegs(id string) (target, stop client.OrderStatus, err error) {
+	if paperTrading {
+		mu.Lock()
+		defer mu.Unlock()
+		b, ok := paperBrackets[id]
+		if !ok {
+			return client.OrderStatus{}, client.OrderStatus{}, fmt.Errorf("unknown paper bracket %s", id)
+		}
+		return paperOrders[b.Target].Status, paperOrders[b.Stop].Status, nil
+	}
+	return broker.GetBracketLegs(context.Background(), id)
+}
+
+// orderStatus returns the current state of an order from the paper book
+// or the broker, so fills are handled the same way in both modes.
+func orderStatus(id string) (client.OrderStatus, error) {
+	if paperTrading {
+		mu.Lock()
+		defer mu.Unlock()
+		o, ok := paperOrders[id]
+		if !ok {
+			return client.OrderStatus{}, fmt.Errorf("unknown paper order %s", id)
+		}
+		return o.Status, nil
+	}
+	return broker.GetOrderStatus(context.Background(), id)
+}
+
+func cancelOrder(id string) error {
+	if paperTrading {
+		mu.Lock()
+		defer mu.Unlock()
+		if o, ok := paperOrders[id]; ok && o.Status.Status == client.StatusOpen {
+			o.Status.Status = client.StatusCancelled
+		}
+		return nil
+	}
+	return broker.CancelOrder(context.Background(), id)
+}
+
+// submitLimitEntry places a limit entry and tracks it until it fills,
+// is rejected, or expires.
+func submitLimitEntry(sym string, src entrySource, dir models.Direction, qty int, limit, leverage float64) {
+	p := entryOrder(sym, dir, qty)
+	p.PriceType, p.Price = client.PriceLimit, limit
+	id, p, err := placeEntry(p)
+	if err != nil {
+		notifyTrade(notify.EventError, fmt.Sprintf("%s ENTRY FAILED %s: %v", dir, sym, err))
+		return
+	}
+	qty = p.Qty
+
+	mu.Lock()
+	pendingEntries[id] = pendingOrder{
+		ID: id, Symbol: sym, Direction: dir, Qty: qty,
+		Limit: limit, Leverage: leverage, Product: p.Product, Source: src, Placed: time.Now(),
+	}
+	mu.Unlock()
+
+	logTrade(fmt.Sprintf("PENDING %s %s LMT @ %.2f Qty: %d (order %s)", dir, sym, limit, qty, id))
+}
+
+// submitBracketEntry enters at market with exchange-managed target and SL
+// legs at the symbol's Target and SL distances from ltp.
+func submitBracketEntry(sym string, src entrySource, dir models.Direction, qty int, ltp, leverage float64) {
+	strat := getStrategy(sym)
+	tick := tickSizeFor(sym)
+	side := client.Buy
+	if dir == models.Short {
+		side = client.Sell
+	}
+
+	p := client.BracketParams{
+		OrderParams:  marketOrder(sym, dir, side, qty),
+		TargetPoints: max(client.RoundToTick(ltp*strat.Target, tick), tick),
+		StopPoints:   max(client.RoundToTick(ltp*strat.SL, tick), tick),
+	}
+	id, err := placeBracket(p)
+	if err != nil {
+		var rej *client.OrderRejection
+		if errors.As(err, &rej) {
+			reactToRejection(sym, rej)
+		}
+		notifyTrade(notify.EventError, fmt.Sprintf("%s BRACKET ENTRY FAILED %s: %v", dir, sym, err))
+		return
+	}
+
+	fill := fillPrice(sym, side, ltp, id)
+	stop, target := fill-p.StopPoints, fill+p.TargetPoints
+	if dir == models.Short {
+		stop, target = fill+p.StopPoints, fill-p.TargetPoints
+	}
+
+	if paperTrading {
+		placePaperBracketLegs(id, sym, dir, qty, stop, target)
+	}
+
+	pos := position{HighestPrice: ltp, LowestPrice: ltp, Product: client.ProductBO, BracketOrder: id, BracketStop: stop, BracketTarget: target}
+	pos.addLot(fill, qty, leverage, src)
+	mu.Lock()
+	if dir == models.Long {
+		longPositions[sym] = pos
+	} else {
+		shortPositions[sym] = pos
+	}
+	mu.Unlock()
+
+	notifyTrade(notify.EventEntry, fmt.Sprintf("ENTRY %s %s BRACKET @ %.2f Qty: %d SL %.2f Target %.2f Leverage: %.1f (order %s)",
+		dir, sym, fill, qty, stop, target, leverage, id))
+}
+
+// pollBracketPositions books the exit of each bracket position whose stop
+// or target leg has filled, at the leg's fill price. The legs are watched
+// at the broker rather than mirrored from the last price, which may cross
+// a leg's price without the leg filling.
+func pollBracketPositions() {
+	type bracket struct {
+		sym string
+		dir models.Direction
+		pos position
+	}
+	mu.Lock()
+	var brackets []bracket
+	for _, dir := range []models.Direction{models.Long, models.Short} {
+		book := positionsFor(dir)
+		for _, sym := range orderedSymbols(book) {
+			if pos := book[sym]; pos.BracketOrder != "" {
+				brackets = append(brackets, bracket{sym: sym, dir: dir, pos: pos})
+			}
+		}
+	}
+	mu.Unlock()
+
+	for _, b := range brackets {
+		target, stop, err := bracketLegs(b.pos.BracketOrder)
+		if err != nil {
+			log.Printf("Bracket legs for %s (%s) unavailable: %v", b.pos.BracketOrder, b.sym, err)
+			continue
+		}
+		switch {
+		case stop.Status == client.StatusComplete:
+			bookBracketFill(b.sym, b.dir, b.pos, stop, b.pos.BracketStop, ReasonBracketSL)
+		case target.Status == client.StatusComplete:
+			bookBracketFill(b.sym, b.dir, b.pos, target, b.pos.BracketTarget, ReasonBracketTarget)
+		}
+	}
+}
+
+// bookBracketFill books the exit of bracket position pos by a filled leg,
+// unless an exit has already closed it.
+func bookBracketFill(sym string, dir models.Direction, pos position, st client.OrderStatus, price float64, reason ExitReason) {
+	defer lockExits(sym)()
+	mu.Lock()
+	cur, ok := positionsFor(dir)[sym]
+	mu.Unlock()
+	if !ok || cur.BracketOrder != pos.BracketOrder {
+		return
+	}
+	bookExchangeFill(sym, dir, cur, st, price, reason)
+}
+
+func hasPendingEntry(sym string, dir models.Direction) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, p := range pendingEntries {
+		if p.Symbol == sym && p.Direction == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// pollPendingOrders checks every pending entry once per tick, in symbol
+// order, opening the position as it fills and dropping rejected, cancelled,
+// or expired orders. A partly filled order is settled by the symbol's
+// partial-fill policy.
+func pollPendingOrders() {
+	mu.Lock()
+	pending := make([]pendingOrder, 0, len(pendingEntries))
+	for _, p := range pendingEntries {
+		pending = append(pending, p)
+	}
+	mu.Unlock()
+	slices.SortFunc(pending, func(a, b pendingOrder) int {
+		return cmp.Or(compareSymbols(a.Symbol, b.Symbol), strings.Compare(a.ID, b.ID))
+	})
+
+	for _, p := range pending {
+		st, err := orderStatus(p.ID)
+		if err != nil {
+			log.Printf("Order status for %s (%s) failed: %v", p.ID, p.Symbol, err)
+			continue
+		}
+
+		switch st.Status {
+		case client.StatusComplete:
+			removePendingEntry(p.ID)
+			openEntryFill(p, cmp.Or(st.FilledQty, p.Qty), st.AvgPrice)
+
+		case client.StatusRejected, client.StatusCancelled:
+			removePendingEntry(p.ID)
+			if p.Filled > 0 || st.FilledQty > 0 {
+				settlePartial(openEntryFill(p, st.FilledQty, st.AvgPrice), false)
+				continue
+			}
+			logTrade(fmt.Sprintf("%s ENTRY %s %s: order %s %s", p.Direction, st.Status, p.Symbol, p.ID, st.Reason))
+			if st.Status == client.StatusRejected {
+				reactToRejection(p.Symbol, &client.OrderRejection{Reason: client.ClassifyRejection(st.Reason), Message: st.Reason})
+			}
+
+		default:
+			if st.FilledQty > p.Filled {
+				p = openEntryFill(p, st.FilledQty, st.AvgPrice)
+				if getStrategy(p.Symbol).PartialFill != partialAccept {
+					settlePartial(p, true)
+					continue
+				}
+				mu.Lock()
+				pendingEntries[p.ID] = p
+				mu.Unlock()
+			}
+			if time.Since(p.Placed) < pendingOrderTTL {
+				continue
+			}
+			if err := cancelOrder(p.ID); err != nil {
+				log.Printf("Cancel of expired order %s (%s) failed: %v", p.ID, p.Symbol, err)
+				continue
+			}
+			removePendingEntry(p.ID)
+			// More may have filled before the cancel landed.
+			if st, err := orderStatus(p.ID); err == nil {
+				p = openEntryFill(p, st.FilledQty, st.AvgPrice)
+			}
+			if p.Filled > 0 {
+				settlePartial(p, false)
+				continue
+			}
+			logTrade(fmt.Sprintf("%s ENTRY EXPIRED %s: limit %.2f not reached (order %s)", p.Direction, p.Symbol, p.Limit, p.ID))
+		}
+	}
+}
+
+func removePendingEntry(id string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(pendingEntries, id)
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	outputText = "text" // the decorated log lines
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// outputFormat is how the daily summary is written and the default body
+// of /status, for tools reading either. Text keeps the summary as log
+// lines; /status has no text form and stays JSON unless this is yaml.
+var outputFormat = outputText
+
+func setOutputFormat(s string) error {
+	switch s {
+	case outputText, outputJSON, outputYAML:
+		outputFormat = s
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q (want %s, %s or %s)", s, outputText, outputJSON, outputYAML)
+}
+
+// dailySummary is the day's end-of-session report, logged as text or
+// written whole as JSON or YAML.
+type dailySummary struct {
+	Date     string  `json:"date"`
+	Trades   int     `json:"trades"`
+	NetPnL   float64 `json:"net_pnl"`
+	LongPnL  float64 `json:"long_pnl"`
+	ShortPnL float64 `json:"short_pnl"`
+	Wins     int     `json:"wins"`
+	Losses   int     `json:"losses"`
+	Flat     int     `json:"flat"`
+	WinRate  float64 `json:"win_rate"` // percent
+	AvgWin   float64 `json:"avg_win"`
+	AvgLoss  float64 `json:"avg_loss"`
+
+	Best  *TradeRecord `json:"best,omitempty"`
+	Worst *TradeRecord `json:"worst,omitempty"`
+
+	Strategies []strategySummary `json:"strategies"`
+	Reasons    []reasonSummary   `json:"reasons"`
+	Shadow     map[string]int    `json:"shadow,omitempty"` // signals per shadow strategy
+}
+
+// buildDailySummary summarizes the day's trades, whose P&L sums to netPnL,
+// and the shadow signal counts as of now.
+func buildDailySummary(trades []TradeRecord, netPnL float64, shadow map[string]int, now time.Time) dailySummary {
+	sum := dailySummary{
+		Date:       now.Format("2006-01-02"),
+		Trades:     len(trades),
+		NetPnL:     roundMoney(netPnL),
+		Strategies: summarizeStrategies(trades),
+		Reasons:    summarizeReasons(trades),
+	}
+	for _, t := range trades {
+		if t.Direction == "LONG" {
+			sum.LongPnL += t.PnL
+		} else {
+			sum.ShortPnL += t.PnL
+		}
+	}
+	sum.LongPnL, sum.ShortPnL = roundMoney(sum.LongPnL), roundMoney(sum.ShortPnL)
+	for i := range sum.Strategies {
+		sum.Strategies[i].PnL = roundMoney(sum.Strategies[i].PnL)
+	}
+	for i := range sum.Reasons {
+		sum.Reasons[i].PnL = roundMoney(sum.Reasons[i].PnL)
+	}
+
+	day := summarizeDay(trades)
+	sum.Wins, sum.Losses, sum.Flat = day.Wins, day.Losses, day.Trades-day.Wins-day.Losses
+	sum.WinRate = day.WinRate()
+	sum.AvgWin, sum.AvgLoss = roundMoney(day.AvgWin()), roundMoney(day.AvgLoss())
+	if len(trades) > 0 {
+		sum.Best, sum.Worst = &day.Best, &day.Worst
+	}
+	if len(shadow) > 0 {
+		sum.Shadow = maps.Clone(shadow)
+	}
+	return sum
+}
+
+// logSummary writes sum to the trade log as the text summary.
+func logSummary(sum dailySummary) {
+	logTrade("═══════════════════════════════════════════════════════")
+	logTrade("DAILY TRADE & P&L SUMMARY")
+	logTrade(fmt.Sprintf("Date: %s", sum.Date))
+	logTrade(fmt.Sprintf("Total Trades: %d", sum.Trades))
+	logTrade(fmt.Sprintf("Net P&L: %s", money(sum.NetPnL)))
+	logTrade(fmt.Sprintf("Long Trades P&L: %s", money(sum.LongPnL)))
+	logTrade(fmt.Sprintf("Short Trades P&L: %s", money(sum.ShortPnL)))
+	logTrade(fmt.Sprintf("Wins: %d | Losses: %d | Flat: %d | Win rate: %.1f%%", sum.Wins, sum.Losses, sum.Flat, sum.WinRate))
+	logTrade(fmt.Sprintf("Avg win: %s | Avg loss: %s", money(sum.AvgWin), money(sum.AvgLoss)))
+	logTrade(fmt.Sprintf("Best: %s %s %s | Worst: %s %s %s",
+		sum.Best.Direction, sum.Best.Symbol, money(sum.Best.PnL), sum.Worst.Direction, sum.Worst.Symbol, money(sum.Worst.PnL)))
+	for _, s := range sum.Strategies {
+		logTrade(fmt.Sprintf("Strategy %s: %d trades, P&L %s", s.Strategy, s.Trades, money(s.PnL)))
+	}
+	for _, r := range sum.Reasons {
+		logTrade(fmt.Sprintf("%s: %d trades, P&L %s", r.Reason, r.Trades, money(r.PnL)))
+	}
+	for _, name := range slices.Sorted(maps.Keys(sum.Shadow)) {
+		logTrade(fmt.Sprintf("Shadow %s: %d signals (see %s)", name, sum.Shadow[name], filepath.Join(logDir, "shadow.jsonl")))
+	}
+	logTrade("═══════════════════════════════════════════════════════")
+}
+
+// writeSummary writes sum in outputFormat to the console and the trade
+// log, unprefixed so the block parses as it stands.
+func writeSummary(sum dailySummary) {
+	out, err := encodeOutput(sum, outputFormat)
+	if err != nil {
+		log.Printf("Daily summary encode failed: %v", err)
+		return
+	}
+	os.Stdout.Write(out)
+	if tradeLogFile != nil {
+		tradeLogFile.Write(out)
+		tradeLogFile.Sync()
+	}
+}
+
+// encodeOutput renders v as indented JSON, or as YAML with the same keys
+// in the same order, ending in a newline.
+func encodeOutput(v any, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if format != outputYAML {
+		return append(data, '\n'), nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := readYAMLNode(dec)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	node.write(&b, 0)
+	return []byte(b.String()), nil
+}
+
+// yamlNode is a decoded JSON value that keeps object keys in order.
+type yamlNode struct {
+	scalar string      // set for everything but objects and arrays
+	keys   []string    // object keys, in order
+	items  []*yamlNode // object values by key, or array elements
+	object bool
+	array  bool
+}
+
+func readYAMLNode(dec *json.Decoder) (*yamlNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		n := &yamlNode{object: t == '{', array: t == '['}
+		for dec.More() {
+			if n.object {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key.(string))
+			}
+			item, err := readYAMLNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, item)
+		}
+		_, err := dec.Token() // closing delimiter
+		return n, err
+	case string:
+		return &yamlNode{scalar: yamlString(t)}, nil
+	case nil:
+		return &yamlNode{scalar: "null"}, nil
+	default: // bool or json.Number
+		return &yamlNode{scalar: fmt.Sprint(t)}, nil
+	}
+}
+
+// collection reports whether n is written on lines of its own.
+func (n *yamlNode) collection() bool {
+	return (n.object || n.array) && len(n.items) > 0
+}
+
+func (n *yamlNode) inline() string {
+	switch {
+	case n.object && len(n.items) == 0:
+		return "{}"
+	case n.array && len(n.items) == 0:
+		return "[]"
+	}
+	return n.scalar
+}
+
+// write emits n's entries at indent; a collection's first line is written
+// without indentation, so an array item can put it after its "- ".
+func (n *yamlNode) write(b *strings.Builder, indent int) {
+	if !n.collection() {
+		b.WriteString(n.inline() + "\n")
+		return
+	}
+	pad := strings.Repeat(" ", indent)
+	for i, item := range n.items {
+		if i > 0 {
+			b.WriteString(pad)
+		}
+		if n.array {
+			b.WriteString("- ")
+			if item.array && item.collection() {
+				// A nested list cannot start on the dash's line.
+				b.WriteString("\n" + pad + "  ")
+			}
+			item.write(b, indent+2)
+			continue
+		}
+		b.WriteString(yamlString(n.keys[i]) + ":")
+		if item.collection() {
+			b.WriteString("\n" + pad + "  ")
+			item.write(b, indent+2)
+			continue
+		}
+		b.WriteString(" " + item.inline() + "\n")
+	}
+}
+
+var (
+	yamlPlain    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_./ -]*$`)
+	yamlReserved = []string{"true", "false", "null", "yes", "no", "on", "off", "y", "n", "~"}
+)
+
+// yamlString writes s plain when YAML would read it back as the same
+// string, and double-quoted otherwise. JSON string escapes are a subset of
+// YAML's, so the quoted form is JSON's.
+func yamlString(s string) string {
+	if yamlPlain.MatchString(s) && !strings.HasSuffix(s, " ") && !slices.Contains(yamlReserved, strings.ToLower(s)) {
+		return s
+	}
+	q, _ := json.Marshal(s)
+	return string(q)
+}
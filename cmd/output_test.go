@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDailySummaryJSON(t *testing.T) {
+	trades := []TradeRecord{
+		{Symbol: "AAA", Direction: "LONG", PnL: 300, Reason: ReasonTarget, Strategy: "breakout_long"},
+		{Symbol: "BBB", Direction: "SHORT", PnL: -100.004, Reason: ReasonFixedSL, Strategy: "quick_drop"},
+		{Symbol: "CCC", Direction: "LONG", PnL: 0.001, Reason: ReasonMaxHold, Strategy: "breakout_long"},
+	}
+	sum := buildDailySummary(trades, 199.997, map[string]int{"gap_up": 2}, time.Date(2026, 3, 2, 15, 30, 0, 0, ist))
+
+	out, err := encodeOutput(sum, outputJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Date     string  `json:"date"`
+		Trades   int     `json:"trades"`
+		NetPnL   float64 `json:"net_pnl"`
+		LongPnL  float64 `json:"long_pnl"`
+		ShortPnL float64 `json:"short_pnl"`
+		Wins     int     `json:"wins"`
+		Losses   int     `json:"losses"`
+		Flat     int     `json:"flat"`
+		WinRate  float64 `json:"win_rate"`
+		Best     struct {
+			Symbol string `json:"symbol"`
+		} `json:"best"`
+		Worst struct {
+			Symbol string `json:"symbol"`
+		} `json:"worst"`
+		Strategies []strategySummary `json:"strategies"`
+		Reasons    []reasonSummary   `json:"reasons"`
+		Shadow     map[string]int    `json:"shadow"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("summary is not JSON: %v\n%s", err, out)
+	}
+
+	if got.Date != "2026-03-02" || got.Trades != 3 || got.NetPnL != 200 {
+		t.Errorf("date %s, trades %d, net %v; want 2026-03-02, 3, 200", got.Date, got.Trades, got.NetPnL)
+	}
+	if got.LongPnL != 300 || got.ShortPnL != -100 {
+		t.Errorf("long %v short %v, want 300 and -100", got.LongPnL, got.ShortPnL)
+	}
+	if got.Wins != 1 || got.Losses != 1 || got.Flat != 1 || math.Abs(got.WinRate-100.0/3) > 1e-9 {
+		t.Errorf("wins %d losses %d flat %d rate %v, want 1 1 1 33.3", got.Wins, got.Losses, got.Flat, got.WinRate)
+	}
+	if got.Best.Symbol != "AAA" || got.Worst.Symbol != "BBB" {
+		t.Errorf("best %s worst %s, want AAA and BBB", got.Best.Symbol, got.Worst.Symbol)
+	}
+	if len(got.Strategies) != 2 || len(got.Reasons) != 3 || got.Shadow["gap_up"] != 2 {
+		t.Errorf("strategies %v reasons %v shadow %v", got.Strategies, got.Reasons, got.Shadow)
+	}
+}
+
+func TestEncodeOutputYAML(t *testing.T) {
+	v := struct {
+		Mode   string            `json:"mode"`
+		PnL    float64           `json:"pnl"`
+		Paused bool              `json:"paused"`
+		Tags   []string          `json:"tags"`
+		Empty  map[string]int    `json:"empty"`
+		Lots   []map[string]any  `json:"lots"`
+		Labels map[string]string `json:"labels"`
+	}{
+		Mode:   "paper",
+		PnL:    -12.5,
+		Tags:   []string{"gap-play", "yes", "10:30"},
+		Empty:  map[string]int{},
+		Lots:   []map[string]any{{"qty": 10, "sym": "ABC"}},
+		Labels: map[string]string{"exit": "Exchange SL"},
+	}
+	out, err := encodeOutput(v, outputYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `mode: paper
+pnl: -12.5
+paused: false
+tags:
+  - gap-play
+  - "yes"
+  - "10:30"
+empty: {}
+lots:
+  - qty: 10
+    sym: ABC
+labels:
+  exit: Exchange SL
+`
+	if string(out) != want {
+		t.Errorf("YAML =\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestStatusFormat(t *testing.T) {
+	resetBooks(t)
+	defer func(f string) { outputFormat = f }(outputFormat)
+
+	rec := httptest.NewRecorder()
+	handleStatus(rec, httptest.NewRequest("GET", "/status?format=yaml", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/yaml" {
+		t.Errorf("content type = %s, want application/yaml", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "\nmode: paper\n") && !strings.HasPrefix(rec.Body.String(), "mode: paper\n") {
+		t.Errorf("YAML status lacks the mode:\n%s", rec.Body)
+	}
+
+	outputFormat = outputYAML
+	rec = httptest.NewRecorder()
+	handleStatus(rec, httptest.NewRequest("GET", "/status?format=json", nil))
+	var resp statusResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Mode != "paper" {
+		t.Errorf("?format=json did not override -output yaml: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// symbolOverride pins a watchlist symbol to a known instrument, for
+// renamed, split or dual-listed tickers whose "-EQ" search finds nothing
+// or the wrong scrip. symbol_overrides.json in the data directory maps
+// symbols to these:
+//
+//	{"TATAMOTORS": {"token": "3456", "tsym": "TMPV-EQ", "lot_size": 1}}
+type symbolOverride struct {
+	Token    string  `json:"token"`
+	Tsym     string  `json:"tsym,omitempty"` // trading symbol for orders; sym-EQ when empty
+	TickSize float64 `json:"tick_size,omitempty"`
+	LotSize  int     `json:"lot_size,omitempty"`
+}
+
+var (
+	symbolOverridesPath = dataPath("symbol_overrides.json")
+
+	symbolOverrides = make(map[string]symbolOverride) // read-only after startup
+	tradingSymbols  = make(map[string]string)         // overridden tsyms by symbol; guarded by mu
+)
+
+// loadSymbolOverrides reads path. A missing file is not an error: most
+// watchlists need no overrides.
+func loadSymbolOverrides(path string) (map[string]symbolOverride, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]symbolOverride{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]symbolOverride
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	out := make(map[string]symbolOverride, len(raw))
+	for sym, o := range raw {
+		if o.Token == "" {
+			return nil, fmt.Errorf("%s: %s has no token", path, sym)
+		}
+		out[strings.ToUpper(sym)] = o
+	}
+	return out, nil
+}
+
+// overrideScrip returns sym's pinned instrument, if any.
+func overrideScrip(sym string) (scrip, bool) {
+	o, ok := symbolOverrides[sym]
+	if !ok {
+		return scrip{}, false
+	}
+	return scrip{Token: o.Token, Tsym: o.Tsym, TickSize: o.TickSize, LotSize: o.LotSize}, true
+}
+
+// applySymbolOverrides stores every override over whatever the mapping
+// step found, so a saved token map cannot resurrect a stale token.
+func applySymbolOverrides() {
+	mu.Lock()
+	defer mu.Unlock()
+	for sym := range symbolOverrides {
+		sc, _ := overrideScrip(sym)
+		storeScrip(sym, sc)
+	}
+}
+
+// tsymFor is the trading symbol orders for sym use: the override's tsym
+// when one is set, otherwise sym-EQ.
+func tsymFor(sym string) string {
+	mu.Lock()
+	defer mu.Unlock()
+	return tradingSymbols[sym]
+}
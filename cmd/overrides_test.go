@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSymbolOverrides(t *testing.T) {
+	resetBooks(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "symbol_overrides.json")
+	os.WriteFile(path, []byte(`{"tatamotors": {"token": "3456", "tsym": "TMPV-EQ", "lot_size": 1}}`), 0644)
+
+	overrides, err := loadSymbolOverrides(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldOverrides, oldTokens, oldTsyms := symbolOverrides, symbolToToken, tradingSymbols
+	t.Cleanup(func() { symbolOverrides, symbolToToken, tradingSymbols = oldOverrides, oldTokens, oldTsyms })
+	symbolOverrides, tradingSymbols = overrides, make(map[string]string)
+	symbolToToken = map[string]string{"TATAMOTORS": "884"} // stale, e.g. from a saved token map
+
+	// A nil master and no session: only the override can answer.
+	sc, err := lookupScrip(context.Background(), nil, "TATAMOTORS")
+	if err != nil || sc.Token != "3456" {
+		t.Fatalf("lookupScrip = %+v, %v; want the pinned token", sc, err)
+	}
+
+	applySymbolOverrides()
+	if tokenFor("TATAMOTORS") != "3456" {
+		t.Errorf("token = %s, want the override over the saved map", tokenFor("TATAMOTORS"))
+	}
+	if p := entryOrder("TATAMOTORS", "LONG", 1); p.Tsym != "TMPV-EQ" {
+		t.Errorf("order tsym = %q, want TMPV-EQ", p.Tsym)
+	}
+	if p := entryOrder("SBIN", "LONG", 1); p.Tsym != "" {
+		t.Errorf("order tsym = %q for a plain symbol, want empty (SBIN-EQ)", p.Tsym)
+	}
+
+	if got, err := loadSymbolOverrides(filepath.Join(dir, "missing.json")); err != nil || len(got) != 0 {
+		t.Errorf("missing file = %v, %v; want no overrides and no error", got, err)
+	}
+	os.WriteFile(path, []byte(`{"X": {"tsym": "Y-EQ"}}`), 0644)
+	if _, err := loadSymbolOverrides(path); err == nil {
+		t.Error("override without a token was accepted")
+	}
+}
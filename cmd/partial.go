@@ -0,0 +1,172 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/notify"
+)
+
+// Partial-fill policies: what happens to the unfilled rest of an entry.
+// The filled part is always kept as the position.
+const (
+	partialAccept = "accept" // leave the rest working until it fills or expires
+	partialCancel = "cancel" // cancel the rest
+	partialChase  = "chase"  // cancel the rest and re-send it at market
+)
+
+var (
+	partialFillPolicy   = partialAccept
+	partialChaseRetries = 2 // fresh orders the chase policy sends per entry
+)
+
+func setPartialFillPolicy(v string) error {
+	switch v {
+	case partialAccept, partialCancel, partialChase:
+		partialFillPolicy = v
+		return nil
+	}
+	return fmt.Errorf("unknown partial fill policy %q (want %s, %s or %s)", v, partialAccept, partialCancel, partialChase)
+}
+
+// entryFilled is how much of market entry id, sent for qty, has filled,
+// at what average price, and whether the rest is still working. An order
+// whose status cannot be read, or that is open with nothing filled yet,
+// counts as filled in full, as market entries always have.
+func entryFilled(id string, qty int) (filled int, avg float64, working bool) {
+	st, err := orderStatus(id)
+	if err != nil {
+		log.Printf("Entry order %s status unavailable, assuming filled: %v", id, err)
+		return qty, 0, false
+	}
+	switch st.Status {
+	case client.StatusComplete:
+		if st.FilledQty == 0 {
+			return qty, st.AvgPrice, false
+		}
+		return min(st.FilledQty, qty), st.AvgPrice, false
+	case client.StatusRejected, client.StatusCancelled:
+		return min(st.FilledQty, qty), st.AvgPrice, false
+	}
+	if st.FilledQty == 0 {
+		return qty, st.AvgPrice, false
+	}
+	return min(st.FilledQty, qty), st.AvgPrice, st.FilledQty < qty
+}
+
+// openEntryFill adds the part of entry o filled since it was last seen to
+// its position, given the order's total filled qty and average price avg,
+// and returns o with Filled and AvgPrice brought up to date. The new fills
+// are priced on their own (see incrementPrice), falling back to the limit,
+// or the last price for a market order, when the broker reports no price.
+func openEntryFill(o pendingOrder, filled int, avg float64) pendingOrder {
+	filled = min(filled, o.Qty)
+	n := filled - o.Filled
+	if n <= 0 {
+		return o
+	}
+	price := cmp.Or(incrementPrice(o.Filled, o.AvgPrice, filled, avg), o.Limit, symbolLTP(o.Symbol))
+	openFilled(o, n, price, price)
+	o.Filled, o.AvgPrice = filled, avg
+	return o
+}
+
+// openFilled adds qty of entry o to its position at fill.
+func openFilled(o pendingOrder, qty int, fill, ltp float64) {
+	if o.Direction == models.Long {
+		openLong(o.Symbol, o.Source, fill, ltp, qty, o.Leverage, o.Product)
+	} else {
+		openShort(o.Symbol, o.Source, fill, ltp, qty, o.Leverage, o.Product)
+	}
+}
+
+// settlePartial applies the symbol's partial-fill policy to entry o, of
+// which o.Filled of o.Qty has filled and been opened; working says whether
+// the rest is still live at the broker.
+func settlePartial(o pendingOrder, working bool) {
+	policy := getStrategy(o.Symbol).PartialFill
+	if policy != partialCancel && policy != partialChase {
+		if working {
+			mu.Lock()
+			pendingEntries[o.ID] = o
+			mu.Unlock()
+		}
+		rest := "lapsed"
+		if working {
+			rest = "working"
+		}
+		logTrade(fmt.Sprintf("PARTIAL %s %s: %d of %d filled, rest %s (order %s)", o.Direction, o.Symbol, o.Filled, o.Qty, rest, o.ID))
+		return
+	}
+
+	if working {
+		if err := cancelOrder(o.ID); err != nil {
+			// Leave it to pollPendingOrders to try again.
+			log.Printf("Cancel of partial entry %s (%s) failed: %v", o.ID, o.Symbol, err)
+			mu.Lock()
+			pendingEntries[o.ID] = o
+			mu.Unlock()
+			return
+		}
+		removePendingEntry(o.ID)
+		// More may have filled before the cancel landed.
+		if st, err := orderStatus(o.ID); err == nil {
+			o = openEntryFill(o, st.FilledQty, st.AvgPrice)
+		}
+	}
+	rest := o.Qty - o.Filled
+	if rest <= 0 {
+		return
+	}
+	// A chase is a fresh entry, so none is sent once close-only begins.
+	if policy == partialCancel || o.Chases >= partialChaseRetries || closeOnly {
+		logTrade(fmt.Sprintf("PARTIAL %s %s: %d of %d filled, rest cancelled (order %s)", o.Direction, o.Symbol, o.Filled, o.Qty, o.ID))
+		return
+	}
+	chasePartial(o, rest)
+}
+
+// chasePartial sends a fresh market order for the rest of entry o and
+// settles whatever part of it fills in turn. The order follows one sent
+// moments ago on the same side, so it skips the duplicate-order check.
+func chasePartial(o pendingOrder, rest int) {
+	ltp := symbolLTP(o.Symbol)
+	logTrade(fmt.Sprintf("CHASE %s %s: %d of %d filled, re-sending %d (try %d of %d)",
+		o.Direction, o.Symbol, o.Filled, o.Qty, rest, o.Chases+1, partialChaseRetries))
+	p := entryOrder(o.Symbol, o.Direction, rest)
+	id, err := sendOrder(p)
+	if err != nil {
+		notifyTrade(notify.EventError, fmt.Sprintf("%s CHASE FAILED %s: %v", o.Direction, o.Symbol, err))
+		return
+	}
+	side := client.Buy
+	if o.Direction == models.Short {
+		side = client.Sell
+	}
+	filled, avg, working := entryFilled(id, p.Qty)
+	next := pendingOrder{
+		ID: id, Symbol: o.Symbol, Direction: o.Direction, Qty: p.Qty, Leverage: o.Leverage,
+		Product: o.Product, Source: o.Source, Placed: time.Now(), Chases: o.Chases + 1,
+		Filled: filled, AvgPrice: avg,
+	}
+	if filled > 0 {
+		openFilled(next, filled, fillPrice(o.Symbol, side, ltp, id), ltp)
+	}
+	if next.Filled < next.Qty {
+		settlePartial(next, working)
+	}
+}
+
+// symbolLTP is sym's last traded price, or 0 before its first quote.
+func symbolLTP(sym string) float64 {
+	mu.Lock()
+	defer mu.Unlock()
+	if ms, ok := markets[sym]; ok {
+		return ms.LTP
+	}
+	return 0
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+// partialBroker fills each order up to fill(qty) and leaves the rest open
+// until it is cancelled or fillAll is called. Orders reject refuses fail,
+// as do cancels of the orders in noCancel.
+type partialBroker struct {
+	client.Broker
+	mu        sync.Mutex
+	fill      func(qty int) int
+	reject    func(p client.OrderParams) error
+	orders    []client.OrderParams
+	filled    map[string]int
+	cancelled []string
+	noCancel  []string
+}
+
+func (b *partialBroker) PlaceOrder(ctx context.Context, p client.OrderParams) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.reject != nil {
+		if err := b.reject(p); err != nil {
+			return "", err
+		}
+	}
+	b.orders = append(b.orders, p)
+	id := fmt.Sprintf("ORD-%d", len(b.orders))
+	if b.filled == nil {
+		b.filled = make(map[string]int)
+	}
+	b.filled[id] = b.fill(p.Qty)
+	return id, nil
+}
+
+func (b *partialBroker) GetOrderStatus(ctx context.Context, id string) (client.OrderStatus, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var n int
+	fmt.Sscanf(id, "ORD-%d", &n)
+	qty, filled := b.orders[n-1].Qty, b.filled[id]
+	st := client.OrderStatus{OrderNo: id, Status: client.StatusOpen, FilledQty: filled, AvgPrice: 100}
+	switch {
+	case filled == qty:
+		st.Status = client.StatusComplete
+	case slices.Contains(b.cancelled, id):
+		st.Status = client.StatusCancelled
+	}
+	return st, nil
+}
+
+func (b *partialBroker) CancelOrder(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if slices.Contains(b.noCancel, id) {
+		return errors.New("order not cancellable")
+	}
+	b.cancelled = append(b.cancelled, id)
+	return nil
+}
+
+func (b *partialBroker) fillAll(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var n int
+	fmt.Sscanf(id, "ORD-%d", &n)
+	b.filled[id] = b.orders[n-1].Qty
+}
+
+// livePartial switches to live trading against a partialBroker filling
+// each order by fill, under the partial-fill policy.
+func livePartial(t *testing.T, policy string, fill func(int) int) *partialBroker {
+	t.Helper()
+	resetBooks(t)
+	paperTrading = false
+	pb := &partialBroker{fill: fill}
+	oldBroker, oldPolicy := broker, partialFillPolicy
+	t.Cleanup(func() { broker, partialFillPolicy = oldBroker, oldPolicy })
+	broker, partialFillPolicy = pb, policy
+	return pb
+}
+
+func longQty(sym string) int {
+	mu.Lock()
+	defer mu.Unlock()
+	return longPositions[sym].TotalQty
+}
+
+func TestEntryFullFill(t *testing.T) {
+	pb := livePartial(t, partialCancel, func(qty int) int { return qty })
+	enterLong("ABC", entrySource{}, 100, 1, 1)
+
+	if len(pb.orders) != 1 {
+		t.Fatalf("sent %d orders, want 1", len(pb.orders))
+	}
+	if got, want := longQty("ABC"), pb.orders[0].Qty; got != want {
+		t.Errorf("position qty %d, want the full %d", got, want)
+	}
+	if len(pb.cancelled) > 0 || len(pendingEntries) > 0 {
+		t.Errorf("full fill cancelled %v, left pending %v", pb.cancelled, pendingEntries)
+	}
+}
+
+func TestEntryPartialAccept(t *testing.T) {
+	pb := livePartial(t, partialAccept, func(qty int) int { return qty / 2 })
+	enterLong("ABC", entrySource{}, 100, 1, 1)
+
+	sent := pb.orders[0].Qty
+	if got := longQty("ABC"); got != sent/2 {
+		t.Fatalf("position qty %d, want the %d filled of %d", got, sent/2, sent)
+	}
+	if len(pb.cancelled) > 0 {
+		t.Errorf("accept cancelled %v", pb.cancelled)
+	}
+	if _, ok := pendingEntries["ORD-1"]; !ok {
+		t.Fatal("rest of the order is not tracked")
+	}
+
+	// The rest fills later and is added to the position.
+	pb.fillAll("ORD-1")
+	pollPendingOrders()
+	if got := longQty("ABC"); got != sent {
+		t.Errorf("position qty %d after the rest filled, want %d", got, sent)
+	}
+	if len(pendingEntries) > 0 {
+		t.Errorf("completed order still pending: %v", pendingEntries)
+	}
+}
+
+func TestEntryPartialCancel(t *testing.T) {
+	pb := livePartial(t, partialAccept, func(qty int) int { return qty / 2 })
+	stockStrategies["ABC"] = models.StockStrategy{Class: "B", Target: 0.02, SL: 0.01, Leverage: 1, PartialFill: partialCancel}
+	enterLong("ABC", entrySource{}, 100, 1, 1)
+
+	sent := pb.orders[0].Qty
+	if got := longQty("ABC"); got != sent/2 {
+		t.Errorf("position qty %d, want the %d filled of %d", got, sent/2, sent)
+	}
+	if !slices.Equal(pb.cancelled, []string{"ORD-1"}) {
+		t.Errorf("cancelled %v, want the entry's rest", pb.cancelled)
+	}
+	if len(pb.orders) != 1 || len(pendingEntries) > 0 {
+		t.Errorf("sent %d orders and left %v pending after cancelling", len(pb.orders), pendingEntries)
+	}
+}
+
+func TestEntryPartialChase(t *testing.T) {
+	pb := livePartial(t, partialChase, func(qty int) int { return qty / 2 })
+	defer func(n int) { partialChaseRetries = n }(partialChaseRetries)
+	partialChaseRetries = 2
+	enterLong("ABC", entrySource{}, 100, 1, 1)
+
+	if len(pb.orders) != 3 {
+		t.Fatalf("sent %d orders, want the entry and 2 chases", len(pb.orders))
+	}
+	want := 0
+	for i, o := range pb.orders {
+		want += o.Qty / 2
+		if i > 0 && o.Qty != pb.orders[i-1].Qty-pb.orders[i-1].Qty/2 {
+			t.Errorf("chase %d sent %d, want the %d left", i, o.Qty, pb.orders[i-1].Qty-pb.orders[i-1].Qty/2)
+		}
+	}
+	if got := longQty("ABC"); got != want {
+		t.Errorf("position qty %d, want the %d filled across orders", got, want)
+	}
+	if len(pb.cancelled) != 3 {
+		t.Errorf("cancelled %v, want each order's rest", pb.cancelled)
+	}
+}
+
+func TestPartialFillIncrementsPricedOnTheirOwn(t *testing.T) {
+	resetBooks(t)
+	submitLimitEntry("ABC", entrySource{}, models.Long, 100, 102, 1)
+	var id string
+	for _, p := range pendingEntries {
+		id = p.ID
+	}
+
+	// 50 fill at 100: the rest counts once toward capital, not the whole.
+	paperOrders[id].Status.FilledQty, paperOrders[id].Status.AvgPrice = 50, 100
+	pollPendingOrders()
+	if got := longQty("ABC"); got != 50 {
+		t.Fatalf("position qty %d after the first fill, want 50", got)
+	}
+	if deployed, _ := deployedCapital(); deployed != 50*100+50*102 {
+		t.Errorf("deployed %v, want the 50 filled at 100 plus the 50 resting at 102", deployed)
+	}
+
+	// 50 more at 102 take the order's average to 101.
+	paperOrders[id].Status = client.OrderStatus{Status: client.StatusComplete, FilledQty: 100, AvgPrice: 101}
+	pollPendingOrders()
+	pos := longPositions["ABC"]
+	if pos.TotalQty != 100 || pos.AvgEntry() != 101 {
+		t.Errorf("position %d @ %v, want 100 @ 101", pos.TotalQty, pos.AvgEntry())
+	}
+}
+
+func TestPendingMarketRestCountsAtLTP(t *testing.T) {
+	resetBooks(t)
+	markets["ABC"] = &state.MarketState{Symbol: "ABC", LTP: 200}
+	pendingEntries["ORD-1"] = pendingOrder{ID: "ORD-1", Symbol: "ABC", Direction: models.Long, Qty: 10, Filled: 4, Leverage: 1}
+	if deployed, _ := deployedCapital(); deployed != 6*200 {
+		t.Errorf("deployed %v, want the 6 left at the 200 ltp", deployed)
+	}
+}
+
+// lateFillBroker fills 6 of an order just before cancelling it.
+type lateFillBroker struct{ *partialBroker }
+
+func (b lateFillBroker) CancelOrder(ctx context.Context, id string) error {
+	b.mu.Lock()
+	b.filled[id] = 6
+	b.mu.Unlock()
+	return b.partialBroker.CancelOrder(ctx, id)
+}
+
+func TestExpiredEntryBooksFillBeforeCancel(t *testing.T) {
+	pb := livePartial(t, partialAccept, func(int) int { return 0 })
+	broker = lateFillBroker{pb}
+	stockStrategies["ABC"] = models.StockStrategy{Class: "B", SL: 0.01, Target: 0.02, Leverage: 1}
+
+	submitLimitEntry("ABC", entrySource{}, models.Long, 10, 100, 1)
+	mu.Lock()
+	p := pendingEntries["ORD-1"]
+	p.Placed = p.Placed.Add(-pendingOrderTTL)
+	pendingEntries["ORD-1"] = p
+	mu.Unlock()
+
+	pollPendingOrders()
+	if got := longQty("ABC"); got != 6 {
+		t.Errorf("position qty = %d, want the 6 filled before the cancel", got)
+	}
+	if hasPendingEntry("ABC", models.Long) {
+		t.Error("expired entry still pending")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"path/filepath"
+
+	"github.com/may-bach/Axiom/internal/atomicfile"
+)
+
+var (
+	dataDir = "data" // watchlist, strategy config, token map and other state
+	logDir  = "logs" // trade logs, CSV exports and shadow signals
+)
+
+// dataPath returns name inside dataDir.
+func dataPath(name string) string {
+	return filepath.Join(dataDir, name)
+}
+
+// dataFiles are the files that live in dataDir unless their own flag
+// names another path.
+var dataFiles = []struct {
+	flag string
+	path *string
+	name string
+}{
+	{"stocks", &stocksPath, "stocks.json"},
+	{"config", &brainConfigPath, "config.json"},
+	{"performance", &performancePath, "performance.json"},
+	{"market-state", &marketSnapshotPath, "market_state.json"},
+	{"instruments-cache", &instrumentsPath, "instruments.csv"},
+	{"symbol-overrides", &symbolOverridesPath, "symbol_overrides.json"},
+}
+
+// dirFlags registers -data-dir and -log-dir on fs.
+func dirFlags(fs *flag.FlagSet) {
+	fs.StringVar(&dataDir, "data-dir", dataDir, "directory holding the watchlist, strategy config and saved state")
+	fs.StringVar(&logDir, "log-dir", logDir, "directory for trade logs and exports")
+}
+
+// writeJSONAtomic writes v to path as indented JSON, atomically: a crash
+// or a concurrent writer leaves either the old file or the new one.
+func writeJSONAtomic(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(path, data, 0644)
+}
+
+// resolveDataFiles re-roots every data file whose flag was not set on fs
+// under the (possibly changed) dataDir. Call it after fs is parsed.
+func resolveDataFiles(fs *flag.FlagSet) {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, f := range dataFiles {
+		if !set[f.flag] {
+			*f.path = dataPath(f.name)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import "fmt"
+
+// setEntriesPaused pauses or resumes new entries, logging the transition
+// with its source. It reports whether the state changed.
+func setEntriesPaused(paused bool, source string) bool {
+	mu.Lock()
+	changed := entriesPaused != paused
+	entriesPaused = paused
+	mu.Unlock()
+
+	if changed {
+		logTrade(fmt.Sprintf("%s via %s", pauseLabel(paused), source))
+	}
+	return changed
+}
+
+// toggleEntriesPaused flips the pause state and returns the new one.
+func toggleEntriesPaused(source string) bool {
+	mu.Lock()
+	paused := !entriesPaused
+	mu.Unlock()
+	setEntriesPaused(paused, source)
+	return paused
+}
+
+func pauseLabel(paused bool) string {
+	if paused {
+		return "entries paused"
+	}
+	return "entries resumed"
+}
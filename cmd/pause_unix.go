@@ -0,0 +1,30 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchPauseSignal toggles the pause state on each SIGUSR1 until ctx is
+// done, so entries can be ducked from a shell with kill -USR1. The signal
+// is registered before it returns.
+func watchPauseSignal(ctx context.Context) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGUSR1)
+
+	go func() {
+		defer signal.Stop(sig)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sig:
+				toggleEntriesPaused("SIGUSR1")
+			}
+		}
+	}()
+}
@@ -0,0 +1,42 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestPauseSignalTogglesEntries(t *testing.T) {
+	resetBooks(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watchPauseSignal(ctx)
+
+	waitFor := func(want bool) {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			mu.Lock()
+			paused := entriesPaused
+			mu.Unlock()
+			if paused == want {
+				return
+			}
+		}
+		t.Fatalf("entriesPaused never became %v", want)
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	waitFor(true)
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	waitFor(false)
+
+	if setEntriesPaused(false, "test") {
+		t.Error("resuming while running reported a change")
+	}
+	if !setEntriesPaused(true, "test") {
+		t.Error("pausing while running reported no change")
+	}
+}
@@ -0,0 +1,7 @@
+package main
+
+import "context"
+
+// watchPauseSignal does nothing on Windows, which has no SIGUSR1; pause
+// entries through the control endpoint instead.
+func watchPauseSignal(ctx context.Context) {}
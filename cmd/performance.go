@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+var performancePath = dataPath("performance.json")
+
+// dayPerformance is one day's entry in performance.json.
+type dayPerformance struct {
+	Date        string  `json:"date"` // YYYY-MM-DD
+	NetPnL      float64 `json:"net_pnl"`
+	Trades      int     `json:"trades"`
+	Wins        int     `json:"wins"`
+	WinRate     float64 `json:"win_rate"` // percent
+	LongPnL     float64 `json:"long_pnl"`
+	ShortPnL    float64 `json:"short_pnl"`
+	LongTrades  int     `json:"long_trades"`
+	ShortTrades int     `json:"short_trades"`
+}
+
+// add folds trades into the day's totals.
+func (d *dayPerformance) add(trades []TradeRecord) {
+	for _, t := range trades {
+		d.NetPnL += t.PnL
+		d.Trades++
+		if t.PnL > 0 {
+			d.Wins++
+		}
+		if t.Direction == "LONG" {
+			d.LongPnL += t.PnL
+			d.LongTrades++
+		} else {
+			d.ShortPnL += t.PnL
+			d.ShortTrades++
+		}
+	}
+	if d.Trades > 0 {
+		d.WinRate = float64(d.Wins) / float64(d.Trades) * 100
+	}
+}
+
+func loadPerformance(path string) ([]dayPerformance, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var days []dayPerformance
+	if err := json.Unmarshal(data, &days); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	return days, nil
+}
+
+// recordPerformance adds trades to day's entry in performancePath. A day
+// summarised twice (after a restart) is merged rather than duplicated.
+func recordPerformance(trades []TradeRecord, day time.Time) error {
+	days, err := loadPerformance(performancePath)
+	if err != nil {
+		return err
+	}
+
+	date := day.Format("2006-01-02")
+	i := len(days)
+	for j := range days {
+		if days[j].Date == date {
+			i = j
+			break
+		}
+	}
+	if i == len(days) {
+		days = append(days, dayPerformance{Date: date})
+	}
+	days[i].add(trades)
+
+	return writeJSONAtomic(performancePath, days)
+}
+
+// performanceStats are the cumulative figures printed by `axiom report`.
+type performanceStats struct {
+	Days        int
+	TotalPnL    float64
+	AvgDailyPnL float64
+	Best, Worst dayPerformance
+	MaxDrawdown float64 // largest peak-to-trough fall of cumulative P&L
+}
+
+func summarizePerformance(days []dayPerformance) performanceStats {
+	var s performanceStats
+	if len(days) == 0 {
+		return s
+	}
+
+	s.Days = len(days)
+	s.Best, s.Worst = days[0], days[0]
+	var peak float64
+	for _, d := range days {
+		s.TotalPnL += d.NetPnL
+		if d.NetPnL > s.Best.NetPnL {
+			s.Best = d
+		}
+		if d.NetPnL < s.Worst.NetPnL {
+			s.Worst = d
+		}
+		peak = max(peak, s.TotalPnL)
+		s.MaxDrawdown = max(s.MaxDrawdown, peak-s.TotalPnL)
+	}
+	s.AvgDailyPnL = s.TotalPnL / float64(s.Days)
+	return s
+}
+
+// runReport implements the `report` subcommand.
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	path := fs.String("file", "", "performance history written by the daily summary (default performance.json in -data-dir)")
+	dirFlags(fs)
+	moneyFlags(fs)
+	fs.Parse(args)
+	if *path == "" {
+		*path = dataPath("performance.json")
+	}
+
+	days, err := loadPerformance(*path)
+	if err != nil {
+		return err
+	}
+	if len(days) == 0 {
+		fmt.Printf("No performance history in %s\n", *path)
+		return nil
+	}
+
+	s := summarizePerformance(days)
+	fmt.Printf("Performance %s → %s (%d days)\n", days[0].Date, days[len(days)-1].Date, s.Days)
+	fmt.Printf("Total P&L:        %s\n", money(s.TotalPnL))
+	fmt.Printf("Average daily:    %s\n", money(s.AvgDailyPnL))
+	fmt.Printf("Best day:         %s %s\n", s.Best.Date, money(s.Best.NetPnL))
+	fmt.Printf("Worst day:        %s %s\n", s.Worst.Date, money(s.Worst.NetPnL))
+	fmt.Printf("Max drawdown:     %s\n", money(s.MaxDrawdown))
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSummarizePerformance(t *testing.T) {
+	days := []dayPerformance{
+		{Date: "2026-01-01", NetPnL: 1000},
+		{Date: "2026-01-02", NetPnL: -400},
+		{Date: "2026-01-03", NetPnL: -800},
+		{Date: "2026-01-04", NetPnL: 1500},
+	}
+	s := summarizePerformance(days)
+
+	if s.TotalPnL != 1300 || s.Days != 4 {
+		t.Errorf("total = %.2f over %d days, want 1300 over 4", s.TotalPnL, s.Days)
+	}
+	if math.Abs(s.AvgDailyPnL-325) > 1e-9 {
+		t.Errorf("avg = %.2f, want 325", s.AvgDailyPnL)
+	}
+	if s.Best.Date != "2026-01-04" || s.Worst.Date != "2026-01-03" {
+		t.Errorf("best/worst = %s/%s", s.Best.Date, s.Worst.Date)
+	}
+	// Peak 1000 after day 1, trough -200 after day 3.
+	if s.MaxDrawdown != 1200 {
+		t.Errorf("max drawdown = %.2f, want 1200", s.MaxDrawdown)
+	}
+}
+
+func TestRecordPerformanceMergesSameDay(t *testing.T) {
+	old := performancePath
+	performancePath = filepath.Join(t.TempDir(), "performance.json")
+	t.Cleanup(func() { performancePath = old })
+
+	day := time.Date(2026, 1, 5, 15, 30, 0, 0, ist)
+	if err := recordPerformance([]TradeRecord{{Direction: "LONG", PnL: 100}}, day); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordPerformance([]TradeRecord{{Direction: "SHORT", PnL: -50}}, day); err != nil {
+		t.Fatal(err)
+	}
+
+	days, err := loadPerformance(performancePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 1 {
+		t.Fatalf("%d entries, want 1", len(days))
+	}
+	d := days[0]
+	if d.Trades != 2 || d.NetPnL != 50 || d.WinRate != 50 || d.LongTrades != 1 || d.ShortTrades != 1 {
+		t.Errorf("merged day = %+v", d)
+	}
+}
+
+func TestMoneyFormatting(t *testing.T) {
+	defer func(s string, d int) { currencySymbol, moneyDecimals = s, d }(currencySymbol, moneyDecimals)
+
+	if got := money(-1234.567); got != "₹-1234.57" {
+		t.Errorf("default money = %q", got)
+	}
+	currencySymbol, moneyDecimals = "", 3
+	if got := money(12.5); got != "12.500" {
+		t.Errorf("bare money = %q", got)
+	}
+	currencySymbol, moneyDecimals = "$", 0
+	if got, plain := money(99.6), plainMoney(99.6); got != "$100" || plain != "100" {
+		t.Errorf("money = %q, plainMoney = %q", got, plain)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/notify"
+)
+
+var (
+	// pollWorkers is how many symbols are fetched concurrently each tick.
+	// The client rate limiter bounds the request rate regardless of this
+	// value.
+	pollWorkers = 4
+
+	// classPollIntervals polls symbols of a StockStrategy.Class at their
+	// own interval; classes not listed are polled every pollInterval.
+	classPollIntervals = make(map[string]time.Duration)
+
+	// minRefetch is the least time between two quotes for the same
+	// symbol, whatever its class or position; the exchange throttles
+	// repeated requests for one token independently of the global rate
+	// limit. 0 disables it.
+	minRefetch = time.Second
+
+	nextPoll   = make(map[string]time.Time) // per-symbol next-due time; guarded by mu
+	lastPolled = make(map[string]time.Time) // per-symbol time of the last scheduled poll; guarded by mu
+)
+
+// symbolPollInterval is how often sym is polled when it is flat, stretched
+// by latencySlowdown while the API is slow.
+func symbolPollInterval(sym string) time.Duration {
+	d := pollInterval
+	if ci, ok := classPollIntervals[getStrategy(sym).Class]; ok && ci > 0 {
+		d = ci
+	}
+	if latencyDegraded.Load() {
+		d *= latencySlowdown
+	}
+	return max(d, minRefetch)
+}
+
+// tickInterval is the loop's tick: pollInterval, or the shortest class
+// interval if that is shorter, but never below minRefetch, which no
+// symbol could be polled faster than anyway.
+func tickInterval() time.Duration {
+	d := pollInterval
+	for _, ci := range classPollIntervals {
+		if ci > 0 {
+			d = min(d, ci)
+		}
+	}
+	return max(d, minRefetch)
+}
+
+// duePolls returns the symbols in tokens whose next poll is due at now and
+// schedules their following poll. Symbols with an open position or a
+// pending entry are polled every tick so exits are never delayed, unless
+// trading in them is halted; flat low-priority symbols are shed while the
+// API is slow. No symbol is polled twice within minRefetch; held symbols
+// are due again as soon as it allows.
+func duePolls(tokens map[string]string, now time.Time) map[string]string {
+	due := make(map[string]string, len(tokens))
+	for _, sym := range orderedSymbols(tokens) {
+		token := tokens[sym]
+		interval := symbolPollInterval(sym)
+
+		mu.Lock()
+		_, long := longPositions[sym]
+		_, short := shortPositions[sym]
+		next := nextPoll[sym]
+		throttled := now.Sub(lastPolled[sym]) < minRefetch
+		mu.Unlock()
+
+		if throttled {
+			continue
+		}
+
+		held := long || short || hasPendingEntry(sym, models.Long) || hasPendingEntry(sym, models.Short)
+		if !held && shedForLatency(sym) {
+			continue
+		}
+		// Ticks are jittered, so allow half a tick of slack rather than
+		// skipping a symbol that is due a moment after this tick.
+		if (!held || isHalted(sym)) && now.Add(tickInterval()/2).Before(next) {
+			continue
+		}
+		due[sym] = token
+
+		if isHalted(sym) {
+			interval = haltPollInterval
+		}
+		mu.Lock()
+		nextPoll[sym] = now.Add(interval)
+		lastPolled[sym] = now
+		mu.Unlock()
+	}
+	return due
+}
+
+// pollSymbols fetches and processes every symbol in tokens using a pool of
+// pollWorkers goroutines and returns how many quotes were fetched. Symbols
+// are handed out in symbol order. Each symbol is handled by exactly one
+// worker per tick; shared state is only touched under mu by the functions
+// pollSymbol calls.
+func pollSymbols(ctx context.Context, tokens map[string]string) int {
+	type job struct{ sym, token string }
+	jobs := make(chan job)
+
+	var (
+		wg      sync.WaitGroup
+		fetched atomic.Int64
+	)
+	for range max(pollWorkers, 1) {
+		wg.Go(func() {
+			for j := range jobs {
+				if pollSymbol(ctx, j.sym, j.token) {
+					fetched.Add(1)
+				}
+			}
+		})
+	}
+
+	first := true
+feed:
+	for _, sym := range orderedSymbols(tokens) {
+		token := tokens[sym]
+		if !first {
+			if err := sleepCtx(ctx, staggerDelay(len(tokens))); err != nil {
+				break feed
+			}
+		}
+		first = false
+
+		select {
+		case jobs <- job{sym, token}:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return int(fetched.Load())
+}
+
+// sleepCtx sleeps for d or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// pollSymbol fetches one quote and runs entries and exits for sym. It
+// reports whether the quote was fetched.
+func pollSymbol(ctx context.Context, sym, token string) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	quote, err := quotes.GetQuote(ctx, "NSE", token)
+	if ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, client.ErrCircuitOpen) {
+		return false // logged once by onCircuitChange
+	}
+	if errors.Is(err, client.ErrInstrumentHalted) {
+		// Not a mapping problem: keep the token and wait it out.
+		markHalted(sym, err, time.Now())
+		return false
+	}
+	recordLTPResult(ctx, sym, err)
+	if err != nil {
+		log.Printf("%s LTP error: %v", sym, err)
+		return false
+	}
+	clearHalted(sym, time.Now())
+
+	if ticks != nil {
+		ticks.record(sym, token, quote, time.Now())
+	}
+
+	ltp := quote.LTP
+	if quote.TickSize > 0 {
+		mu.Lock()
+		tickSizes[sym] = quote.TickSize
+		mu.Unlock()
+	}
+
+	// Entries are evaluated against the range before this tick, so
+	// the session high/low is only extended afterwards.
+	updateLTPHistory(sym, quote)
+	matchPaperOrders(sym, ltp)
+	checkAllEntries(sym, ltp)
+	updateHighLow(sym, ltp)
+	checkLongExit(sym, ltp)
+	checkShortExit(sym, ltp)
+	return true
+}
+
+// onCircuitChange reports API circuit transitions. While the circuit is
+// not closed checkAllEntries takes no entries; polling continues so the
+// breaker's probes can detect recovery.
+func onCircuitChange(s client.CircuitState) {
+	switch s {
+	case client.CircuitOpen:
+		notifier.Notify(notify.EventError, "API circuit open after repeated failures - requests suspended, entries paused")
+	case client.CircuitHalfOpen:
+		log.Printf("API circuit half-open - probing for recovery")
+	case client.CircuitClosed:
+		notifier.Notify(notify.EventError, "API circuit closed - connectivity restored, entries resumed")
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+// fakeQuotes serves a rising price per token and counts requests.
+type fakeQuotes struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (f *fakeQuotes) GetQuote(ctx context.Context, exch, token string) (client.Quote, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[token]++
+	return client.Quote{LTP: 100 + float64(f.calls[token])}, nil
+}
+
+func (f *fakeQuotes) GetLTP(ctx context.Context, exch, token string) (float64, error) {
+	q, err := f.GetQuote(ctx, exch, token)
+	return q.LTP, err
+}
+
+func TestPollSymbolsConcurrent(t *testing.T) {
+	resetBooks(t)
+	fq := &fakeQuotes{calls: make(map[string]int)}
+	oldQuotes, oldWorkers, oldTokens := quotes, pollWorkers, symbolToToken
+	quotes, pollWorkers = fq, 8
+	t.Cleanup(func() { quotes, pollWorkers, symbolToToken = oldQuotes, oldWorkers, oldTokens })
+
+	tokens := make(map[string]string)
+	for i := range 40 {
+		sym := fmt.Sprintf("SYM%02d", i)
+		tokens[sym] = fmt.Sprint(i)
+		stockStrategies[sym] = models.StockStrategy{
+			Class: "B", AllowShort: true, BreakoutLong: 0.001, SL: 0.05, Target: 0.05, Leverage: 1,
+		}
+	}
+	symbolToToken = tokens
+
+	const ticks = 10
+	for range ticks {
+		if got := pollSymbols(context.Background(), tokens); got != len(tokens) {
+			t.Fatalf("fetched %d symbols, want %d", got, len(tokens))
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for sym := range tokens {
+		ms := markets[sym]
+		if ms == nil || ms.Ticks != ticks {
+			t.Fatalf("%s: market state missing or wrong tick count", sym)
+		}
+	}
+	if open := len(longPositions) + len(shortPositions); open > defaultMaxPositions {
+		t.Errorf("%d positions open, cap is %d", open, defaultMaxPositions)
+	}
+}
+
+func TestDuePollsByClass(t *testing.T) {
+	resetBooks(t)
+	oldInterval := pollInterval
+	pollInterval = 15 * time.Second
+	classPollIntervals = map[string]time.Duration{"A": 5 * time.Second}
+	t.Cleanup(func() { pollInterval, classPollIntervals = oldInterval, make(map[string]time.Duration) })
+
+	stockStrategies["AAA"] = models.StockStrategy{Class: "A"}
+	stockStrategies["CCC"] = models.StockStrategy{Class: "C"}
+	stockStrategies["HELD"] = models.StockStrategy{Class: "C"}
+	seedLong("HELD", 100, 1)
+	tokens := map[string]string{"AAA": "1", "CCC": "3", "HELD": "4"}
+
+	if got := tickInterval(); got != 5*time.Second {
+		t.Fatalf("tickInterval = %v, want the class A interval", got)
+	}
+
+	counts := make(map[string]int)
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	for i := range 12 { // one minute of 5s ticks
+		for sym := range duePolls(tokens, start.Add(time.Duration(i)*5*time.Second)) {
+			counts[sym]++
+		}
+	}
+	if counts["AAA"] != 12 || counts["CCC"] != 4 {
+		t.Errorf("polls = %v, want AAA 12 and CCC 4", counts)
+	}
+	if counts["HELD"] != 12 {
+		t.Errorf("symbol with an open position polled %d times, want every tick", counts["HELD"])
+	}
+}
+
+func TestDuePollsMinRefetch(t *testing.T) {
+	resetBooks(t)
+	oldInterval, oldRefetch := pollInterval, minRefetch
+	t.Cleanup(func() {
+		pollInterval, minRefetch, classPollIntervals = oldInterval, oldRefetch, make(map[string]time.Duration)
+	})
+	pollInterval, minRefetch = 10*time.Second, 3*time.Second
+	classPollIntervals = map[string]time.Duration{"A": time.Second}
+
+	stockStrategies["FAST"] = models.StockStrategy{Class: "A"}
+	stockStrategies["HELD"] = models.StockStrategy{Class: "C"}
+	seedLong("HELD", 100, 1)
+	tokens := map[string]string{"FAST": "1", "HELD": "2"}
+
+	if got := tickInterval(); got != 3*time.Second {
+		t.Errorf("tickInterval = %v, want it raised to -min-refetch", got)
+	}
+
+	polls := make(map[string][]int)
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	for i := range 12 { // 1s ticks, faster than the loop would run
+		for sym := range duePolls(tokens, start.Add(time.Duration(i)*time.Second)) {
+			polls[sym] = append(polls[sym], i)
+		}
+	}
+	for _, sym := range []string{"FAST", "HELD"} {
+		if got := polls[sym]; fmt.Sprint(got) != "[0 3 6 9]" {
+			t.Errorf("%s polled at %v s, want every 3s", sym, got)
+		}
+	}
+
+	// A jittered tick just short of the spacing waits for the next one.
+	early := start.Add(12*time.Second - time.Millisecond)
+	if _, ok := duePolls(tokens, early)["HELD"]; ok {
+		t.Error("held symbol re-polled inside -min-refetch")
+	}
+}
+
+// haltQuotes answers every token with a halt until resumed, then ltp.
+type haltQuotes struct {
+	mu      sync.Mutex
+	resumed bool
+	ltp     float64
+	calls   int
+}
+
+func (h *haltQuotes) GetQuote(ctx context.Context, exch, token string) (client.Quote, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls++
+	if !h.resumed {
+		return client.Quote{}, fmt.Errorf("%w: Security is suspended", client.ErrInstrumentHalted)
+	}
+	return client.Quote{LTP: h.ltp}, nil
+}
+
+func (h *haltQuotes) GetLTP(ctx context.Context, exch, token string) (float64, error) {
+	q, err := h.GetQuote(ctx, exch, token)
+	return q.LTP, err
+}
+
+func TestHaltedSymbolBacksOffAndExitsOnResume(t *testing.T) {
+	resetBooks(t)
+	oldQuotes, oldInterval, oldHalt := quotes, pollInterval, haltPollInterval
+	t.Cleanup(func() { quotes, pollInterval, haltPollInterval = oldQuotes, oldInterval, oldHalt })
+	hq := &haltQuotes{ltp: 97}
+	quotes, pollInterval, haltPollInterval = hq, 5*time.Second, 30*time.Second
+
+	stockStrategies["HELD"] = models.StockStrategy{Class: "B", SL: 0.01, Target: 0.02, Leverage: 1}
+	seedLong("HELD", 100, 10)
+	tokens := map[string]string{"HELD": "4"}
+
+	start := time.Now()
+	for i := range 12 { // one minute of 5s ticks
+		for sym, token := range duePolls(tokens, start.Add(time.Duration(i)*5*time.Second)) {
+			pollSymbol(context.Background(), sym, token)
+		}
+	}
+	if hq.calls != 2 {
+		t.Errorf("halted symbol polled %d times in a minute, want 2 at the 30s halt interval", hq.calls)
+	}
+	if !isHalted("HELD") || ltpFailures["HELD"] != 0 {
+		t.Errorf("halted = %v, LTP failures = %d; want halted and no failures towards a re-map", isHalted("HELD"), ltpFailures["HELD"])
+	}
+	if !hasPosition("HELD", models.Long) {
+		t.Fatal("position exited while halted")
+	}
+
+	hq.resumed = true
+	pollSymbol(context.Background(), "HELD", "4")
+	if isHalted("HELD") {
+		t.Error("halt not cleared by a good quote")
+	}
+	if hasPosition("HELD", models.Long) {
+		t.Error("SL exit not taken once trading resumed")
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import "sort"
+
+// Portfolio is the day's P&L at a point in time.
+type Portfolio struct {
+	Realized   float64  `json:"realized_pnl"`   // closed trades today
+	Unrealized float64  `json:"unrealized_pnl"` // open positions marked to the latest LTP
+	Total      float64  `json:"total_pnl"`
+	Unpriced   []string `json:"unpriced,omitempty"` // open positions with no LTP yet, left out of Unrealized
+}
+
+// PortfolioSnapshot returns realized P&L from tradeHistory plus unrealized
+// P&L for every open position against its symbol's latest LTP.
+func PortfolioSnapshot() Portfolio {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var p Portfolio
+	for _, t := range tradeHistory {
+		p.Realized += t.PnL
+	}
+
+	mark := func(sym string, pos position, sign float64) {
+		ms, ok := markets[sym]
+		if !ok || ms.LTP <= 0 {
+			p.Unpriced = append(p.Unpriced, sym)
+			return
+		}
+		p.Unrealized += sign * (ms.LTP - pos.AvgEntry()) * float64(pos.TotalQty)
+	}
+	for sym, pos := range longPositions {
+		mark(sym, pos, 1)
+	}
+	for sym, pos := range shortPositions {
+		mark(sym, pos, -1)
+	}
+	sort.Strings(p.Unpriced)
+
+	p.Total = p.Realized + p.Unrealized
+	return p
+}
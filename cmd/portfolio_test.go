@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+func TestPortfolioSnapshot(t *testing.T) {
+	resetBooks(t)
+	tradeHistory = []TradeRecord{{Symbol: "TCS", PnL: 250}, {Symbol: "INFY", PnL: -100}}
+
+	seedLong("SBIN", 800, 10)  // LTP 810: +100
+	seedLong("HDFC", 1500, 2)  // no LTP yet
+	seedShort("ITC", 450, 20)  // LTP 455: -100
+	seedShort("WIPRO", 400, 5) // LTP 390: +50
+	markets["SBIN"] = &state.MarketState{Symbol: "SBIN", LTP: 810}
+	markets["ITC"] = &state.MarketState{Symbol: "ITC", LTP: 455}
+	markets["WIPRO"] = &state.MarketState{Symbol: "WIPRO", LTP: 390}
+
+	p := PortfolioSnapshot()
+	if p.Realized != 150 {
+		t.Errorf("realized = %.2f, want 150", p.Realized)
+	}
+	if math.Abs(p.Unrealized-50) > 1e-9 {
+		t.Errorf("unrealized = %.2f, want 50", p.Unrealized)
+	}
+	if math.Abs(p.Total-200) > 1e-9 {
+		t.Errorf("total = %.2f, want 200", p.Total)
+	}
+	if !slices.Equal(p.Unpriced, []string{"HDFC"}) {
+		t.Errorf("unpriced = %v, want [HDFC]", p.Unpriced)
+	}
+}
+
+func TestPortfolioSnapshotEmpty(t *testing.T) {
+	resetBooks(t)
+	if p := PortfolioSnapshot(); p.Realized != 0 || p.Unrealized != 0 || p.Total != 0 || p.Unpriced != nil {
+		t.Errorf("empty book snapshot = %+v", p)
+	}
+}
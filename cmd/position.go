@@ -0,0 +1,69 @@
+package main
+
+import "time"
+
+// position is an open long or short. Each entry lot is folded into
+// TotalCost and TotalQty, so the entry price is the weighted average over
+// every lot still held.
+type position struct {
+	TotalCost    float64 // sum of fill price * qty over the held lots
+	TotalQty     int
+	HighestPrice float64   // best price since entry, longs
+	LowestPrice  float64   // best price since entry, shorts
+	EntryTime    time.Time // first lot
+	Strategy     string    // entry strategy of the first lot
+	Tags         []string  // tags of the first lot
+	Capital      float64   // capital tied up: each lot's cost over its leverage
+	Product      string    // order product of the entry; exits must match it
+
+	// BracketOrder is the entry order number of a bracket position. When
+	// set, the exchange owns the exits at BracketStop and BracketTarget
+	// and the local exit rules do not run.
+	BracketOrder  string
+	BracketStop   float64
+	BracketTarget float64
+
+	// StopOrder is the resting SL-MKT order protecting the position at
+	// StopPrice, and TargetOrder the resting limit taking profit at
+	// TargetPrice, when stopMode is stopsExchange. They are a
+	// one-cancels-other pair: when one fills, pollExchangeStops cancels
+	// the other.
+	StopOrder   string
+	StopPrice   float64
+	TargetOrder string
+	TargetPrice float64
+}
+
+// AvgEntry is the weighted-average entry price.
+func (p position) AvgEntry() float64 {
+	if p.TotalQty == 0 {
+		return 0
+	}
+	return p.TotalCost / float64(p.TotalQty)
+}
+
+// addLot folds a fill of qty at price and leverage, opened by src, into
+// the cost basis. The position keeps the strategy and tags of its first
+// lot.
+func (p *position) addLot(price float64, qty int, leverage float64, src entrySource) {
+	if p.TotalQty == 0 {
+		p.EntryTime = time.Now()
+		p.Strategy, p.Tags = src.Strategy, src.Tags
+	}
+	p.TotalCost += price * float64(qty)
+	p.Capital += price * float64(qty) / max(leverage, 1)
+	p.TotalQty += qty
+}
+
+// reduce removes qty at the average cost and reports whether the position
+// is now flat.
+func (p *position) reduce(qty int) bool {
+	if qty >= p.TotalQty {
+		p.TotalCost, p.TotalQty, p.Capital = 0, 0, 0
+		return true
+	}
+	p.Capital -= p.Capital * float64(qty) / float64(p.TotalQty)
+	p.TotalCost -= p.AvgEntry() * float64(qty)
+	p.TotalQty -= qty
+	return false
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+func TestPositionWeightedAverage(t *testing.T) {
+	var p position
+	p.addLot(100, 10, 1, entrySource{})
+	p.addLot(110, 30, 1, entrySource{})
+
+	if p.TotalQty != 40 {
+		t.Fatalf("TotalQty = %d, want 40", p.TotalQty)
+	}
+	if got, want := p.AvgEntry(), 107.5; math.Abs(got-want) > 1e-9 {
+		t.Errorf("AvgEntry = %.4f, want %.4f", got, want)
+	}
+
+	// A partial exit keeps the average; a full exit flattens.
+	if p.reduce(15) {
+		t.Fatal("partial reduce reported flat")
+	}
+	if got, want := p.AvgEntry(), 107.5; math.Abs(got-want) > 1e-9 {
+		t.Errorf("AvgEntry after partial exit = %.4f, want %.4f", got, want)
+	}
+	if !p.reduce(25) {
+		t.Fatal("full reduce did not report flat")
+	}
+}
+
+func TestExitLongBlendedPnL(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{Class: "B"}
+	slippageBps["B"] = 0
+	t.Cleanup(func() { slippageBps["B"] = 5 })
+
+	openLong("TEST", entrySource{Strategy: "breakout_long", Tags: []string{"earnings"}}, 100, 100, 10, 1, client.ProductCNC)
+	openLong("TEST", entrySource{Strategy: "bounce_long"}, 110, 110, 30, 1, client.ProductCNC)
+	exitLong("TEST", 112, 40, ReasonTarget)
+
+	tr := lastTrade(t)
+	if tr.Strategy != "breakout_long" {
+		t.Errorf("Strategy = %q, want the first lot's breakout_long", tr.Strategy)
+	}
+	if !tr.hasTag("earnings") {
+		t.Errorf("Tags = %v, want the first lot's earnings tag", tr.Tags)
+	}
+	if want := 107.5; math.Abs(tr.EntryPrice-want) > 1e-9 {
+		t.Errorf("EntryPrice = %.4f, want %.4f", tr.EntryPrice, want)
+	}
+	// 10*(112-100) + 30*(112-110) = 180
+	if want := 180.0; math.Abs(tr.PnL-want) > 1e-9 {
+		t.Errorf("PnL = %.4f, want %.4f", tr.PnL, want)
+	}
+	if hasPosition("TEST", models.Long) {
+		t.Error("position still open after exiting the full quantity")
+	}
+}
+
+func TestExitShortBlendedPnLWithPartialExit(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{Class: "B"}
+	slippageBps["B"] = 0
+	t.Cleanup(func() { slippageBps["B"] = 5 })
+
+	openShort("TEST", entrySource{}, 200, 200, 5, 1, client.ProductMIS)
+	openShort("TEST", entrySource{}, 190, 190, 15, 1, client.ProductMIS) // avg 192.5
+
+	exitShort("TEST", 185, 10, ReasonManual)
+	if want := 75.0; math.Abs(lastTrade(t).PnL-want) > 1e-9 {
+		t.Errorf("partial PnL = %.4f, want %.4f", lastTrade(t).PnL, want)
+	}
+
+	mu.Lock()
+	pos := shortPositions["TEST"]
+	mu.Unlock()
+	if pos.TotalQty != 10 || math.Abs(pos.AvgEntry()-192.5) > 1e-9 {
+		t.Fatalf("remaining = %d @ %.4f, want 10 @ 192.5", pos.TotalQty, pos.AvgEntry())
+	}
+
+	exitShort("TEST", 195, 10, ReasonFixedSL)
+	if want := -25.0; math.Abs(lastTrade(t).PnL-want) > 1e-9 {
+		t.Errorf("final PnL = %.4f, want %.4f", lastTrade(t).PnL, want)
+	}
+	if hasPosition("TEST", models.Short) {
+		t.Error("position still open after exiting the remaining quantity")
+	}
+}
+
+func TestReentryStartsFreshCostBasis(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{Class: "B"}
+	slippageBps["B"] = 0
+	t.Cleanup(func() { slippageBps["B"] = 5 })
+
+	openLong("TEST", entrySource{}, 100, 100, 10, 1, client.ProductCNC)
+	exitLong("TEST", 105, 10, ReasonTarget)
+	openLong("TEST", entrySource{}, 120, 120, 10, 1, client.ProductCNC)
+
+	mu.Lock()
+	pos := longPositions["TEST"]
+	mu.Unlock()
+	if math.Abs(pos.AvgEntry()-120) > 1e-9 || pos.TotalQty != 10 {
+		t.Errorf("re-entry = %d @ %.4f, want 10 @ 120", pos.TotalQty, pos.AvgEntry())
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// symbolPriority lists the symbols handled first, in this order, wherever
+// the bot walks its books or watchlist: polling, square-off and order
+// checks. The rest follow alphabetically, so every run takes the same
+// order and, when capital or position caps run out part way, the same
+// symbols win.
+var symbolPriority []string
+
+func setSymbolPriority(v string) error {
+	symbolPriority = nil
+	for _, sym := range strings.Split(v, ",") {
+		if sym = strings.ToUpper(strings.TrimSpace(sym)); sym != "" {
+			symbolPriority = append(symbolPriority, sym)
+		}
+	}
+	return nil
+}
+
+// compareSymbols orders a before b if it is listed earlier in
+// symbolPriority, or, when neither is listed, alphabetically.
+func compareSymbols(a, b string) int {
+	rank := func(sym string) int {
+		if i := slices.Index(symbolPriority, sym); i >= 0 {
+			return i
+		}
+		return len(symbolPriority)
+	}
+	if c := cmp.Compare(rank(a), rank(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// orderedSymbols returns m's keys in compareSymbols order.
+func orderedSymbols[V any](m map[string]V) []string {
+	return slices.SortedFunc(maps.Keys(m), compareSymbols)
+}
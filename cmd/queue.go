@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+var (
+	// signalQueueSize is how many signals are held while every position
+	// slot is taken, to be entered when one frees up. 0 drops them.
+	signalQueueSize = 0
+	signalQueueTTL  = 30 * time.Second // queued signals older than this are dropped
+
+	signalQueue []queuedSignal // best first; guarded by mu
+)
+
+// queuedSignal is a signal that fired while max positions was reached.
+type queuedSignal struct {
+	Signal   models.Signal
+	Leverage float64
+	Queued   time.Time
+}
+
+// queueSignal holds q, replacing any older signal for the same symbol and
+// direction, and keeps only the signalQueueSize strongest by
+// Signal.Strength.
+func queueSignal(q queuedSignal) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i, old := range signalQueue {
+		if old.Signal.Symbol == q.Signal.Symbol && old.Signal.Direction == q.Signal.Direction {
+			signalQueue = append(signalQueue[:i], signalQueue[i+1:]...)
+			break
+		}
+	}
+	signalQueue = append(signalQueue, q)
+	sort.SliceStable(signalQueue, func(i, j int) bool {
+		return signalQueue[i].Signal.Strength > signalQueue[j].Signal.Strength
+	})
+	if len(signalQueue) > signalQueueSize {
+		signalQueue = signalQueue[:signalQueueSize]
+	}
+}
+
+// popSignal removes and returns the strongest queued signal that has not
+// expired, dropping expired ones.
+func popSignal(now time.Time) (queuedSignal, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for len(signalQueue) > 0 {
+		q := signalQueue[0]
+		signalQueue = signalQueue[1:]
+		if now.Sub(q.Queued) <= signalQueueTTL {
+			return q, true
+		}
+		fmt.Printf("Queued %s %s expired\n", q.Signal.Direction, q.Signal.Symbol)
+	}
+	return queuedSignal{}, false
+}
+
+// drainSignalQueue enters queued signals, strongest first, while position
+// slots are free. Each entry is re-checked against the current state and
+// taken at the symbol's latest LTP.
+func drainSignalQueue() {
+	entryMu.Lock()
+	defer entryMu.Unlock()
+
+	for {
+		mu.Lock()
+		free := len(longPositions)+len(shortPositions) < defaultMaxPositions
+		mu.Unlock()
+		if !free {
+			return
+		}
+
+		q, ok := popSignal(time.Now())
+		if !ok {
+			return
+		}
+		enterQueued(q)
+	}
+}
+
+func enterQueued(q queuedSignal) {
+	sym, dir := q.Signal.Symbol, q.Signal.Direction
+
+	mu.Lock()
+	blocked := closeOnly || disabledSymbols[sym] || benched[sym] || entriesPaused
+	var ms *state.MarketState
+	if m, ok := markets[sym]; ok {
+		ms = m.Snapshot()
+	}
+	mu.Unlock()
+
+	var ltp float64
+	if ms != nil {
+		ltp = ms.LTP
+	}
+
+	if blocked || ltp <= 0 || client.Circuit() != client.CircuitClosed || isHalted(sym) {
+		return
+	}
+	if hasPosition(sym, dir) || hasPendingEntry(sym, dir) {
+		return
+	}
+	strat := getStrategy(sym)
+	if !strat.Allows(dir) {
+		fmt.Printf("%s bias %q - dropping queued %s\n", sym, strat.DirectionBias, dir)
+		return
+	}
+	if !inEntryWindow(strat, nowIST()) {
+		fmt.Printf("%s outside its entry windows - dropping queued %s\n", sym, dir)
+		return
+	}
+	if reason := positionCapReached(dir, strat.Sector); reason != "" {
+		fmt.Printf("%s - dropping queued %s %s\n", reason, dir, sym)
+		return
+	}
+	if chased := dayChangeExceeded(ms, dir, strat); chased != "" {
+		fmt.Printf("%s - dropping queued %s %s\n", chased, dir, sym)
+		return
+	}
+	if stretched := overExtended(ms, dir, strat); stretched != "" {
+		fmt.Printf("%s - dropping queued %s %s\n", stretched, dir, sym)
+		return
+	}
+
+	fmt.Printf("Entering queued %s %s (queued %s ago): %s\n", dir, sym, time.Since(q.Queued).Round(time.Second), q.Signal.Reason)
+	if dir == models.Long {
+		enterLong(sym, signalSource(q.Signal), ltp, q.Leverage, q.Signal.Strength)
+	} else {
+		enterShort(sym, signalSource(q.Signal), ltp, q.Leverage, q.Signal.Strength)
+	}
+}
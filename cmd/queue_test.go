@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+func queued(sym string, dir models.Direction, strength float64, at time.Time) queuedSignal {
+	return queuedSignal{
+		Signal:   models.Signal{Symbol: sym, Direction: dir, Strength: strength},
+		Leverage: 1, Queued: at,
+	}
+}
+
+func TestQueueSignalKeepsStrongest(t *testing.T) {
+	resetBooks(t)
+	defer func(n int) { signalQueueSize = n }(signalQueueSize)
+	signalQueueSize = 2
+	now := time.Now()
+
+	queueSignal(queued("A", models.Long, 0.01, now))
+	queueSignal(queued("B", models.Long, 0.03, now))
+	queueSignal(queued("C", models.Short, 0.02, now))
+	queueSignal(queued("A", models.Long, 0.05, now)) // replaces A's earlier signal
+
+	if len(signalQueue) != 2 {
+		t.Fatalf("queue length = %d, want 2", len(signalQueue))
+	}
+	if signalQueue[0].Signal.Symbol != "A" || signalQueue[1].Signal.Symbol != "B" {
+		t.Errorf("queue = %s, %s; want A, B", signalQueue[0].Signal.Symbol, signalQueue[1].Signal.Symbol)
+	}
+}
+
+func TestDrainSignalQueueEntersWhenSlotFrees(t *testing.T) {
+	resetBooks(t)
+	defer func(n, limit int) { signalQueueSize, defaultMaxPositions = n, limit }(signalQueueSize, defaultMaxPositions)
+	signalQueueSize, defaultMaxPositions = 3, 1
+
+	seedLong("HELD", 100, 10)
+	for _, sym := range []string{"WEAK", "STRONG", "OLD"} {
+		stockStrategies[sym] = models.StockStrategy{Class: "B", SL: 0.01, Target: 0.02, Leverage: 1}
+		markets[sym] = &state.MarketState{Symbol: sym, LTP: 100}
+	}
+	now := time.Now()
+	queueSignal(queued("WEAK", models.Long, 0.01, now))
+	queueSignal(queued("STRONG", models.Long, 0.02, now))
+	queueSignal(queued("OLD", models.Long, 0.09, now.Add(-2*signalQueueTTL)))
+
+	drainSignalQueue()
+	if hasPosition("STRONG", models.Long) || hasPosition("WEAK", models.Long) {
+		t.Fatal("entered a queued signal while every slot was taken")
+	}
+
+	delete(longPositions, "HELD")
+	drainSignalQueue()
+	if hasPosition("OLD", models.Long) {
+		t.Error("entered an expired signal")
+	}
+	if !hasPosition("STRONG", models.Long) {
+		t.Error("strongest live signal not entered when a slot freed")
+	}
+	if hasPosition("WEAK", models.Long) {
+		t.Error("entered beyond max positions")
+	}
+}
+
+func TestCheckAllEntriesQueuesWhenFull(t *testing.T) {
+	resetBooks(t)
+	defer func(n, limit int) { signalQueueSize, defaultMaxPositions = n, limit }(signalQueueSize, defaultMaxPositions)
+	signalQueueSize, defaultMaxPositions = 5, 1
+
+	seedLong("HELD", 100, 10)
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", BreakoutLong: 0.001, SL: 0.01, Target: 0.02, Leverage: 1,
+	}
+	markets["TEST"] = &state.MarketState{Symbol: "TEST", High: 100, Low: 95, Ticks: warmupTicks}
+
+	checkAllEntries("TEST", 101)
+	if hasPosition("TEST", models.Long) {
+		t.Fatal("entered beyond max positions")
+	}
+	if len(signalQueue) != 1 || signalQueue[0].Signal.Symbol != "TEST" {
+		t.Fatalf("queue = %+v, want the TEST breakout", signalQueue)
+	}
+	// (101/100 - 1) / 0.001
+	if s := signalQueue[0].Signal.Strength; s < 9.99 || s > 10.01 {
+		t.Errorf("breakout strength = %v, want 10", s)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// ExitReason is why a position was closed. The value is the stable code
+// written to trade logs; String gives the label used in messages.
+type ExitReason string
+
+const (
+	ReasonFixedSL        ExitReason = "fixed_sl"
+	ReasonBreakEven      ExitReason = "break_even"
+	ReasonTarget         ExitReason = "target"
+	ReasonTrailingSL     ExitReason = "trailing_sl"
+	ReasonMaxHold        ExitReason = "max_hold"
+	ReasonBracketSL      ExitReason = "bracket_sl"
+	ReasonBracketTarget  ExitReason = "bracket_target"
+	ReasonExchangeSL     ExitReason = "exchange_sl"
+	ReasonExchangeTarget ExitReason = "exchange_target"
+	ReasonEOD            ExitReason = "eod"
+	ReasonManual         ExitReason = "manual"
+	ReasonFlatten        ExitReason = "flatten"
+	ReasonDeadMan        ExitReason = "dead_man"
+	ReasonUnknown        ExitReason = "unknown"
+)
+
+var exitReasonLabels = map[ExitReason]string{
+	ReasonFixedSL:        "Fixed SL",
+	ReasonBreakEven:      "Break-even SL",
+	ReasonTarget:         "Target",
+	ReasonTrailingSL:     "Trailing SL",
+	ReasonMaxHold:        "Max hold time",
+	ReasonBracketSL:      "Bracket SL",
+	ReasonBracketTarget:  "Bracket target",
+	ReasonExchangeSL:     "Exchange SL",
+	ReasonExchangeTarget: "Exchange target",
+	ReasonEOD:            "EOD Square-off",
+	ReasonManual:         "Manual exit",
+	ReasonFlatten:        "Manual flatten",
+	ReasonDeadMan:        "Dead-man's switch",
+	ReasonUnknown:        "Unknown",
+}
+
+func (r ExitReason) String() string {
+	if l, ok := exitReasonLabels[r]; ok {
+		return l
+	}
+	return string(r)
+}
+
+// parseExitReason maps a logged reason to its ExitReason. Logs written
+// before reasons were typed hold the free-form label, sometimes with a
+// percentage appended ("Fixed SL 1.0%"), so labels are matched by prefix.
+func parseExitReason(s string) ExitReason {
+	if _, ok := exitReasonLabels[ExitReason(s)]; ok {
+		return ExitReason(s)
+	}
+	best, bestLen := ReasonUnknown, 0
+	for r, l := range exitReasonLabels {
+		// Take the longest matching label in case one prefixes another.
+		if strings.HasPrefix(s, l) && len(l) > bestLen {
+			best, bestLen = r, len(l)
+		}
+	}
+	return best
+}
+
+// reasonSummary is one exit reason's line in the daily summary.
+type reasonSummary struct {
+	Reason ExitReason `json:"reason"`
+	Trades int        `json:"trades"`
+	PnL    float64    `json:"pnl"`
+}
+
+// summarizeReasons totals trades by exit reason, most frequent first.
+func summarizeReasons(trades []TradeRecord) []reasonSummary {
+	var out []reasonSummary
+	idx := make(map[ExitReason]int)
+	for _, t := range trades {
+		i, ok := idx[t.Reason]
+		if !ok {
+			i = len(out)
+			idx[t.Reason] = i
+			out = append(out, reasonSummary{Reason: t.Reason})
+		}
+		out[i].Trades++
+		out[i].PnL += t.PnL
+	}
+	slices.SortStableFunc(out, func(a, b reasonSummary) int { return b.Trades - a.Trades })
+	return out
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/auth"
+	"github.com/may-bach/Axiom/internal/notify"
+)
+
+var (
+	// sessionRefreshAfter is the session age at which the token is renewed
+	// proactively, ahead of Flattrade's end-of-day expiry. 0 leaves
+	// re-authentication to the reactive path in client.MakeRequest.
+	sessionRefreshAfter time.Duration
+	sessionRetryAfter   = 5 * time.Minute // wait between failed refreshes
+
+	lastRefreshAttempt time.Time
+)
+
+// maybeRefreshSession renews the session token once it is older than
+// sessionRefreshAfter. It runs between ticks and only while no limit
+// entry is pending, so no request is in flight on the old token. Unlike
+// startup it never prompts: on failure the current token stays in use and
+// the operator is notified. A request_code is good for one exchange, so
+// once Flattrade refuses the configured one, proactive refresh stops for
+// the run rather than retrying a code that cannot work.
+func maybeRefreshSession(ctx context.Context) {
+	if sessionRefreshAfter <= 0 || account.Session.Age() < sessionRefreshAfter {
+		return
+	}
+	if time.Since(lastRefreshAttempt) < sessionRetryAfter {
+		return
+	}
+
+	mu.Lock()
+	pending := len(pendingEntries)
+	mu.Unlock()
+	if pending > 0 {
+		return
+	}
+
+	lastRefreshAttempt = time.Now()
+	fmt.Printf("Session is %s old - refreshing token\n", account.Session.Age().Round(time.Minute))
+
+	if _, err := account.Refresh(ctx); err != nil {
+		if errors.Is(err, auth.ErrRequestCodeExpired) {
+			sessionRefreshAfter = 0
+			notifier.Notify(notify.EventError, fmt.Sprintf("Proactive session refresh needs a new request_code - disabled until restart: %v", err))
+			return
+		}
+		notifier.Notify(notify.EventError, fmt.Sprintf("Proactive session refresh failed: %v", err))
+		return
+	}
+	fmt.Println("Session token refreshed")
+}
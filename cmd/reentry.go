@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+// reentryTag marks trades opened by a re-entry after a target exit.
+const reentryTag = "reentry"
+
+// reentryArm is a target exit waiting for the move to continue: a dir
+// re-entry fires if price goes past Extreme before Until.
+type reentryArm struct {
+	Direction models.Direction
+	Extreme   float64 // best price of the exited position
+	Until     time.Time
+	Source    entrySource
+}
+
+var (
+	reentryWindow = 5 * time.Minute // default time after a target exit to watch for a new extreme
+
+	reentryArms   = make(map[string]reentryArm) // by symbol
+	reentryCounts = make(map[string]int)        // re-entries taken today, by symbol
+)
+
+// reentryLimit is how long sym may wait for a continuation and how many
+// re-entries it may take in a day; a zero count disables re-entry.
+func reentryLimit(strat models.StockStrategy) (time.Duration, int) {
+	if !strat.AllowReentry {
+		return 0, 0
+	}
+	window := reentryWindow
+	if strat.ReentryWindowMinutes > 0 {
+		window = time.Duration(strat.ReentryWindowMinutes * float64(time.Minute))
+	}
+	return window, max(strat.MaxReentries, 1)
+}
+
+// armReentry watches for a continuation after pos, a dir position in sym
+// with best price extreme, is closed for reason. Only target exits arm,
+// and only while the symbol has re-entries left today.
+func armReentry(sym string, dir models.Direction, pos position, extreme float64, reason ExitReason) {
+	if reason != ReasonTarget && reason != ReasonBracketTarget && reason != ReasonExchangeTarget {
+		return
+	}
+	window, limit := reentryLimit(getStrategy(sym))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if reentryCounts[sym] >= limit {
+		return
+	}
+	src := entrySource{Strategy: pos.Strategy, Tags: slices.Clone(pos.Tags)}
+	if !slices.Contains(src.Tags, reentryTag) {
+		src.Tags = append(src.Tags, reentryTag)
+	}
+	reentryArms[sym] = reentryArm{Direction: dir, Extreme: extreme, Until: time.Now().Add(window), Source: src}
+}
+
+// checkReentry re-enters sym in the armed direction at ltp once the
+// decision price makes a new extreme within the window. The re-entry goes
+// through the normal entry path, so it is sized, capped and exited like
+// any other position.
+func checkReentry(sym string, ltp float64, strat models.StockStrategy) {
+	mu.Lock()
+	arm, ok := reentryArms[sym]
+	if ok && time.Now().After(arm.Until) {
+		delete(reentryArms, sym)
+		ok = false
+	}
+	mu.Unlock()
+	if !ok {
+		return
+	}
+
+	dir, px := arm.Direction, decisionLTP(sym, ltp)
+	if (dir == models.Long && px <= arm.Extreme) || (dir == models.Short && px >= arm.Extreme) {
+		return
+	}
+	if !strat.Allows(dir) || (dir == models.Short && (!strat.AllowShort || !shortable(sym))) {
+		return
+	}
+	if hasPosition(sym, dir) || hasPendingEntry(sym, dir) {
+		return
+	}
+	if blocked := positionCapReached(dir, strat.Sector); blocked != "" {
+		fmt.Printf("%s - skipping re-entry %s %s\n", blocked, dir, sym)
+		return
+	}
+
+	mu.Lock()
+	delete(reentryArms, sym)
+	reentryCounts[sym]++
+	n := reentryCounts[sym]
+	mu.Unlock()
+
+	logTrade(fmt.Sprintf("RE-ENTRY %s %s @ %.2f past %.2f (%d today)", dir, sym, ltp, arm.Extreme, n))
+	if dir == models.Long {
+		enterLong(sym, arm.Source, ltp, strat.Leverage, 1)
+	} else {
+		enterShort(sym, arm.Source, ltp, strat.Leverage, 1)
+	}
+}
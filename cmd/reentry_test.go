@@ -0,0 +1,113 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+// exitLongAtTarget drives sym's long through its target and returns the
+// exit price, which is the position's best price.
+func exitLongAtTarget(t *testing.T, sym string) float64 {
+	t.Helper()
+	// The re-entries run inside one dedup window; real ones are minutes apart.
+	clear(recentOrders)
+	px := longPositions[sym].AvgEntry() * 1.03
+	checkLongExit(sym, px)
+	if hasPosition(sym, models.Long) {
+		t.Fatalf("%s long not exited at its target", sym)
+	}
+	if r := lastTrade(t).Reason; r != ReasonTarget {
+		t.Fatalf("exit reason = %s, want target", r)
+	}
+	return px
+}
+
+func TestReentryAfterTargetCountLimit(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", SL: 0.01, Target: 0.02, Leverage: 1, AllowReentry: true, MaxReentries: 2,
+	}
+	seedLong("TEST", 100, 10)
+
+	for i := 1; i <= 2; i++ {
+		high := exitLongAtTarget(t, "TEST")
+
+		checkReentry("TEST", high, getStrategy("TEST"))
+		if hasPosition("TEST", models.Long) {
+			t.Fatalf("re-entry %d fired without a new high", i)
+		}
+		checkReentry("TEST", high+0.5, getStrategy("TEST"))
+		if !hasPosition("TEST", models.Long) {
+			t.Fatalf("re-entry %d did not fire on a new high", i)
+		}
+		if tags := longPositions["TEST"].Tags; !slices.Equal(tags, []string{reentryTag}) {
+			t.Errorf("re-entry %d tags = %v, want one %q", i, tags, reentryTag)
+		}
+	}
+
+	high := exitLongAtTarget(t, "TEST")
+	checkReentry("TEST", high+1, getStrategy("TEST"))
+	if hasPosition("TEST", models.Long) {
+		t.Error("re-entered beyond MaxReentries")
+	}
+	if n := reentryCounts["TEST"]; n != 2 {
+		t.Errorf("re-entry count = %d, want 2", n)
+	}
+}
+
+func TestReentryWindow(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", SL: 0.01, Target: 0.02, Leverage: 1, AllowReentry: true, ReentryWindowMinutes: 2,
+	}
+	seedLong("TEST", 100, 10)
+	high := exitLongAtTarget(t, "TEST")
+
+	arm := reentryArms["TEST"]
+	if d := time.Until(arm.Until); d <= time.Minute || d > 2*time.Minute {
+		t.Errorf("window = %s, want the symbol's 2m", d)
+	}
+	arm.Until = time.Now().Add(-time.Second)
+	reentryArms["TEST"] = arm
+
+	checkReentry("TEST", high+1, getStrategy("TEST"))
+	if hasPosition("TEST", models.Long) {
+		t.Error("re-entered after the window closed")
+	}
+	if _, ok := reentryArms["TEST"]; ok {
+		t.Error("expired arm not dropped")
+	}
+}
+
+func TestReentryOnlyAfterTarget(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{
+		Class: "B", SL: 0.01, Target: 0.02, Leverage: 1, AllowShort: true, AllowReentry: true,
+	}
+
+	seedLong("TEST", 100, 10)
+	checkLongExit("TEST", 98)
+	if _, ok := reentryArms["TEST"]; ok {
+		t.Error("SL exit armed a re-entry")
+	}
+
+	// Shorts re-enter on a new low.
+	seedShort("TEST", 100, 10)
+	checkShortExit("TEST", 97)
+	checkReentry("TEST", 96.5, getStrategy("TEST"))
+	if !hasPosition("TEST", models.Short) {
+		t.Error("short did not re-enter on a new low")
+	}
+
+	// Without AllowReentry nothing arms.
+	resetBooks(t)
+	stockStrategies["TEST"] = models.StockStrategy{Class: "B", SL: 0.01, Target: 0.02, Leverage: 1}
+	seedLong("TEST", 100, 10)
+	exitLongAtTarget(t, "TEST")
+	if _, ok := reentryArms["TEST"]; ok {
+		t.Error("re-entry armed without allow_reentry")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/notify"
+)
+
+// placeEntry is placeOrder for entries. When the broker rejects the order
+// for its tick or lot size, the symbol's instrument details are looked up
+// again and the corrected order is sent once more; other rejections are
+// handed to reactToRejection. It returns the params actually sent, whose
+// Qty may have been rounded down to the refreshed lot size.
+func placeEntry(p client.OrderParams) (string, client.OrderParams, error) {
+	id, err := placeOrder(p)
+	var rej *client.OrderRejection
+	if !errors.As(err, &rej) {
+		return id, p, err
+	}
+	if !rej.Fixable() {
+		reactToRejection(p.Symbol, rej)
+		return "", p, err
+	}
+
+	fixed, ferr := refreshOrderScrip(p)
+	if ferr != nil {
+		return "", p, fmt.Errorf("%w (not retried: %v)", err, ferr)
+	}
+	logTrade(fmt.Sprintf("RETRY %s %s Qty:%d after %s rejection", fixed.Side, fixed.Symbol, fixed.Qty, rej.Reason))
+	id, err = sendOrder(fixed)
+	if errors.As(err, &rej) {
+		reactToRejection(p.Symbol, rej)
+	}
+	return id, fixed, err
+}
+
+// refreshOrderScrip re-reads sym's tick and lot size, from its symbol
+// override or SearchScrip, and returns p with its quantity rounded down
+// to whole lots. The price is re-rounded to the new tick by sendOrder.
+func refreshOrderScrip(p client.OrderParams) (client.OrderParams, error) {
+	sc, err := lookupScrip(context.Background(), nil, p.Symbol)
+	if err != nil {
+		return p, err
+	}
+
+	mu.Lock()
+	storeScrip(p.Symbol, sc)
+	lot := max(lotSizes[p.Symbol], 1)
+	mu.Unlock()
+
+	p.Token = sc.Token
+	p.Qty = p.Qty / lot * lot
+	if p.Qty < 1 {
+		return p, fmt.Errorf("quantity below one lot of %d", lot)
+	}
+	return p, nil
+}
+
+// reactToRejection adjusts trading after a rejection the bot cannot fix
+// itself: insufficient funds or a closed market pause new entries, and an
+// RMS block disables the symbol. Exits are never affected.
+func reactToRejection(sym string, rej *client.OrderRejection) {
+	switch rej.Reason {
+	case client.RejectInsufficientFunds, client.RejectMarketClosed:
+		mu.Lock()
+		paused := entriesPaused
+		entriesPaused = true
+		mu.Unlock()
+		if !paused {
+			notifyTrade(notify.EventError, fmt.Sprintf("ENTRIES PAUSED after %s rejection on %s: %s", rej.Reason, sym, rej.Message))
+		}
+	case client.RejectRMSBlock:
+		setSymbolEnabled(sym, false)
+		notifyTrade(notify.EventError, fmt.Sprintf("%s DISABLED after RMS rejection: %s", sym, rej.Message))
+	}
+}
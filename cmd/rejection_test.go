@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/client"
+)
+
+func TestReactToRejection(t *testing.T) {
+	resetBooks(t)
+
+	reactToRejection("ABC", &client.OrderRejection{Reason: client.RejectRMSBlock, Message: "RMS:Blocked"})
+	if !disabledSymbols["ABC"] || entriesPaused {
+		t.Fatalf("RMS block: disabled=%v paused=%v, want symbol disabled only", disabledSymbols["ABC"], entriesPaused)
+	}
+
+	reactToRejection("XYZ", &client.OrderRejection{Reason: client.RejectUnknown, Message: "?"})
+	if disabledSymbols["XYZ"] || entriesPaused {
+		t.Fatal("unknown rejection changed trading state")
+	}
+
+	reactToRejection("XYZ", &client.OrderRejection{Reason: client.RejectInsufficientFunds, Message: "Insufficient funds"})
+	if !entriesPaused {
+		t.Fatal("insufficient funds did not pause entries")
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	squareOffAt       = 15*60 + 10       // minutes past midnight IST
+	summaryAt         = 15*60 + 30       // daily summary and reset, minutes past midnight IST
+	brainRefreshEvery = 15 * time.Minute // 0 disables the periodic brain.py run
+)
+
+// job is a named task that runs once each time its trigger comes due.
+type job struct {
+	name string
+	next func(after time.Time) time.Time // first trigger strictly after after
+	run  func(now time.Time)
+	due  time.Time
+}
+
+// scheduler runs time-based jobs from the polling loop. Each trigger fires
+// its job exactly once, on the first tick at or after it, however many
+// ticks fall within the same minute. A tick that comes late (the machine
+// slept through several triggers) runs the job once and schedules the
+// next trigger after that tick rather than replaying the missed ones.
+// Jobs due on the same tick run in the order they were added.
+type scheduler struct {
+	loc  *time.Location
+	jobs []*job
+}
+
+func newScheduler(loc *time.Location) *scheduler {
+	return &scheduler{loc: loc}
+}
+
+// daily adds a job run every day at minute (past midnight in the
+// scheduler's zone), starting with the first such time after start.
+func (s *scheduler) daily(name string, minute int, start time.Time, run func(now time.Time)) {
+	next := func(after time.Time) time.Time {
+		t := after.In(s.loc)
+		at := time.Date(t.Year(), t.Month(), t.Day(), minute/60, minute%60, 0, 0, s.loc)
+		if !at.After(t) {
+			at = at.AddDate(0, 0, 1)
+		}
+		return at
+	}
+	s.add(&job{name: name, next: next, run: run}, start)
+}
+
+// every adds a job run every d, first at start+d.
+func (s *scheduler) every(name string, d time.Duration, start time.Time, run func(now time.Time)) {
+	next := func(after time.Time) time.Time { return after.Add(d) }
+	s.add(&job{name: name, next: next, run: run}, start)
+}
+
+func (s *scheduler) add(j *job, start time.Time) {
+	j.due = j.next(start)
+	s.jobs = append(s.jobs, j)
+}
+
+// tick runs every job whose trigger is at or before now.
+func (s *scheduler) tick(now time.Time) {
+	for _, j := range s.jobs {
+		if now.Before(j.due) {
+			continue
+		}
+		j.due = j.next(now)
+		j.run(now)
+	}
+}
+
+// String lists the jobs and their next triggers, for the startup banner.
+func (s *scheduler) String() string {
+	out := ""
+	for _, j := range s.jobs {
+		out += fmt.Sprintf("  %-14s next %s\n", j.name, j.due.In(s.loc).Format("2006-01-02 15:04"))
+	}
+	return out
+}
+
+// dailySchedule is the bot's once-a-day work: the daily summary and
+// reset, and the brain.py refresh. EOD square-off is not a job: it runs on
+// every tick after squareOffAt until the book is flat (see squareOffDue).
+func dailySchedule(start time.Time) *scheduler {
+	s := newScheduler(ist)
+	s.daily("daily-summary", summaryAt, start, func(time.Time) { printDailySummary() })
+	s.daily("daily-reset", summaryAt, start, func(time.Time) { resetDay() })
+	if brainRefreshEvery > 0 {
+		s.every("brain-refresh", brainRefreshEvery, start, func(time.Time) { runBrainAndReload() })
+	}
+	return s
+}
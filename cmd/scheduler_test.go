@@ -0,0 +1,100 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func istAt(day, hour, minute, sec int) time.Time {
+	return time.Date(2026, 3, day, hour, minute, sec, 0, ist)
+}
+
+func TestSchedulerDailyFiresOncePerTrigger(t *testing.T) {
+	s := newScheduler(ist)
+	var fired []time.Time
+	s.daily("square-off", 15*60+10, istAt(2, 9, 15, 0), func(now time.Time) { fired = append(fired, now) })
+
+	// Ticks every 20s from 15:09 to 15:12: only the first one at or after
+	// 15:10 fires, the rest of 15:10 and the minutes after it do not.
+	for tick := istAt(2, 15, 9, 0); tick.Before(istAt(2, 15, 12, 0)); tick = tick.Add(20 * time.Second) {
+		s.tick(tick)
+	}
+	if want := []time.Time{istAt(2, 15, 10, 0)}; !slices.Equal(fired, want) {
+		t.Fatalf("fired at %v, want once at 15:10", fired)
+	}
+
+	// Next day, a tick that lands mid-minute still fires once.
+	s.tick(istAt(3, 15, 9, 59))
+	s.tick(istAt(3, 15, 10, 7))
+	s.tick(istAt(3, 15, 10, 37))
+	if len(fired) != 2 || !fired[1].Equal(istAt(3, 15, 10, 7)) {
+		t.Errorf("fired at %v, want a second run at 15:10:07 the next day", fired)
+	}
+}
+
+func TestSchedulerDailyStartsAfterStart(t *testing.T) {
+	s := newScheduler(ist)
+	n := 0
+	s.daily("summary", 15*60+30, istAt(2, 15, 45, 0), func(time.Time) { n++ })
+
+	s.tick(istAt(2, 15, 50, 0))
+	if n != 0 {
+		t.Error("fired for a trigger that passed before the scheduler started")
+	}
+	s.tick(istAt(3, 15, 30, 0))
+	if n != 1 {
+		t.Errorf("fired %d times at the next day's trigger, want 1", n)
+	}
+}
+
+func TestSchedulerLateTickDoesNotReplay(t *testing.T) {
+	s := newScheduler(ist)
+	n := 0
+	s.daily("summary", 15*60+30, istAt(2, 9, 0, 0), func(time.Time) { n++ })
+	s.every("brain", 15*time.Minute, istAt(2, 9, 0, 0), func(time.Time) { n += 100 })
+
+	// Asleep for three days: each job runs once, not once per missed trigger.
+	s.tick(istAt(5, 10, 0, 0))
+	if n != 101 {
+		t.Errorf("n = %d after a late tick, want each job once (101)", n)
+	}
+	s.tick(istAt(5, 10, 14, 0))
+	if n != 101 {
+		t.Errorf("interval job ran again %s after a late run", 14*time.Minute)
+	}
+	s.tick(istAt(5, 10, 15, 0))
+	if n != 201 {
+		t.Errorf("interval job did not run 15m after its late run (n = %d)", n)
+	}
+}
+
+func TestSchedulerRunsSameTickJobsInOrder(t *testing.T) {
+	s := newScheduler(ist)
+	var order []string
+	start := istAt(2, 9, 0, 0)
+	s.daily("summary", 15*60+30, start, func(time.Time) { order = append(order, "summary") })
+	s.daily("reset", 15*60+30, start, func(time.Time) { order = append(order, "reset") })
+
+	s.tick(istAt(2, 15, 30, 5))
+	s.tick(istAt(2, 15, 30, 35))
+	if want := []string{"summary", "reset"}; !slices.Equal(order, want) {
+		t.Errorf("ran %v, want %v", order, want)
+	}
+}
+
+func TestSchedulerUsesItsZone(t *testing.T) {
+	s := newScheduler(ist)
+	n := 0
+	s.daily("square-off", 15*60+10, istAt(2, 9, 0, 0), func(time.Time) { n++ })
+
+	// 09:39 UTC is 15:09 IST; 09:40 UTC is 15:10 IST.
+	s.tick(time.Date(2026, 3, 2, 9, 39, 0, 0, time.UTC))
+	if n != 0 {
+		t.Fatal("fired at 15:09 IST")
+	}
+	s.tick(time.Date(2026, 3, 2, 9, 40, 0, 0, time.UTC))
+	if n != 1 {
+		t.Error("did not fire at 15:10 IST given a UTC tick")
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/config"
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/notify"
+	"github.com/may-bach/Axiom/internal/stocks"
+)
+
+// strategyFieldDocs describes each config.json field by its JSON key.
+// TestStrategyFieldDocs fails when a StockStrategy field is missing here.
+var strategyFieldDocs = map[string]string{
+	"class":                  "liquidity class (A, B or C); picks the paper slippage and poll interval",
+	"allow_short":            "whether short entries are taken",
+	"breakout_long":          "fraction above the session high that triggers a breakout buy",
+	"breakout_short":         "fraction below the session low that triggers a breakdown short",
+	"bounce_rebound":         "rebound off the low, as a fraction, for a bounce-back buy",
+	"quick_drop":             "single-tick fall, as a fraction, for a quick-drop short",
+	"target":                 "profit target as a fraction of the entry",
+	"sl":                     "fixed stop-loss as a fraction of the entry",
+	"leverage":               "budget multiplier; above 1 trades MIS; capped by -max-leverage",
+	"sector":                 "sector name for the per-sector position caps",
+	"product":                "order product, C (CNC) or I (MIS); derived from leverage when empty",
+	"trail_activate":         "profit fraction before the trailing stop arms",
+	"trail_percent":          "trailing distance from the best price, as a fraction",
+	"entry_limit_offset":     "enter with a limit this fraction better than the signal price instead of at market",
+	"confirm_ticks":          "consecutive ticks an entry condition must hold before it fires",
+	"break_even_trigger":     "profit fraction at which the stop moves to entry",
+	"break_even_buffer":      "fraction past entry the break-even stop sits at",
+	"max_hold_minutes":       "exit a position held this long without SL or target",
+	"entry_validity":         "entry order validity, DAY or IOC",
+	"min_price":              "lowest LTP at which entries are taken",
+	"max_price":              "highest LTP at which entries are taken; 0 has no limit",
+	"gap_up":                 "opening gap above the previous close, as a fraction, for a gap-up buy; 0 disables",
+	"gap_down":               "opening gap below the previous close, as a fraction, for a gap-down short; 0 disables",
+	"gap_window_minutes":     "minutes after the open in which the gap strategies fire (default 15)",
+	"max_notional":           "cap on the symbol's open qty * price across both directions; 0 has no limit",
+	"under_budget":           "when the budget buys less than one lot: skip, min_qty or stretch",
+	"min_qty":                "quantity entered under the min_qty policy",
+	"max_budget":             "most the stretch policy may spend on one lot",
+	"exit_price_type":        "exit orders at market or protected (a limit a few ticks through the ltp)",
+	"exit_protection_ticks":  "ticks between the ltp and a protected exit's limit",
+	"direction_bias":         "the day's view: long, short, both or none",
+	"max_day_change_long":    "skip longs once up more than this fraction on the previous close; 0 has no limit",
+	"max_day_change_short":   "skip shorts once down more than this fraction on the previous close; 0 has no limit",
+	"max_vwap_extension":     "skip entries once this fraction beyond the VWAP in their direction; 0 has no limit",
+	"max_open_extension":     "skip entries once this fraction beyond the day's open in their direction; 0 has no limit",
+	"allow_reentry":          "re-enter when price makes a new extreme soon after a target exit",
+	"max_reentries":          "re-entries a day (at least 1 when allow_reentry is set)",
+	"reentry_window_minutes": "how long after a target exit a re-entry may fire",
+	"entry_windows":          "HH:MM-HH:MM (IST) ranges entries are limited to; empty allows the whole session",
+	"max_consecutive_losses": "bench the symbol for the day after this many losing trades in a row; 0 has no limit",
+	"partial_fill":           "rest of a partially filled entry: accept (keep it working), cancel or chase (re-send at market)",
+}
+
+// fractionFields are config.json fields given as fractions; a value of 1
+// or more was almost certainly written as a percentage.
+var fractionFields = []string{
+	"breakout_long", "breakout_short", "bounce_rebound", "quick_drop", "target", "sl",
+	"trail_activate", "trail_percent", "entry_limit_offset", "break_even_trigger", "break_even_buffer",
+	"gap_up", "gap_down", "max_day_change_long", "max_day_change_short",
+	"max_vwap_extension", "max_open_extension",
+}
+
+// runConfig implements the `config schema` and `config validate`
+// subcommands. Both accept the bot's own flags, so defaults and paths
+// match what a run with the same flags would use.
+func runConfig(args []string) error {
+	if len(args) == 0 || (args[0] != "schema" && args[0] != "validate") {
+		return fmt.Errorf("usage: config schema|validate [flags]")
+	}
+	registerFlags()
+	if err := flag.CommandLine.Parse(args[1:]); err != nil {
+		return err
+	}
+	resolveDataFiles(flag.CommandLine)
+
+	if args[0] == "schema" {
+		printSchema(os.Stdout)
+		return nil
+	}
+	problems := validateConfig()
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems", len(problems))
+	}
+	fmt.Println("Configuration OK")
+	return nil
+}
+
+// schemaField is one StockStrategy field as seen in config.json.
+type schemaField struct {
+	Key   string
+	Type  string
+	Value reflect.Value
+}
+
+// strategyFields lists StockStrategy's fields by JSON key, with their
+// values in strat.
+func strategyFields(strat models.StockStrategy) []schemaField {
+	v := reflect.ValueOf(strat)
+	var fields []schemaField
+	for i := range v.NumField() {
+		f := v.Type().Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		typ := f.Type.Kind().String()
+		switch f.Type.Kind() {
+		case reflect.Float64:
+			typ = "number"
+		case reflect.Int:
+			typ = "integer"
+		}
+		fields = append(fields, schemaField{Key: key, Type: typ, Value: v.Field(i)})
+	}
+	return fields
+}
+
+// printSchema writes every config.json field, bot flag and environment
+// key with its type, default and description.
+func printSchema(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "config.json (%s): {\"SYMBOL\": {field: value, ...}, ...}\n", brainConfigPath)
+	fmt.Fprintln(tw, "FIELD\tTYPE\tDEFAULT\tDESCRIPTION")
+	// Symbols missing from config.json use the default strategy.
+	for _, f := range strategyFields(getStrategy("")) {
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%s\n", f.Key, f.Type, f.Value, strategyFieldDocs[f.Key])
+	}
+
+	fmt.Fprintln(tw, "\nFLAG\tTYPE\tDEFAULT\tDESCRIPTION")
+	flag.VisitAll(func(f *flag.Flag) {
+		typ, usage := flag.UnquoteUsage(f)
+		if typ == "" {
+			typ = "bool"
+		}
+		fmt.Fprintf(tw, "-%s\t%s\t%s\t%s\n", f.Name, typ, f.DefValue, usage)
+	})
+
+	fmt.Fprintln(tw, "\nENVIRONMENT\tREQUIRED\t\tDESCRIPTION")
+	for _, s := range config.Settings(accountName) {
+		fmt.Fprintf(tw, "%s\t%v\t\t%s\n", s.Key, s.Required, s.Description)
+	}
+	tw.Flush()
+}
+
+// validateConfig loads the credentials, watchlist, strategy config and
+// symbol overrides the bot would use and describes every problem found.
+func validateConfig() []string {
+	var problems []string
+	report := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if err := config.Load(secretsPath); err != nil {
+		report("env: %v", err)
+	} else {
+		if _, err := config.Account(accountName); err != nil {
+			report("env: %v", err)
+		}
+		known := []string{string(notify.EventEntry), string(notify.EventExit), string(notify.EventError), string(notify.EventSummary)}
+		for _, e := range config.C.WebhookEvents {
+			if !slices.Contains(known, e) {
+				report("env: AXIOM_WEBHOOK_EVENTS: unknown event %q", e)
+			}
+		}
+	}
+
+	watched := true
+	if err := stocks.Load(stocksPath); err != nil {
+		report("%s: %v", stocksPath, err)
+		watched = false
+	}
+
+	if data, err := os.ReadFile(brainConfigPath); err != nil {
+		report("%s: %v", brainConfigPath, err)
+	} else {
+		for _, p := range validateStrategies(data) {
+			report("%s: %s", brainConfigPath, p)
+		}
+		if watched {
+			var configs map[string]json.RawMessage
+			json.Unmarshal(data, &configs)
+			for _, sym := range slices.Sorted(maps.Keys(configs)) {
+				if !slices.Contains(stocks.Tickers, sym) {
+					report("%s: %s is not in %s and will be ignored", brainConfigPath, sym, stocksPath)
+				}
+			}
+		}
+	}
+
+	if _, err := loadSymbolOverrides(symbolOverridesPath); err != nil {
+		report("%s: %v", symbolOverridesPath, err)
+	}
+	return problems
+}
+
+// validateStrategies checks a config.json body: unknown fields, negative
+// numbers, fractions that look like percentages, and values outside each
+// enumerated field's choices.
+func validateStrategies(data []byte) []string {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return []string{fmt.Sprintf("invalid JSON: %v", err)}
+	}
+
+	var problems []string
+	for _, sym := range slices.Sorted(maps.Keys(raw)) {
+		report := func(format string, args ...any) {
+			problems = append(problems, sym+": "+fmt.Sprintf(format, args...))
+		}
+
+		var strat models.StockStrategy
+		dec := json.NewDecoder(bytes.NewReader(raw[sym]))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&strat); err != nil {
+			report("%v", err)
+			continue
+		}
+
+		for _, f := range strategyFields(strat) {
+			var n float64
+			switch f.Value.Kind() {
+			case reflect.Float64:
+				n = f.Value.Float()
+			case reflect.Int:
+				n = float64(f.Value.Int())
+			default:
+				continue
+			}
+			if n < 0 {
+				report("%s is negative (%v)", f.Key, n)
+			}
+			if n >= 1 && slices.Contains(fractionFields, f.Key) {
+				report("%s is %v; it is a fraction, so 0.02 means 2%%", f.Key, n)
+			}
+		}
+
+		for _, e := range []struct {
+			key, value string
+			allowed    []string
+		}{
+			{"product", strat.Product, []string{client.ProductCNC, client.ProductMIS}},
+			{"entry_validity", strat.EntryValidity, []string{client.ValidityDay, client.ValidityIOC}},
+			{"under_budget", strat.UnderBudget, []string{models.UnderBudgetSkip, models.UnderBudgetMinQty, models.UnderBudgetStretch}},
+			{"exit_price_type", strat.ExitPriceType, []string{exitMarket, exitProtected}},
+			{"direction_bias", strat.DirectionBias, []string{models.BiasLong, models.BiasShort, models.BiasBoth, models.BiasNone}},
+			{"partial_fill", strat.PartialFill, []string{partialAccept, partialCancel, partialChase}},
+		} {
+			if e.value != "" && !slices.Contains(e.allowed, e.value) {
+				report("%s %q is not one of %s", e.key, e.value, strings.Join(e.allowed, ", "))
+			}
+		}
+
+		if _, err := parseWindows(strat.EntryWindows); err != nil {
+			report("entry_windows: %v", err)
+		}
+		if strat.MaxPrice > 0 && strat.MaxPrice < strat.MinPrice {
+			report("max_price %v is below min_price %v", strat.MaxPrice, strat.MinPrice)
+		}
+		if maxLeverage > 0 && strat.Leverage > maxLeverage {
+			report("leverage %v is above -max-leverage %v and will be capped", strat.Leverage, maxLeverage)
+		}
+	}
+	return problems
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+func TestSchemaListsEveryStrategyField(t *testing.T) {
+	resetBooks(t)
+	var out bytes.Buffer
+	printSchema(&out)
+
+	typ := reflect.TypeFor[models.StockStrategy]()
+	for i := range typ.NumField() {
+		key, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if strategyFieldDocs[key] == "" {
+			t.Errorf("%s (%s) has no description", typ.Field(i).Name, key)
+		}
+		if !strings.Contains(out.String(), "\n"+key+" ") {
+			t.Errorf("schema does not list %s", key)
+		}
+	}
+	// The defaults are the effective ones, not the zero values.
+	if !strings.Contains(out.String(), "\nsl ") || !strings.Contains(out.String(), "0.01") {
+		t.Errorf("schema does not show the default sl:\n%s", out.String())
+	}
+}
+
+func TestValidateStrategies(t *testing.T) {
+	good := `{"TEST": {"class": "A", "sl": 0.01, "target": 0.02, "product": "I", "direction_bias": "long"}}`
+	if p := validateStrategies([]byte(good)); len(p) != 0 {
+		t.Errorf("clean config reported %v", p)
+	}
+
+	bad := `{
+		"AAA": {"sl": 1.5},
+		"BBB": {"stop_loss": 0.01},
+		"CCC": {"exit_price_type": "limit", "max_reentries": -1},
+		"DDD": {"min_price": 500, "max_price": 100}
+	}`
+	p := validateStrategies([]byte(bad))
+	for _, want := range []string{
+		"AAA: sl is 1.5",
+		`BBB: json: unknown field "stop_loss"`,
+		`CCC: exit_price_type "limit"`,
+		"CCC: max_reentries is negative",
+		"DDD: max_price 100 is below min_price 500",
+	} {
+		found := false
+		for _, got := range p {
+			found = found || strings.HasPrefix(got, want)
+		}
+		if !found {
+			t.Errorf("missing %q in %q", want, p)
+		}
+	}
+
+	if p := validateStrategies([]byte(`{"TEST": `)); len(p) != 1 || !strings.HasPrefix(p[0], "invalid JSON") {
+		t.Errorf("truncated config reported %v", p)
+	}
+}
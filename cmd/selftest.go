@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"time"
+)
+
+var (
+	selfTestSample     = 5   // mapped symbols whose LTP is fetched at startup; 0 skips the self-test
+	selfTestMinRatio   = 0.6 // fraction of the sample that must answer
+	selfTestRetries    = 2   // extra attempts per symbol before it counts as failed
+	selfTestRetryDelay = time.Second
+	selfTestWarnOnly   = false // warn instead of aborting startup when the self-test fails
+)
+
+// startupSelfTest fetches the LTP of up to selfTestSample symbols from
+// tokens, retrying each up to selfTestRetries times, and returns an error
+// when fewer than selfTestMinRatio of them answer. The sample is the
+// alphabetically first symbols so repeated starts test the same ones.
+func startupSelfTest(ctx context.Context, tokens map[string]string) error {
+	if selfTestSample <= 0 || len(tokens) == 0 {
+		return nil
+	}
+	syms := slices.Sorted(maps.Keys(tokens))
+	syms = syms[:min(selfTestSample, len(syms))]
+
+	ok := 0
+	for _, sym := range syms {
+		ltp, err := selfTestLTP(ctx, tokens[sym])
+		if err != nil {
+			log.Printf("Self-test LTP for %s failed: %v", sym, err)
+			continue
+		}
+		fmt.Printf("Self-test LTP for %s OK: %.2f\n", sym, ltp)
+		ok++
+	}
+
+	ratio := float64(ok) / float64(len(syms))
+	fmt.Printf("Self-test: %d/%d symbols answered\n", ok, len(syms))
+	if ratio < selfTestMinRatio {
+		return fmt.Errorf("only %d/%d symbols answered, need %.0f%%", ok, len(syms), selfTestMinRatio*100)
+	}
+	return nil
+}
+
+func selfTestLTP(ctx context.Context, token string) (float64, error) {
+	var err error
+	for attempt := 0; attempt <= selfTestRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			case <-time.After(selfTestRetryDelay):
+			}
+		}
+		var ltp float64
+		if ltp, err = quotes.GetLTP(ctx, "NSE", token); err == nil {
+			return ltp, nil
+		}
+	}
+	return 0, err
+}
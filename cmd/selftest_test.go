@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/client"
+)
+
+// flakyQuotes fails each token's first fails[token] requests.
+type flakyQuotes struct {
+	fails map[string]int
+	calls map[string]int
+}
+
+func (q *flakyQuotes) GetQuote(ctx context.Context, exch, token string) (client.Quote, error) {
+	q.calls[token]++
+	if q.calls[token] <= q.fails[token] {
+		return client.Quote{}, errors.New("session expired")
+	}
+	return client.Quote{LTP: 100}, nil
+}
+
+func (q *flakyQuotes) GetLTP(ctx context.Context, exch, token string) (float64, error) {
+	quote, err := q.GetQuote(ctx, exch, token)
+	return quote.LTP, err
+}
+
+func TestStartupSelfTest(t *testing.T) {
+	oldQuotes, oldDelay := quotes, selfTestRetryDelay
+	oldSample, oldRatio, oldRetries := selfTestSample, selfTestMinRatio, selfTestRetries
+	t.Cleanup(func() {
+		quotes, selfTestRetryDelay = oldQuotes, oldDelay
+		selfTestSample, selfTestMinRatio, selfTestRetries = oldSample, oldRatio, oldRetries
+	})
+	selfTestRetryDelay, selfTestSample, selfTestMinRatio, selfTestRetries = 0, 3, 0.6, 1
+	tokens := map[string]string{"A": "1", "B": "2", "C": "3", "D": "4"}
+
+	// B recovers on its retry, C never answers, D is outside the sample.
+	q := &flakyQuotes{fails: map[string]int{"2": 1, "3": 99, "4": 99}, calls: map[string]int{}}
+	quotes = q
+	if err := startupSelfTest(context.Background(), tokens); err != nil {
+		t.Errorf("2/3 answering failed the self-test: %v", err)
+	}
+	if q.calls["3"] != 2 || q.calls["4"] != 0 {
+		t.Errorf("calls = %v, want C tried twice and D untouched", q.calls)
+	}
+
+	quotes = &flakyQuotes{fails: map[string]int{"1": 99, "2": 99}, calls: map[string]int{}}
+	if err := startupSelfTest(context.Background(), tokens); err == nil {
+		t.Error("1/3 answering passed the self-test")
+	}
+
+	selfTestSample = 0
+	if err := startupSelfTest(context.Background(), tokens); err != nil {
+		t.Errorf("disabled self-test failed: %v", err)
+	}
+}
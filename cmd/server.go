@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+)
+
+type positionStatus struct {
+	EntryPrice float64   `json:"entry_price"`
+	Qty        int       `json:"qty"`
+	EntryTime  time.Time `json:"entry_time"`
+	Strategy   string    `json:"strategy,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+}
+
+func newPositionStatus(pos position) positionStatus {
+	return positionStatus{pos.AvgEntry(), pos.TotalQty, pos.EntryTime, pos.Strategy, pos.Tags}
+}
+
+type statusResponse struct {
+	Mode     string                    `json:"mode"`
+	Time     time.Time                 `json:"time"`
+	Trades   int                       `json:"trades"`
+	DailyPnL float64                   `json:"daily_pnl"`
+	Longs    map[string]positionStatus `json:"longs"`
+	Shorts   map[string]positionStatus `json:"shorts"`
+	Disabled []string                  `json:"disabled"`
+	Benched  []string                  `json:"benched,omitempty"` // out of entries for the day after a losing streak
+	Paused   bool                      `json:"paused"`
+	Shadow   map[string]int            `json:"shadow"`  // signals recorded per shadow strategy
+	Circuit  string                    `json:"circuit"` // API circuit breaker: closed, open or half-open
+	Latency  latencyStatus             `json:"latency"`
+
+	// Tagged lists today's closed trades carrying the ?tag= query
+	// parameter; Longs and Shorts are then limited to that tag too.
+	Tagged []TradeRecord `json:"tagged,omitempty"`
+
+	Portfolio // realized and unrealized P&L
+}
+
+// latencyStatus is the API's recent request latency.
+type latencyStatus struct {
+	P50MS    int64 `json:"p50_ms"`
+	P95MS    int64 `json:"p95_ms"`
+	Samples  int   `json:"samples"`
+	Degraded bool  `json:"degraded"` // polling slowed by -max-latency
+}
+
+func newLatencyStatus() latencyStatus {
+	s := apiLatency()
+	return latencyStatus{s.P50.Milliseconds(), s.P95.Milliseconds(), s.Samples, latencyDegraded.Load()}
+}
+
+// startStatusServer serves a JSON view of the bot on /status, a liveness
+// check on /health and Prometheus-style gauges on /metrics, plus POST
+// /symbols/{sym}/enable and /disable to toggle entries for a symbol and
+// the /control commands (both require the control token).
+func startStatusServer(port int) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", handleStatus)
+	mux.HandleFunc("/health", handleHealth)
+	mux.HandleFunc("/metrics", handleMetrics)
+	mux.HandleFunc("POST /symbols/{sym}/enable", requireControlToken(handleSymbolToggle(true)))
+	mux.HandleFunc("POST /symbols/{sym}/disable", requireControlToken(handleSymbolToggle(false)))
+	registerControlHandlers(mux)
+
+	addr := fmt.Sprintf(":%d", port)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("Status server stopped: %v", err)
+		}
+	}()
+	fmt.Printf("Status server listening on %s\n", addr)
+}
+
+func handleStatus(w http.ResponseWriter, r *http.Request) {
+	resp := statusResponse{
+		Mode:   "paper",
+		Time:   time.Now(),
+		Longs:  make(map[string]positionStatus),
+		Shorts: make(map[string]positionStatus),
+	}
+	if !paperTrading {
+		resp.Mode = "live"
+	}
+	resp.Portfolio = PortfolioSnapshot()
+	resp.Latency = newLatencyStatus()
+
+	mu.Lock()
+	resp.Trades = len(tradeHistory)
+	resp.DailyPnL = dailyPnL
+	resp.Paused = entriesPaused
+	resp.Shadow = maps.Clone(shadowCounts)
+	resp.Circuit = client.Circuit().String()
+	tag := r.URL.Query().Get("tag")
+	for sym, pos := range longPositions {
+		if tag == "" || slices.Contains(pos.Tags, tag) {
+			resp.Longs[sym] = newPositionStatus(pos)
+		}
+	}
+	for sym, pos := range shortPositions {
+		if tag == "" || slices.Contains(pos.Tags, tag) {
+			resp.Shorts[sym] = newPositionStatus(pos)
+		}
+	}
+	if tag != "" {
+		resp.Tagged = slices.Clone(filterTag(tradeHistory, tag))
+	}
+	resp.Disabled = make([]string, 0, len(disabledSymbols))
+	for sym := range disabledSymbols {
+		resp.Disabled = append(resp.Disabled, sym)
+	}
+	resp.Benched = slices.Sorted(maps.Keys(benched))
+	mu.Unlock()
+	sort.Strings(resp.Disabled)
+
+	writeFormatted(w, r, resp)
+}
+
+// writeFormatted writes v as JSON, or as YAML when ?format=yaml is given
+// or -output is yaml; ?format=json forces JSON.
+func writeFormatted(w http.ResponseWriter, r *http.Request, v any) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = outputFormat
+	}
+	if format != outputYAML {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+		return
+	}
+
+	out, err := encodeOutput(v, outputYAML)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(out)
+}
+
+// handleMetrics writes API latency and the book's size and P&L in the
+// Prometheus text format.
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	lat := apiLatency()
+	degraded := 0
+	if latencyDegraded.Load() {
+		degraded = 1
+	}
+	mu.Lock()
+	open := len(longPositions) + len(shortPositions)
+	pnl := dailyPnL
+	mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintln(w, "# HELP axiom_api_latency_seconds Recent API request latency.")
+	fmt.Fprintln(w, "# TYPE axiom_api_latency_seconds gauge")
+	fmt.Fprintf(w, "axiom_api_latency_seconds{quantile=\"0.5\"} %g\n", lat.P50.Seconds())
+	fmt.Fprintf(w, "axiom_api_latency_seconds{quantile=\"0.95\"} %g\n", lat.P95.Seconds())
+	fmt.Fprintln(w, "# HELP axiom_api_latency_samples Requests the latency quantiles are taken over.")
+	fmt.Fprintln(w, "# TYPE axiom_api_latency_samples gauge")
+	fmt.Fprintf(w, "axiom_api_latency_samples %d\n", lat.Samples)
+	fmt.Fprintln(w, "# HELP axiom_api_degraded 1 while polling is slowed by -max-latency.")
+	fmt.Fprintln(w, "# TYPE axiom_api_degraded gauge")
+	fmt.Fprintf(w, "axiom_api_degraded %d\n", degraded)
+	fmt.Fprintln(w, "# HELP axiom_open_positions Open positions across both directions.")
+	fmt.Fprintln(w, "# TYPE axiom_open_positions gauge")
+	fmt.Fprintf(w, "axiom_open_positions %d\n", open)
+	fmt.Fprintln(w, "# HELP axiom_daily_pnl Realized P&L today.")
+	fmt.Fprintln(w, "# TYPE axiom_daily_pnl gauge")
+	fmt.Fprintf(w, "axiom_daily_pnl %g\n", pnl)
+}
+
+// handleHealth answers 200 while the loop is ticking and 503 once it has
+// missed its heartbeat for longer than the stall limit.
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	since := sinceHeartbeat()
+	status := http.StatusOK
+	if since > stallLimit() {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"ok":             status == http.StatusOK,
+		"last_tick_secs": since.Round(time.Second).Seconds(),
+	})
+}
+
+func handleSymbolToggle(enabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sym := strings.ToUpper(r.PathValue("sym"))
+		mu.Lock()
+		_, known := symbolToToken[sym]
+		mu.Unlock()
+		if !known {
+			http.Error(w, fmt.Sprintf("unknown symbol %s", sym), http.StatusNotFound)
+			return
+		}
+
+		setSymbolEnabled(sym, enabled)
+		state := "enabled"
+		if !enabled {
+			state = "disabled"
+		}
+		logTrade(fmt.Sprintf("%s %s for new entries via status server", sym, state))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"symbol": sym, "enabled": enabled})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+	"github.com/may-bach/Axiom/internal/strategy"
+)
+
+// registeredStrategy is an entry strategy in the live loop. Shadow
+// strategies see the same ticks but their signals are only recorded to
+// shadow.jsonl in logDir, never ordered.
+type registeredStrategy struct {
+	strategy.Strategy
+	Shadow bool
+}
+
+// shadowSignal is one line of shadow.jsonl.
+type shadowSignal struct {
+	Time      time.Time        `json:"time"`
+	Strategy  string           `json:"strategy"`
+	Symbol    string           `json:"symbol"`
+	Direction models.Direction `json:"direction"`
+	Price     float64          `json:"price"`
+	Reason    string           `json:"reason"`
+}
+
+var (
+	shadowCounts = make(map[string]int)       // signals recorded per shadow strategy
+	shadowLast   = make(map[string]time.Time) // last signal by strategy|symbol|direction
+)
+
+// setShadow marks the named strategies, comma-separated, as shadow-only.
+func setShadow(names string) error {
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for i := range entryStrategies {
+			if entryStrategies[i].Name() == name {
+				entryStrategies[i].Shadow = true
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown strategy %q", name)
+		}
+	}
+	return nil
+}
+
+// evaluateShadow runs the shadow strategies for one tick and records any
+// confirmed signal. A repeat of the same signal within dedupWindow is
+// dropped so a held condition is counted once.
+func evaluateShadow(ms *state.MarketState, strat models.StockStrategy) {
+	for _, s := range entryStrategies {
+		if !s.Shadow {
+			continue
+		}
+		if (s.Direction() == models.Short && !strat.AllowShort) || !strat.Allows(s.Direction()) {
+			continue
+		}
+
+		sig, ok := s.Evaluate(ms, strat)
+		if !confirmed(ms.Symbol, "shadow:"+s.Name(), ok, strat.ConfirmTicks) {
+			continue
+		}
+
+		now := time.Now()
+		key := s.Name() + "|" + ms.Symbol + "|" + string(sig.Direction)
+		mu.Lock()
+		if now.Sub(shadowLast[key]) < dedupWindow {
+			mu.Unlock()
+			continue
+		}
+		shadowLast[key] = now
+		shadowCounts[s.Name()]++
+		mu.Unlock()
+
+		fmt.Printf("[SHADOW] %s\n", sig.Reason)
+		recordShadowSignal(shadowSignal{
+			Time: now, Strategy: s.Name(), Symbol: ms.Symbol,
+			Direction: sig.Direction, Price: sig.Price, Reason: sig.Reason,
+		})
+	}
+}
+
+func recordShadowSignal(sig shadowSignal) {
+	line, err := json.Marshal(sig)
+	if err != nil {
+		log.Printf("Shadow signal encode failed: %v", err)
+		return
+	}
+
+	f, err := os.OpenFile(filepath.Join(logDir, "shadow.jsonl"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("Shadow log open failed: %v", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+func TestEntryQtyRoundsToLots(t *testing.T) {
+	resetBooks(t)
+	t.Cleanup(func() { lotSizes = make(map[string]int) })
+	lotSizes["NIFTYFUT"] = 75
+	lotSizes["STOCK"] = 1
+
+	tests := []struct {
+		name   string
+		sym    string
+		budget float64
+		ltp    float64
+		want   int
+	}{
+		{"equity any integer", "STOCK", 10000, 333, 30},
+		{"unknown lot size is 1", "OTHER", 10000, 333, 30},
+		{"partial lot rounds down", "NIFTYFUT", 100000, 500, 150}, // 200 → 2 lots
+		{"exactly one lot", "NIFTYFUT", 37500, 500, 75},
+		{"just under one lot", "NIFTYFUT", 37499, 500, 0},
+		{"well under one lot", "NIFTYFUT", 10000, 500, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entryQty(tt.sym, tt.budget, tt.ltp); got != tt.want {
+				t.Errorf("entryQty = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// limitsBroker is a Broker that only answers GetLimits.
+type limitsBroker struct {
+	client.Broker
+	limits client.Limits
+	err    error
+}
+
+func (b limitsBroker) GetLimits(context.Context) (client.Limits, error) {
+	return b.limits, b.err
+}
+
+func TestMarginAvailable(t *testing.T) {
+	resetBooks(t)
+	paperTrading = false
+	oldBroker := broker
+	t.Cleanup(func() { broker = oldBroker })
+
+	broker = limitsBroker{limits: client.Limits{Cash: 50000, MarginUsed: 20000}}
+	if !marginAvailable("ABC", 100000, 4) { // needs 25000 of 30000
+		t.Error("entry within margin was refused")
+	}
+	if marginAvailable("ABC", 200000, 4) { // needs 50000
+		t.Error("entry beyond margin was allowed")
+	}
+	if !marginAvailable("ABC", 1e9, 1) {
+		t.Error("unleveraged entry was checked")
+	}
+
+	broker = limitsBroker{err: errors.New("timeout")}
+	if marginAvailable("ABC", 1000, 2) {
+		t.Error("entry allowed after the limits request failed")
+	}
+}
+
+func TestGetStrategyCapsLeverage(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["ABC"] = models.StockStrategy{Leverage: 10}
+	if got := getStrategy("ABC").Leverage; got != maxLeverage {
+		t.Errorf("leverage = %v, want capped at %v", got, maxLeverage)
+	}
+}
+
+func TestStrengthScale(t *testing.T) {
+	defer func(m float64) { maxStrengthScale = m }(maxStrengthScale)
+
+	maxStrengthScale = 1
+	if got := strengthScale(3); got != 1 {
+		t.Errorf("disabled scale = %v, want 1", got)
+	}
+	maxStrengthScale = 2
+	for strength, want := range map[float64]float64{0: 1, 0.5: 1, 1.5: 1.5, 5: 2} {
+		if got := strengthScale(strength); got != want {
+			t.Errorf("strengthScale(%v) = %v, want %v", strength, got, want)
+		}
+	}
+}
+
+func TestShortGuard(t *testing.T) {
+	resetBooks(t)
+	stockStrategies["ABC"] = models.StockStrategy{Class: "B", Product: client.ProductCNC, Leverage: 1}
+	stockStrategies["XYZ"] = models.StockStrategy{Class: "B", Leverage: 1, Target: 0.02}
+
+	if got := productFor("ABC", models.Short); got != client.ProductMIS {
+		t.Errorf("short product = %q, want MIS even when CNC is configured", got)
+	}
+	if got := productFor("ABC", models.Long); got != client.ProductCNC {
+		t.Errorf("long product = %q, want the configured CNC", got)
+	}
+
+	setShortable([]string{"XYZ"})
+	enterShort("ABC", entrySource{}, 100, 1, 0)
+	if hasPosition("ABC", models.Short) {
+		t.Error("shorted a symbol missing from the shortable list")
+	}
+	enterShort("XYZ", entrySource{}, 100, 1, 0)
+	if !hasPosition("XYZ", models.Short) {
+		t.Error("shortable symbol was not shorted")
+	}
+}
+
+func TestCapNotional(t *testing.T) {
+	resetBooks(t)
+	defer func(s, tot float64) { maxSymbolNotional, maxTotalNotional = s, tot }(maxSymbolNotional, maxTotalNotional)
+	t.Cleanup(func() { lotSizes = make(map[string]int) })
+
+	maxSymbolNotional, maxTotalNotional = 10000, 0
+	longPositions["ABC"] = position{TotalCost: 4000, TotalQty: 40}
+	if got := capNotional("ABC", 100, 100); got != 60 {
+		t.Errorf("symbol cap qty = %d, want 60", got)
+	}
+	if got := capNotional("ABC", 50, 100); got != 50 {
+		t.Errorf("qty within the cap = %d, want 50 untouched", got)
+	}
+
+	stockStrategies["ABC"] = models.StockStrategy{MaxNotional: 4000}
+	if got := capNotional("ABC", 10, 100); got != 0 {
+		t.Errorf("override cap qty = %d, want 0 once the symbol is full", got)
+	}
+
+	maxSymbolNotional, maxTotalNotional = 0, 6000
+	pendingEntries["1"] = pendingOrder{Symbol: "XYZ", Qty: 10, Limit: 50}
+	lotSizes["XYZ"] = 40
+	if got := capNotional("XYZ", 200, 10); got != 120 { // 6000 - 4000 - 500 leaves 150, rounded down to lots of 40
+		t.Errorf("portfolio cap qty = %d, want 120", got)
+	}
+}
+
+func TestTargetClearsCosts(t *testing.T) {
+	resetBooks(t)
+	defer func(c costModel, m float64) { costs, minNetProfit = c, m }(costs, minNetProfit)
+	costs.BrokeragePerOrder = 20
+	minNetProfit = 0
+
+	// 10 shares at 100: a 0.1% target makes 1, under the 40+ of brokerage.
+	stockStrategies["ABC"] = models.StockStrategy{Target: 0.001, Leverage: 1}
+	if targetClearsCosts("ABC", models.Long, 100, 10) {
+		t.Error("scalp that cannot cover brokerage was allowed")
+	}
+	need := minTarget("ABC", models.Long, 100, 10)
+	if need < 0.047 || need > 0.05 {
+		t.Errorf("minTarget = %.4f, want just over the 4.7%% that covers brokerage and GST", need)
+	}
+	stockStrategies["ABC"] = models.StockStrategy{Target: need + 0.001, Leverage: 1}
+	if !targetClearsCosts("ABC", models.Short, 100, 10) {
+		t.Error("target above minTarget was refused")
+	}
+
+	minNetProfit = -1
+	stockStrategies["ABC"] = models.StockStrategy{Target: 0.001, Leverage: 1}
+	if !targetClearsCosts("ABC", models.Long, 100, 10) {
+		t.Error("check ran with minNetProfit disabled")
+	}
+}
+
+func TestRoundTripCosts(t *testing.T) {
+	c := costModel{STTIntraday: 0.00025, STTDelivery: 0.001, StampIntraday: 0.00003, StampDelivery: 0.00015}
+	if got := c.roundTrip(client.ProductMIS, 100, 100, 100); math.Abs(got-2.8) > 1e-9 { // 2.5 STT + 0.3 stamp
+		t.Errorf("intraday round trip = %v, want 2.8", got)
+	}
+	if got := c.roundTrip(client.ProductCNC, 100, 100, 100); math.Abs(got-21.5) > 1e-9 { // 20 STT + 1.5 stamp
+		t.Errorf("delivery round trip = %v, want 21.5", got)
+	}
+}
+
+func TestEntryQtyUnderBudget(t *testing.T) {
+	resetBooks(t)
+	t.Cleanup(func() { lotSizes = make(map[string]int) })
+	lotSizes["LOT"] = 5
+
+	// 10000 buys no share at 30000.
+	if got := entryQty("MRF", 10000, 30000); got != 0 {
+		t.Errorf("default policy qty = %d, want 0 (skip)", got)
+	}
+	stockStrategies["MRF"] = models.StockStrategy{UnderBudget: models.UnderBudgetMinQty, MinQty: 2}
+	if got := entryQty("MRF", 10000, 30000); got != 2 {
+		t.Errorf("min_qty policy qty = %d, want 2", got)
+	}
+	stockStrategies["LOT"] = models.StockStrategy{UnderBudget: models.UnderBudgetMinQty, MinQty: 2}
+	if got := entryQty("LOT", 10000, 30000); got != 5 {
+		t.Errorf("min_qty policy qty = %d, want rounded up to the lot of 5", got)
+	}
+
+	stockStrategies["MRF"] = models.StockStrategy{UnderBudget: models.UnderBudgetStretch, MaxBudget: 40000}
+	if got := entryQty("MRF", 10000, 30000); got != 1 {
+		t.Errorf("stretch within MaxBudget qty = %d, want 1", got)
+	}
+	if got := entryQty("MRF", 10000, 50000); got != 0 {
+		t.Errorf("stretch beyond MaxBudget qty = %d, want 0", got)
+	}
+}
+
+func TestEntryQtyATRSizing(t *testing.T) {
+	resetBooks(t)
+	defer func(m string) { sizingMode = m }(sizingMode)
+	sizingMode = sizingATR
+	t.Cleanup(func() { lotSizes = make(map[string]int) })
+
+	// Both trade at 100; CALM moves 0.5 a tick and WILD 2.
+	markets["CALM"] = &state.MarketState{Symbol: "CALM", History: []float64{100, 100.5, 100, 100.5, 100}}
+	markets["WILD"] = &state.MarketState{Symbol: "WILD", History: []float64{100, 102, 100, 102, 100}}
+
+	calm := entryQty("CALM", 100000, 100)
+	wild := entryQty("WILD", 100000, 100)
+	if want := int(atrRisk / (atrMultiple * 0.5)); calm != want {
+		t.Errorf("CALM qty = %d, want %d", calm, want)
+	}
+	if want := int(atrRisk / (atrMultiple * 2)); wild != want {
+		t.Errorf("WILD qty = %d, want %d", wild, want)
+	}
+	if wild >= calm {
+		t.Errorf("WILD qty %d not below CALM qty %d for the same risk", wild, calm)
+	}
+
+	// The budget still caps a very calm stock, and an unknown ATR sizes by budget.
+	if got := entryQty("CALM", 5000, 100); got != 50 {
+		t.Errorf("budget-capped qty = %d, want 50", got)
+	}
+	if got := entryQty("NEW", 5000, 100); got != 50 {
+		t.Errorf("qty without an ATR = %d, want 50", got)
+	}
+
+	// ATR sizing below one lot skips rather than rounding up.
+	lotSizes["WILD"] = 500
+	if got := entryQty("WILD", 100000, 100); got != 0 {
+		t.Errorf("qty under one lot = %d, want 0", got)
+	}
+
+	sizingMode = sizingFixed
+	if got := entryQty("WILD", 100000, 100); got != 1000 {
+		t.Errorf("fixed qty = %d, want 1000", got)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+var (
+	// marketSnapshotPath holds the session range and tick history so a
+	// restart later the same day resumes without losing them.
+	marketSnapshotPath  = dataPath("market_state.json")
+	marketSnapshotEvery = time.Minute // 0 disables snapshots
+
+	lastMarketSnapshot time.Time
+)
+
+// marketSnapshot is the on-disk form of markets for one trading day.
+type marketSnapshot struct {
+	Date    string                        `json:"date"` // YYYY-MM-DD, IST
+	Markets map[string]*state.MarketState `json:"markets"`
+}
+
+// saveMarketSnapshot writes every symbol's market state to path, stamped
+// with now's date.
+func saveMarketSnapshot(path string, now time.Time) error {
+	snap := marketSnapshot{Date: now.Format("2006-01-02"), Markets: make(map[string]*state.MarketState)}
+	mu.Lock()
+	for sym, ms := range markets {
+		snap.Markets[sym] = ms.Snapshot()
+	}
+	mu.Unlock()
+
+	return writeJSONAtomic(path, snap)
+}
+
+// loadMarketSnapshot reads path and returns its markets if the snapshot
+// was taken on today's date. A missing file or a snapshot from another
+// day returns nil without error.
+func loadMarketSnapshot(path string, today time.Time) (map[string]*state.MarketState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap marketSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	if snap.Date != today.Format("2006-01-02") {
+		fmt.Printf("Discarding market snapshot from %s\n", snap.Date)
+		return nil, nil
+	}
+	return snap.Markets, nil
+}
+
+// restoreMarketSnapshot seeds markets from today's snapshot, if any.
+func restoreMarketSnapshot(now time.Time) {
+	restored, err := loadMarketSnapshot(marketSnapshotPath, now)
+	if err != nil {
+		log.Printf("Market snapshot not restored: %v", err)
+		return
+	}
+	if len(restored) == 0 {
+		return
+	}
+
+	mu.Lock()
+	for sym, ms := range restored {
+		ms.Symbol = sym
+		markets[sym] = ms
+	}
+	mu.Unlock()
+	fmt.Printf("Restored session range and history for %d symbols\n", len(restored))
+}
+
+// maybeSaveMarketSnapshot snapshots markets once marketSnapshotEvery has
+// passed since the last snapshot.
+func maybeSaveMarketSnapshot(now time.Time) {
+	if marketSnapshotEvery <= 0 || now.Sub(lastMarketSnapshot) < marketSnapshotEvery {
+		return
+	}
+	lastMarketSnapshot = now
+	if err := saveMarketSnapshot(marketSnapshotPath, now); err != nil {
+		log.Printf("Market snapshot failed: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+func TestMarketSnapshotRoundTrip(t *testing.T) {
+	resetBooks(t)
+	path := filepath.Join(t.TempDir(), "market_state.json")
+	day := time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC)
+
+	markets["ABC"] = &state.MarketState{Symbol: "ABC", LTP: 101, High: 105, Low: 98, History: []float64{99, 100, 101}, Ticks: 3}
+	if err := saveMarketSnapshot(path, day); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := loadMarketSnapshot(path, day.Add(3*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := got["ABC"]
+	if ms == nil || ms.High != 105 || ms.Low != 98 || len(ms.History) != 3 || ms.Ticks != 3 {
+		t.Fatalf("restored %+v", ms)
+	}
+
+	got, err = loadMarketSnapshot(path, day.AddDate(0, 0, 1))
+	if err != nil || got != nil {
+		t.Fatalf("next day: got %v, %v; want the snapshot discarded", got, err)
+	}
+}
+
+func TestLoadMarketSnapshotMissing(t *testing.T) {
+	got, err := loadMarketSnapshot(filepath.Join(t.TempDir(), "none.json"), time.Now())
+	if got != nil || err != nil {
+		t.Fatalf("got %v, %v; want nil, nil", got, err)
+	}
+}
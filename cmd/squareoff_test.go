@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+// fakeBroker fills every order and records what was sent.
+type fakeBroker struct {
+	client.Broker
+	mu     sync.Mutex
+	orders []client.OrderParams
+}
+
+func (b *fakeBroker) PlaceOrder(ctx context.Context, p client.OrderParams) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.orders = append(b.orders, p)
+	return p.Symbol, nil
+}
+
+// flakyBroker is a fakeBroker that fails its first fails orders.
+type flakyBroker struct {
+	fakeBroker
+	fails int
+}
+
+func (b *flakyBroker) PlaceOrder(ctx context.Context, p client.OrderParams) (string, error) {
+	if b.fails > 0 {
+		b.fails--
+		return "", errors.New("network error")
+	}
+	return b.fakeBroker.PlaceOrder(ctx, p)
+}
+
+func (b *fakeBroker) GetOrderStatus(ctx context.Context, id string) (client.OrderStatus, error) {
+	return client.OrderStatus{OrderNo: id, Status: client.StatusComplete}, nil
+}
+
+// priceQuotes serves a fixed LTP per token and fails for unknown tokens.
+type priceQuotes map[string]float64
+
+func (q priceQuotes) GetQuote(ctx context.Context, exch, token string) (client.Quote, error) {
+	ltp, ok := q[token]
+	if !ok {
+		return client.Quote{}, errors.New("no quote")
+	}
+	return client.Quote{LTP: ltp}, nil
+}
+
+func (q priceQuotes) GetLTP(ctx context.Context, exch, token string) (float64, error) {
+	quote, err := q.GetQuote(ctx, exch, token)
+	return quote.LTP, err
+}
+
+func TestSquareOffAllPositions(t *testing.T) {
+	resetBooks(t)
+	paperTrading = false
+	fb := &fakeBroker{}
+	oldBroker, oldQuotes, oldTokens := broker, quotes, symbolToToken
+	t.Cleanup(func() { broker, quotes, symbolToToken = oldBroker, oldQuotes, oldTokens })
+	broker = fb
+	quotes = priceQuotes{"1": 110, "2": 90, "3": 110}
+	symbolToToken = map[string]string{"UP": "1", "DOWN": "2", "SQUEEZE": "3", "STALE": "4"}
+
+	seedLong("UP", 100, 10)
+	seedShort("DOWN", 100, 10)
+	seedShort("SQUEEZE", 100, 10)
+	seedLong("STALE", 100, 10)
+	mu.Lock()
+	markets["STALE"] = &state.MarketState{LTP: 95}
+	mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		squareOffAllPositions(time.Now())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("square-off deadlocked")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(longPositions)+len(shortPositions) != 0 {
+		t.Errorf("positions left open: %d long, %d short", len(longPositions), len(shortPositions))
+	}
+	if len(fb.orders) != 4 {
+		t.Errorf("sent %d exit orders, want 4", len(fb.orders))
+	}
+	for _, o := range fb.orders {
+		want := client.Sell
+		if o.Symbol == "DOWN" || o.Symbol == "SQUEEZE" {
+			want = client.Buy
+		}
+		if o.Side != want || o.Qty != 10 || o.PriceType != client.PriceMarket {
+			t.Errorf("%s exit order = %s %d %s, want %s 10 MKT", o.Symbol, o.Side, o.Qty, o.PriceType, want)
+		}
+	}
+
+	wantSign := map[string]float64{"UP": 1, "DOWN": 1, "SQUEEZE": -1, "STALE": -1}
+	if len(tradeHistory) != len(wantSign) {
+		t.Fatalf("recorded %d trades, want %d", len(tradeHistory), len(wantSign))
+	}
+	for _, tr := range tradeHistory {
+		if tr.Reason != ReasonEOD || tr.Reason.String() != "EOD Square-off" {
+			t.Errorf("%s reason = %q, want EOD Square-off", tr.Symbol, tr.Reason)
+		}
+		if tr.PnL*wantSign[tr.Symbol] <= 0 {
+			t.Errorf("%s %s P&L = %.2f, wrong sign", tr.Direction, tr.Symbol, tr.PnL)
+		}
+		if tr.ExitPrice == 0 {
+			t.Errorf("%s booked at 0", tr.Symbol)
+		}
+	}
+}
+
+func TestSquareOffSymbolOrder(t *testing.T) {
+	resetBooks(t)
+	paperTrading = false
+	fb := &fakeBroker{}
+	oldBroker, oldQuotes, oldTokens := broker, quotes, symbolToToken
+	t.Cleanup(func() { broker, quotes, symbolToToken, symbolPriority = oldBroker, oldQuotes, oldTokens, nil })
+	broker = fb
+	quotes = priceQuotes{}
+	symbolToToken = map[string]string{}
+
+	for _, sym := range []string{"MMM", "ZZZ", "AAA", "KKK", "BBB"} {
+		seedLong(sym, 100, 1)
+	}
+	seedShort("YYY", 100, 1)
+	seedShort("CCC", 100, 1)
+
+	sent := func() []string {
+		var syms []string
+		for _, o := range fb.orders {
+			syms = append(syms, o.Symbol)
+		}
+		fb.orders = nil
+		return syms
+	}
+
+	squareOffAllPositions(time.Now())
+	if got, want := sent(), []string{"AAA", "BBB", "KKK", "MMM", "ZZZ", "CCC", "YYY"}; !slices.Equal(got, want) {
+		t.Errorf("square-off order = %v, want %v", got, want)
+	}
+
+	// -priority puts its symbols first, in its order.
+	setSymbolPriority("zzz, KKK")
+	for _, sym := range []string{"MMM", "ZZZ", "AAA", "KKK"} {
+		seedLong(sym, 100, 1)
+	}
+	squareOffAllPositions(time.Now())
+	if got, want := sent(), []string{"ZZZ", "KKK", "AAA", "MMM"}; !slices.Equal(got, want) {
+		t.Errorf("prioritized square-off order = %v, want %v", got, want)
+	}
+}
+
+func TestSquareOffRepeatsUntilFlat(t *testing.T) {
+	resetBooks(t)
+	paperTrading = false
+	fb := &flakyBroker{fails: 1}
+	oldBroker, oldQuotes := broker, quotes
+	t.Cleanup(func() { broker, quotes = oldBroker, oldQuotes })
+	broker, quotes = fb, priceQuotes{}
+
+	seedLong("TEST", 100, 10)
+	if squareOffDue(istAt(2, 15, 9, 0)) {
+		t.Fatal("square-off due before squareOffAt")
+	}
+	now := istAt(2, 15, 10, 0)
+	if !squareOffDue(now) {
+		t.Fatal("square-off not due with a position open")
+	}
+
+	// The first exit fails; the next tick tries again.
+	squareOffAllPositions(now)
+	if !hasPosition("TEST", models.Long) || !squareOffDue(now.Add(5*time.Second)) {
+		t.Fatal("failed square-off exit not retried")
+	}
+	squareOffAllPositions(now.Add(5 * time.Second))
+	if hasPosition("TEST", models.Long) || squareOffDue(now.Add(10*time.Second)) {
+		t.Error("square-off still due after the retry closed the position")
+	}
+
+	// A pending entry keeps square-off running and is cancelled.
+	paperTrading = true
+	submitLimitEntry("LATE", entrySource{}, models.Long, 10, 90, 1)
+	if !squareOffDue(now.Add(time.Minute)) {
+		t.Fatal("square-off not due with an entry pending")
+	}
+	squareOffAllPositions(now.Add(time.Minute))
+	pollPendingOrders()
+	if squareOffDue(now.Add(time.Minute)) {
+		t.Error("cancelled pending entry still keeps square-off due")
+	}
+}
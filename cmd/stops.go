@@ -0,0 +1,334 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/notify"
+)
+
+const (
+	stopsBot      = "bot"      // local exit rules watch every tick and exit at market
+	stopsExchange = "exchange" // resting SL-MKT and target orders at the exchange own the exits
+)
+
+// stopMode decides who owns a position's stop-loss. In exchange mode every
+// position carries a resting SL-MKT order at its fixed SL and a limit at
+// its target, and the bot only watches those for a fill, cancelling the
+// other: none of the local exit rules run, so trailing and break-even
+// stops and max hold are off. The two must never be mixed - a local exit
+// and the exchange stop can both fill and leave the position reversed -
+// so the mode is all or nothing.
+var stopMode = stopsBot
+
+func setStopMode(s string) error {
+	switch s {
+	case stopsBot, stopsExchange:
+		stopMode = s
+		return nil
+	}
+	return fmt.Errorf("unknown stop mode %q (want %s or %s)", s, stopsBot, stopsExchange)
+}
+
+// positionsFor is the book holding dir positions. Callers hold mu.
+func positionsFor(dir models.Direction) map[string]position {
+	if dir == models.Short {
+		return shortPositions
+	}
+	return longPositions
+}
+
+// setStop records id as the resting stop at price on sym's dir position,
+// if it is still open.
+func setStop(sym string, dir models.Direction, id string, price float64) {
+	mu.Lock()
+	defer mu.Unlock()
+	book := positionsFor(dir)
+	if pos, ok := book[sym]; ok {
+		pos.StopOrder, pos.StopPrice = id, price
+		book[sym] = pos
+	}
+}
+
+// setTarget records id as the resting target at price on sym's dir
+// position, if it is still open.
+func setTarget(sym string, dir models.Direction, id string, price float64) {
+	mu.Lock()
+	defer mu.Unlock()
+	book := positionsFor(dir)
+	if pos, ok := book[sym]; ok {
+		pos.TargetOrder, pos.TargetPrice = id, price
+		book[sym] = pos
+	}
+}
+
+// placeExchangeStop replaces the stop on sym's dir position with an SL-MKT
+// order for its full quantity at the fixed SL from the average entry. A
+// position that cannot be protected is closed at market at ltp instead.
+func placeExchangeStop(sym string, dir models.Direction, ltp float64) {
+	qty, err := sendExchangeStop(sym, dir)
+	if err == nil {
+		return
+	}
+	notifyTrade(notify.EventError, fmt.Sprintf("%s STOP FAILED %s: %v - exiting at market", dir, sym, err))
+	defer lockExits(sym)()
+	if dir == models.Long {
+		exitLong(sym, ltp, qty, ReasonFixedSL)
+	} else {
+		exitShort(sym, ltp, qty, ReasonFixedSL)
+	}
+}
+
+// sendExchangeStop is placeExchangeStop without the fallback exit. It
+// returns the position's quantity, and fails only if the new stop could
+// not be placed; a stop that cannot be cancelled is left as it is.
+func sendExchangeStop(sym string, dir models.Direction) (int, error) {
+	mu.Lock()
+	pos, ok := positionsFor(dir)[sym]
+	mu.Unlock()
+	if !ok || pos.BracketOrder != "" {
+		return 0, nil
+	}
+
+	if pos.StopOrder != "" {
+		if err := cancelOrder(pos.StopOrder); err != nil {
+			notifyTrade(notify.EventError, fmt.Sprintf("%s STOP %s not replaced, cancel of %s failed: %v", dir, sym, pos.StopOrder, err))
+			return pos.TotalQty, nil
+		}
+		setStop(sym, dir, "", 0)
+	}
+
+	sl := getStrategy(sym).SL
+	side, trigger := client.Sell, pos.AvgEntry()*(1-sl)
+	if dir == models.Short {
+		side, trigger = client.Buy, pos.AvgEntry()*(1+sl)
+	}
+	trigger = client.RoundToTick(trigger, tickSizeFor(sym))
+
+	p := marketOrder(sym, dir, side, pos.TotalQty)
+	p.Product = heldProduct(sym, dir)
+	p.PriceType, p.Trigger = client.PriceSLMarket, trigger
+	// Skip the duplicate check: the stop shares its side with the exit
+	// that may follow within the same minute.
+	id, err := sendOrder(p)
+	if err != nil {
+		return pos.TotalQty, err
+	}
+	setStop(sym, dir, id, trigger)
+	logTrade(fmt.Sprintf("STOP %s %s SL-MKT trigger %.2f Qty: %d (order %s)", dir, sym, trigger, pos.TotalQty, id))
+	return pos.TotalQty, nil
+}
+
+// placeExchangeTarget replaces the target on sym's dir position with a
+// DAY limit for its full quantity at the strategy's Target from the
+// average entry. A failed target leaves the position to its stop.
+func placeExchangeTarget(sym string, dir models.Direction) {
+	mu.Lock()
+	pos, ok := positionsFor(dir)[sym]
+	mu.Unlock()
+	target := getStrategy(sym).Target
+	if !ok || pos.BracketOrder != "" || target <= 0 {
+		return
+	}
+
+	if pos.TargetOrder != "" {
+		if err := cancelOrder(pos.TargetOrder); err != nil {
+			notifyTrade(notify.EventError, fmt.Sprintf("%s TARGET %s not replaced, cancel of %s failed: %v", dir, sym, pos.TargetOrder, err))
+			return
+		}
+		setTarget(sym, dir, "", 0)
+	}
+
+	side, price := client.Sell, pos.AvgEntry()*(1+target)
+	if dir == models.Short {
+		side, price = client.Buy, pos.AvgEntry()*(1-target)
+	}
+	price = client.RoundToTick(price, tickSizeFor(sym))
+
+	p := marketOrder(sym, dir, side, pos.TotalQty)
+	p.Product = heldProduct(sym, dir)
+	p.PriceType, p.Price = client.PriceLimit, price
+	// Same side as the stop, so it too skips the duplicate check.
+	id, err := sendOrder(p)
+	if err != nil {
+		notifyTrade(notify.EventError, fmt.Sprintf("%s TARGET FAILED %s: %v - position left to its stop", dir, sym, err))
+		return
+	}
+	setTarget(sym, dir, id, price)
+	logTrade(fmt.Sprintf("TARGET %s %s LMT @ %.2f Qty: %d (order %s)", dir, sym, price, pos.TotalQty, id))
+}
+
+// cancelExchangeExits takes down the stop and target on sym's dir position
+// ahead of an exit of its own. It fails if either has already filled, so
+// the exit is not sent twice; pollExchangeStops books that fill instead.
+// If the target cannot be cancelled, the stop already taken down is put
+// back so the position is never left with neither.
+func cancelExchangeExits(sym string, dir models.Direction) error {
+	mu.Lock()
+	pos := positionsFor(dir)[sym]
+	mu.Unlock()
+
+	for i, leg := range []struct {
+		name string
+		id   string
+		set  func(sym string, dir models.Direction, id string, price float64)
+	}{
+		{"stop", pos.StopOrder, setStop},
+		{"target", pos.TargetOrder, setTarget},
+	} {
+		if leg.id == "" {
+			continue
+		}
+		if st, err := orderStatus(leg.id); err == nil && st.Status == client.StatusComplete {
+			return fmt.Errorf("%s order %s already filled", leg.name, leg.id)
+		}
+		if err := cancelOrder(leg.id); err != nil {
+			if i > 0 && pos.StopOrder != "" {
+				restoreExchangeExits(sym, dir)
+			}
+			return fmt.Errorf("cancel %s order %s: %w", leg.name, leg.id, err)
+		}
+		leg.set(sym, dir, "", 0)
+	}
+	return nil
+}
+
+// exchangeLegs is the current stop and target order IDs of sym's dir
+// position, which a replacement may have changed since it was read.
+func exchangeLegs(sym string, dir models.Direction) (stop, target string) {
+	mu.Lock()
+	defer mu.Unlock()
+	pos := positionsFor(dir)[sym]
+	return pos.StopOrder, pos.TargetOrder
+}
+
+// restoreExchangeExits puts back the stop and target of sym's dir position
+// after an exit of its own failed or closed only part of it, so the rest
+// is not left unprotected. It does not fall back to a market exit: that
+// is what just failed or is already done.
+func restoreExchangeExits(sym string, dir models.Direction) {
+	if stopMode != stopsExchange {
+		return
+	}
+	mu.Lock()
+	pos, ok := positionsFor(dir)[sym]
+	mu.Unlock()
+	if !ok {
+		return
+	}
+	if pos.StopOrder == "" {
+		if _, err := sendExchangeStop(sym, dir); err != nil {
+			notifyTrade(notify.EventError, fmt.Sprintf("%s STOP FAILED %s: %v - position has no stop, check it now", dir, sym, err))
+		}
+	}
+	if pos.TargetOrder == "" {
+		placeExchangeTarget(sym, dir)
+	}
+}
+
+// cancelSibling cancels the other leg of an OCO pair once one has filled.
+// If the sibling has filled too the position has been closed and then
+// reopened the other way, which needs a human.
+func cancelSibling(sym string, dir models.Direction, filled, sibling, id string) {
+	if id == "" {
+		return
+	}
+	if st, err := orderStatus(id); err == nil && st.Status == client.StatusComplete {
+		notifyTrade(notify.EventError, fmt.Sprintf("%s %s: %s and %s orders both filled (%s) - position reversed at the broker, check it now",
+			dir, sym, filled, sibling, id))
+		return
+	}
+	if err := cancelOrder(id); err != nil {
+		notifyTrade(notify.EventError, fmt.Sprintf("%s %s: %s filled but cancel of %s order %s failed: %v - it may still fill",
+			dir, sym, filled, sibling, id, err))
+		return
+	}
+	logTrade(fmt.Sprintf("OCO %s %s: %s filled, %s order %s cancelled", dir, sym, filled, sibling, id))
+}
+
+// pollExchangeStops books positions whose exchange stop or target has
+// filled, cancelling the other leg, and replaces legs the exchange
+// cancelled or rejected.
+func pollExchangeStops() {
+	if stopMode != stopsExchange {
+		return
+	}
+
+	type stop struct {
+		sym string
+		dir models.Direction
+		pos position
+		ltp float64
+	}
+	mu.Lock()
+	var stops []stop
+	for _, dir := range []models.Direction{models.Long, models.Short} {
+		book := positionsFor(dir)
+		for _, sym := range orderedSymbols(book) {
+			pos := book[sym]
+			if pos.StopOrder == "" && pos.TargetOrder == "" {
+				continue
+			}
+			s := stop{sym: sym, dir: dir, pos: pos}
+			if ms, ok := markets[sym]; ok {
+				s.ltp = ms.LTP
+			}
+			stops = append(stops, s)
+		}
+	}
+	mu.Unlock()
+
+	for _, s := range stops {
+		if s.pos.StopOrder != "" {
+			st, err := orderStatus(s.pos.StopOrder)
+			switch {
+			case err != nil:
+				log.Printf("Stop status for %s (%s) failed: %v", s.pos.StopOrder, s.sym, err)
+			case st.Status == client.StatusComplete:
+				_, target := exchangeLegs(s.sym, s.dir)
+				cancelSibling(s.sym, s.dir, "stop", "target", target)
+				bookExchangeFill(s.sym, s.dir, s.pos, st, s.pos.StopPrice, ReasonExchangeSL)
+				continue
+			case st.Status == client.StatusRejected || st.Status == client.StatusCancelled:
+				notifyTrade(notify.EventError, fmt.Sprintf("%s STOP %s %s: order %s %s - replacing",
+					s.dir, s.sym, st.Status, s.pos.StopOrder, st.Reason))
+				setStop(s.sym, s.dir, "", 0)
+				placeExchangeStop(s.sym, s.dir, s.ltp)
+			}
+		}
+
+		if s.pos.TargetOrder != "" {
+			st, err := orderStatus(s.pos.TargetOrder)
+			switch {
+			case err != nil:
+				log.Printf("Target status for %s (%s) failed: %v", s.pos.TargetOrder, s.sym, err)
+			case st.Status == client.StatusComplete:
+				// The stop may have been replaced above in this pass.
+				stop, _ := exchangeLegs(s.sym, s.dir)
+				cancelSibling(s.sym, s.dir, "target", "stop", stop)
+				bookExchangeFill(s.sym, s.dir, s.pos, st, s.pos.TargetPrice, ReasonExchangeTarget)
+			case st.Status == client.StatusRejected || st.Status == client.StatusCancelled:
+				notifyTrade(notify.EventError, fmt.Sprintf("%s TARGET %s %s: order %s %s - replacing",
+					s.dir, s.sym, st.Status, s.pos.TargetOrder, st.Reason))
+				setTarget(s.sym, s.dir, "", 0)
+				placeExchangeTarget(s.sym, s.dir)
+			}
+		}
+	}
+}
+
+// bookExchangeFill books the exit of pos by a filled exchange leg, at the
+// order's average price or, if the status has none, its price.
+func bookExchangeFill(sym string, dir models.Direction, pos position, st client.OrderStatus, price float64, reason ExitReason) {
+	fill := st.AvgPrice
+	if fill == 0 {
+		fill = price
+	}
+	if dir == models.Long {
+		bookLongExit(sym, fill, pos.TotalQty, reason)
+	} else {
+		bookShortExit(sym, fill, pos.TotalQty, reason)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/models"
+)
+
+func TestExchangeStopFill(t *testing.T) {
+	resetBooks(t)
+	defer func(m string) { stopMode = m }(stopMode)
+	stopMode = stopsExchange
+	stockStrategies["ABC"] = models.StockStrategy{SL: 0.02, Target: 0.01}
+
+	openLong("ABC", entrySource{}, 100, 100, 10, 1, client.ProductCNC)
+	pos := longPositions["ABC"]
+	if pos.StopOrder == "" || pos.StopPrice != 98 {
+		t.Fatalf("stop = %q @ %v, want a resting stop @ 98", pos.StopOrder, pos.StopPrice)
+	}
+	if o := paperOrders[pos.StopOrder]; o.Type != client.PriceSLMarket || o.Side != client.Sell || o.Qty != 10 {
+		t.Errorf("stop order = %+v, want SL-MKT sell of 10", *o)
+	}
+	target := pos.TargetOrder
+	if o := paperOrders[target]; o == nil || o.Type != client.PriceLimit || o.Side != client.Sell || o.Limit != 101 {
+		t.Fatalf("target order = %+v, want a limit sell @ 101", o)
+	}
+
+	// Past both the fixed SL and the target, but the bot must not exit.
+	checkLongExit("ABC", 97)
+	checkLongExit("ABC", 102)
+	if _, ok := longPositions["ABC"]; !ok {
+		t.Fatal("local exit fired in exchange stop mode")
+	}
+
+	matchPaperOrders("ABC", 98.5)
+	pollExchangeStops()
+	if _, ok := longPositions["ABC"]; !ok {
+		t.Fatal("stop filled above its trigger")
+	}
+
+	matchPaperOrders("ABC", 97.5)
+	pollExchangeStops()
+	if _, ok := longPositions["ABC"]; ok {
+		t.Fatal("position still open after its stop filled")
+	}
+	if tr := lastTrade(t); tr.Reason != ReasonExchangeSL || tr.ExitPrice != 97.5 {
+		t.Errorf("trade = %s @ %v, want %s @ 97.5", tr.Reason, tr.ExitPrice, ReasonExchangeSL)
+	}
+	if paperOrders[target].Status.Status != client.StatusCancelled {
+		t.Error("target left resting after the stop filled")
+	}
+}
+
+func TestExchangeTargetFillCancelsStop(t *testing.T) {
+	resetBooks(t)
+	defer func(m string) { stopMode = m }(stopMode)
+	stopMode = stopsExchange
+	stockStrategies["XYZ"] = models.StockStrategy{SL: 0.01, Target: 0.02, AllowShort: true}
+
+	openShort("XYZ", entrySource{}, 200, 200, 5, 1, client.ProductMIS)
+	pos := shortPositions["XYZ"]
+	if o := paperOrders[pos.TargetOrder]; o == nil || o.Side != client.Buy || o.Limit != 196 {
+		t.Fatalf("target order = %+v, want a limit buy @ 196", o)
+	}
+
+	matchPaperOrders("XYZ", 195.5)
+	pollExchangeStops()
+	if _, ok := shortPositions["XYZ"]; ok {
+		t.Fatal("position still open after its target filled")
+	}
+	if tr := lastTrade(t); tr.Reason != ReasonExchangeTarget || tr.ExitPrice != 196 {
+		t.Errorf("trade = %s @ %v, want %s @ 196", tr.Reason, tr.ExitPrice, ReasonExchangeTarget)
+	}
+	if paperOrders[pos.StopOrder].Status.Status != client.StatusCancelled {
+		t.Error("stop left resting after the target filled")
+	}
+}
+
+func TestExchangeStopCancelledByExit(t *testing.T) {
+	resetBooks(t)
+	defer func(m string) { stopMode = m }(stopMode)
+	stopMode = stopsExchange
+	stockStrategies["XYZ"] = models.StockStrategy{SL: 0.01, Target: 0.01}
+
+	openShort("XYZ", entrySource{}, 200, 200, 5, 1, client.ProductMIS)
+	stop := shortPositions["XYZ"].StopOrder
+	if o := paperOrders[stop]; o == nil || o.Side != client.Buy || o.Trigger != 202 {
+		t.Fatalf("stop order = %+v, want a buy triggered at 202", o)
+	}
+
+	// A second lot replaces the stop with one for the whole position.
+	openShort("XYZ", entrySource{}, 200, 200, 5, 1, client.ProductMIS)
+	if paperOrders[stop].Status.Status != client.StatusCancelled {
+		t.Error("old stop not cancelled when the position grew")
+	}
+	stop = shortPositions["XYZ"].StopOrder
+	if o := paperOrders[stop]; o.Qty != 10 {
+		t.Errorf("replacement stop qty = %d, want 10", o.Qty)
+	}
+
+	target := shortPositions["XYZ"].TargetOrder
+	exitShort("XYZ", 199, 10, ReasonManual)
+	if paperOrders[stop].Status.Status != client.StatusCancelled {
+		t.Error("stop left resting after a manual exit")
+	}
+	if paperOrders[target].Status.Status != client.StatusCancelled {
+		t.Error("target left resting after a manual exit")
+	}
+	if _, ok := shortPositions["XYZ"]; ok {
+		t.Error("manual exit did not close the position")
+	}
+}
+
+func TestSetStopMode(t *testing.T) {
+	defer func(m string) { stopMode = m }(stopMode)
+	if err := setStopMode(stopsExchange); err != nil || stopMode != stopsExchange {
+		t.Errorf("setStopMode(exchange) = %v, mode %s", err, stopMode)
+	}
+	if err := setStopMode("both"); err == nil {
+		t.Error("setStopMode accepted an unknown mode")
+	}
+}
+
+func TestExchangeStopRestoredAfterExit(t *testing.T) {
+	pb := livePartial(t, partialAccept, func(int) int { return 0 })
+	defer func(m string) { stopMode = m }(stopMode)
+	stopMode = stopsExchange
+	stockStrategies["ABC"] = models.StockStrategy{SL: 0.02, Target: 0.01}
+
+	openLong("ABC", entrySource{}, 100, 100, 10, 1, client.ProductCNC)
+	stop, target := longPositions["ABC"].StopOrder, longPositions["ABC"].TargetOrder
+
+	// The exit is refused after its legs came down: both go back up.
+	pb.reject = func(p client.OrderParams) error {
+		if p.PriceType == client.PriceMarket {
+			return errors.New("RMS: margin exceeded")
+		}
+		return nil
+	}
+	exitLong("ABC", 99, 10, ReasonManual)
+	pos, ok := longPositions["ABC"]
+	if !ok {
+		t.Fatal("failed exit closed the position")
+	}
+	if !slices.Equal(pb.cancelled, []string{stop, target}) {
+		t.Fatalf("cancelled %v, want the stop and target", pb.cancelled)
+	}
+	if pos.StopOrder == "" || pos.StopOrder == stop || pos.TargetOrder == "" || pos.TargetOrder == target {
+		t.Fatalf("legs after a failed exit = %q/%q, want fresh ones", pos.StopOrder, pos.TargetOrder)
+	}
+
+	// A partial exit leaves the rest under a stop for its quantity.
+	pb.reject = nil
+	exitLong("ABC", 99, 4, ReasonManual)
+	pos = longPositions["ABC"]
+	if pos.StopOrder == "" || pb.orders[len(pb.orders)-2].Qty != 6 {
+		t.Errorf("stop after a partial exit = %q for %d, want one for the 6 left", pos.StopOrder, pb.orders[len(pb.orders)-2].Qty)
+	}
+}
+
+func TestExchangeTargetFillCancelsReplacedStop(t *testing.T) {
+	resetBooks(t)
+	defer func(m string) { stopMode = m }(stopMode)
+	stopMode = stopsExchange
+	stockStrategies["ABC"] = models.StockStrategy{SL: 0.02, Target: 0.01}
+
+	openLong("ABC", entrySource{}, 100, 100, 10, 1, client.ProductCNC)
+	pos := longPositions["ABC"]
+
+	// In one pass the exchange cancels the stop, which is replaced, and
+	// the target fills: the replacement must come down, not the old stop.
+	paperOrders[pos.StopOrder].Status.Status = client.StatusCancelled
+	paperOrders[pos.TargetOrder].Status = client.OrderStatus{Status: client.StatusComplete, FilledQty: 10, AvgPrice: 101}
+	pollExchangeStops()
+	if _, ok := longPositions["ABC"]; ok {
+		t.Fatal("position still open after its target filled")
+	}
+	for id, o := range paperOrders {
+		if o.Status.Status == client.StatusOpen {
+			t.Errorf("order %s (%s %s) left resting with no position", id, o.Type, o.Side)
+		}
+	}
+}
+
+func TestExchangeStopKeptWhenTargetCancelFails(t *testing.T) {
+	pb := livePartial(t, partialAccept, func(int) int { return 0 })
+	defer func(m string) { stopMode = m }(stopMode)
+	stopMode = stopsExchange
+	stockStrategies["ABC"] = models.StockStrategy{SL: 0.02, Target: 0.01}
+
+	openLong("ABC", entrySource{}, 100, 100, 10, 1, client.ProductCNC)
+	pb.noCancel = []string{longPositions["ABC"].TargetOrder}
+
+	exitLong("ABC", 99, 10, ReasonManual)
+	pos, ok := longPositions["ABC"]
+	if !ok {
+		t.Fatal("exit went out although the target could not be cancelled")
+	}
+	if pos.StopOrder == "" {
+		t.Error("stop left cancelled after the target cancel failed")
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+)
+
+// recordTicks writes every fetched quote to ticks-YYYY-MM-DD.jsonl in
+// dataDir for later replay.
+var (
+	recordTicks = false
+	ticks       *tickRecorder // nil unless recordTicks
+)
+
+// tickRecord is one line of a tick archive.
+type tickRecord struct {
+	Symbol   string    `json:"symbol"`
+	Token    string    `json:"token"`
+	LTP      float64   `json:"ltp"`
+	Volume   int64     `json:"volume,omitempty"`
+	Bid      float64   `json:"bid,omitempty"`
+	Ask      float64   `json:"ask,omitempty"`
+	FeedTime time.Time `json:"feed_time,omitzero"`
+	WallTime time.Time `json:"wall_time"`
+}
+
+// tickRecorder appends tickRecords to a buffered per-day file in dir. It
+// is safe for concurrent use by the poll workers.
+type tickRecorder struct {
+	mu  sync.Mutex
+	dir string
+	day string
+	f   *os.File
+	w   *bufio.Writer
+}
+
+func newTickRecorder(dir string) *tickRecorder {
+	return &tickRecorder{dir: dir}
+}
+
+// record buffers q for sym, opening the file for now's date if needed.
+func (r *tickRecorder) record(sym, token string, q client.Quote, now time.Time) {
+	line, err := json.Marshal(tickRecord{
+		Symbol: sym, Token: token, LTP: q.LTP, Volume: q.Volume,
+		Bid: q.Bid, Ask: q.Ask, FeedTime: q.FeedTime, WallTime: now,
+	})
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if day := now.Format("2006-01-02"); day != r.day {
+		if err := r.open(day); err != nil {
+			log.Printf("Tick recorder: %v", err)
+			return
+		}
+	}
+	r.w.Write(append(line, '\n'))
+}
+
+// open switches to day's file. r.mu must be held.
+func (r *tickRecorder) open(day string) error {
+	r.closeFile()
+	os.MkdirAll(r.dir, 0755)
+	path := filepath.Join(r.dir, fmt.Sprintf("ticks-%s.jsonl", day))
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	r.day, r.f, r.w = day, f, bufio.NewWriterSize(f, 64<<10)
+	return nil
+}
+
+// flush writes buffered ticks to disk; the loop calls it once per tick.
+func (r *tickRecorder) flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.w != nil {
+		if err := r.w.Flush(); err != nil {
+			log.Printf("Tick recorder flush: %v", err)
+		}
+	}
+}
+
+// close flushes and closes the current file.
+func (r *tickRecorder) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closeFile()
+}
+
+func (r *tickRecorder) closeFile() {
+	if r.f == nil {
+		return
+	}
+	r.w.Flush()
+	r.f.Close()
+	r.day, r.f, r.w = "", nil, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/client"
+)
+
+func TestTickRecorderWritesDailyFiles(t *testing.T) {
+	dir := t.TempDir()
+	r := newTickRecorder(dir)
+	day1 := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	feed := day1.Add(-time.Second)
+
+	r.record("SBIN", "3045", client.Quote{LTP: 750.5, Volume: 1200, FeedTime: feed}, day1)
+	r.record("INFY", "1594", client.Quote{LTP: 1500}, day1.Add(time.Second))
+	r.record("SBIN", "3045", client.Quote{LTP: 752}, day1.AddDate(0, 0, 1))
+	r.close()
+
+	read := func(day string) []tickRecord {
+		f, err := os.Open(filepath.Join(dir, "ticks-"+day+".jsonl"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		var out []tickRecord
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			var rec tickRecord
+			if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, rec)
+		}
+		return out
+	}
+
+	got := read("2026-03-02")
+	if len(got) != 2 {
+		t.Fatalf("day 1 has %d ticks, want 2", len(got))
+	}
+	if got[0].Symbol != "SBIN" || got[0].Token != "3045" || got[0].LTP != 750.5 || got[0].Volume != 1200 || !got[0].FeedTime.Equal(feed) {
+		t.Errorf("first tick = %+v", got[0])
+	}
+	if next := read("2026-03-03"); len(next) != 1 || next[0].LTP != 752 {
+		t.Errorf("day 2 = %+v, want the one later tick", next)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/may-bach/Axiom/internal/client"
+	"github.com/may-bach/Axiom/internal/instruments"
+	"github.com/may-bach/Axiom/internal/notify"
+)
+
+var (
+	ltpFailures        = make(map[string]int) // consecutive LTP errors per symbol
+	remapAfterFailures = 3
+
+	instrumentsURL  = instruments.DefaultURL
+	instrumentsPath = dataPath("instruments.csv") // today's master, re-downloaded daily
+)
+
+// scrip is what SearchScrip reports for a tradable instrument. TickSize
+// and LotSize are zero when the response omits them.
+type scrip struct {
+	Token    string
+	Tsym     string // set only by symbol overrides
+	TickSize float64
+	LotSize  int
+}
+
+// searchToken looks up the NSE -EQ token, tick size and lot size for sym.
+func searchToken(ctx context.Context, sym string) (scrip, error) {
+	respBytes, err := account.Client.SearchScrip(ctx, "NSE", sym+"-EQ")
+	if err != nil {
+		return scrip{}, fmt.Errorf("search failed: %v", err)
+	}
+
+	var sr client.SearchResult
+	if err := client.DecodeResponse(respBytes, &sr); err != nil {
+		return scrip{}, fmt.Errorf("JSON parse error: %v", err)
+	}
+
+	if sr.Stat != "Ok" {
+		return scrip{}, fmt.Errorf("search failed: %s %s", sr.Stat, sr.Emsg)
+	}
+
+	for _, v := range sr.Values {
+		if strings.Contains(v.Tsym, "-EQ") {
+			ti, _ := strconv.ParseFloat(v.Ti, 64)
+			ls, _ := strconv.Atoi(v.Ls)
+			return scrip{Token: v.Token, TickSize: ti, LotSize: ls}, nil
+		}
+	}
+	return scrip{}, fmt.Errorf("no -EQ token found")
+}
+
+// lookupScrip resolves sym from its symbol override, then master, falling
+// back to SearchScrip when master is nil or does not list it.
+func lookupScrip(ctx context.Context, master *instruments.Master, sym string) (scrip, error) {
+	if sc, ok := overrideScrip(sym); ok {
+		return sc, nil
+	}
+	if master != nil {
+		if in, ok := master.Lookup(sym); ok {
+			return scrip{Token: in.Token, TickSize: in.TickSize, LotSize: in.LotSize}, nil
+		}
+	}
+	return searchToken(ctx, sym)
+}
+
+// storeScrip records sc as sym's instrument. The caller holds mu.
+func storeScrip(sym string, sc scrip) {
+	symbolToToken[sym] = sc.Token
+	if sc.Tsym != "" {
+		tradingSymbols[sym] = sc.Tsym
+	}
+	if sc.TickSize > 0 {
+		tickSizes[sym] = sc.TickSize
+	}
+	if sc.LotSize > 0 {
+		lotSizes[sym] = sc.LotSize
+	}
+}
+
+// recordLTPResult tracks consecutive LTP failures for sym. After
+// remapAfterFailures in a row the token is searched again; if that also
+// fails the symbol is dropped from polling.
+func recordLTPResult(ctx context.Context, sym string, err error) {
+	mu.Lock()
+	if err == nil {
+		delete(ltpFailures, sym)
+		mu.Unlock()
+		return
+	}
+	ltpFailures[sym]++
+	if ltpFailures[sym] < remapAfterFailures {
+		mu.Unlock()
+		return
+	}
+	delete(ltpFailures, sym)
+	mu.Unlock()
+
+	sc, searchErr := lookupScrip(ctx, nil, sym)
+	if searchErr != nil {
+		mu.Lock()
+		delete(symbolToToken, sym)
+		_, long := longPositions[sym]
+		_, short := shortPositions[sym]
+		mu.Unlock()
+
+		msg := fmt.Sprintf("%s dropped from polling after %d LTP errors and a failed re-map: %v", sym, remapAfterFailures, searchErr)
+		if long || short {
+			msg += " (position still open)"
+		}
+		notifier.Notify(notify.EventError, msg)
+		return
+	}
+
+	mu.Lock()
+	old := symbolToToken[sym]
+	storeScrip(sym, sc)
+	mu.Unlock()
+
+	fmt.Printf("Re-mapped %s: %s → %s\n", sym, old, sc.Token)
+	saveTokenMap()
+}
@@ -0,0 +1,48 @@
+package main
+
+import "fmt"
+
+const (
+	sizingFixed = "fixed" // qty is the entry budget over the price
+	sizingATR   = "atr"   // qty also risks at most atrRisk over atrMultiple ATRs
+)
+
+// sizingMode decides how entries are sized. Fixed sizing spends the same
+// budget on a calm stock and a wild one; ATR sizing caps the quantity so
+// that a move of atrMultiple average true ranges against the position
+// loses at most atrRisk, giving volatile names fewer shares. The ATR is
+// measured over the last atrLookback ticks, so it is in units of one poll
+// interval; until a symbol has two ticks it is sized by budget alone.
+var (
+	sizingMode  = sizingFixed
+	atrLookback = 14
+	atrMultiple = 2.0
+	atrRisk     = 1000.0
+)
+
+func setSizingMode(s string) error {
+	switch s {
+	case sizingFixed, sizingATR:
+		sizingMode = s
+		return nil
+	}
+	return fmt.Errorf("unknown sizing %q (want %s or %s)", s, sizingFixed, sizingATR)
+}
+
+// atrQty is the most of sym ATR sizing lets an entry buy; ok is false in
+// fixed mode or while sym's ATR is unknown.
+func atrQty(sym string) (qty int, ok bool) {
+	if sizingMode != sizingATR || atrRisk <= 0 || atrMultiple <= 0 {
+		return 0, false
+	}
+	mu.Lock()
+	var atr float64
+	if ms, found := markets[sym]; found {
+		atr = ms.ATR(atrLookback)
+	}
+	mu.Unlock()
+	if atr <= 0 {
+		return 0, false
+	}
+	return int(atrRisk / (atrMultiple * atr)), true
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/notify"
+)
+
+var (
+	pollInterval = 10 * time.Second
+
+	// stallAfter is how long the loop may go without completing a tick
+	// before the watchdog raises an alert; 0 uses twice pollInterval.
+	stallAfter time.Duration
+
+	heartbeat atomic.Int64 // unix nanos of the last completed tick
+)
+
+// beat records that the loop completed a tick.
+func beat() {
+	heartbeat.Store(time.Now().UnixNano())
+}
+
+func sinceHeartbeat() time.Duration {
+	return time.Since(time.Unix(0, heartbeat.Load()))
+}
+
+func stallLimit() time.Duration {
+	if stallAfter > 0 {
+		return stallAfter
+	}
+	return 2 * pollInterval
+}
+
+// watchLoop alerts once when the loop misses its heartbeat for longer than
+// stallAfter, and again when it recovers. It returns when ctx is done.
+func watchLoop(ctx context.Context) {
+	limit := stallLimit()
+	check := time.NewTicker(pollInterval)
+	defer check.Stop()
+
+	stalled := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-check.C:
+		}
+
+		since := sinceHeartbeat()
+		switch {
+		case since > limit && !stalled:
+			stalled = true
+			// The stall may be a deadlock on mu, so never block on it here.
+			positions := "mu is held"
+			if mu.TryLock() {
+				positions = fmt.Sprintf("%d positions open", len(longPositions)+len(shortPositions))
+				mu.Unlock()
+			}
+			notifier.Notify(notify.EventError, fmt.Sprintf("Loop stalled: no tick completed for %s (%s)", since.Round(time.Second), positions))
+		case since <= limit && stalled:
+			stalled = false
+			notifier.Notify(notify.EventError, "Loop recovered after a stall")
+		}
+	}
+}
@@ -2,14 +2,30 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 )
 
+const maxAttempts = 3
+
+// TokenURL is the token exchange endpoint; tests point it at a local
+// server.
+var TokenURL = "https://authapi.flattrade.in/trade/apitoken"
+
+// ErrRequestCodeExpired is returned when Flattrade rejects the request_code
+// as invalid or already consumed. A fresh one must be fetched from the
+// browser login.
+var ErrRequestCodeExpired = errors.New("request_code expired or already used")
+
 type TokenResponse struct {
 	Token  string `json:"token"`
 	Client string `json:"client"`
@@ -17,11 +33,63 @@ type TokenResponse struct {
 	Emsg   string `json:"emsg"`
 }
 
-func GetSessionToken(apiKey, requestCode, apiSecret string) (string, error) {
+var (
+	httpClient = &http.Client{Timeout: 15 * time.Second}
+
+	// A request_code can only be exchanged once, so the token it produced
+	// is cached per API key and handed back to later callers using the
+	// same code.
+	cacheMu sync.Mutex
+	cache   = make(map[string]cachedToken)
+)
+
+type cachedToken struct {
+	code, token string
+}
+
+// Invalidate drops apiKey's cached token, forcing its next GetSessionToken
+// call to hit the API.
+func Invalidate(apiKey string) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	delete(cache, apiKey)
+}
+
+func GetSessionToken(ctx context.Context, apiKey, requestCode, apiSecret string) (string, error) {
 	if requestCode == "" {
 		return "", fmt.Errorf("request_code required - get fresh one from browser daily")
 	}
 
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if c, ok := cache[apiKey]; ok && c.code == requestCode {
+		return c.token, nil
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		token, retry, err := requestToken(ctx, apiKey, requestCode, apiSecret)
+		if err == nil {
+			cache[apiKey] = cachedToken{requestCode, token}
+			return token, nil
+		}
+		if !retry {
+			return "", err
+		}
+		lastErr = err
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(time.Duration(attempt) * time.Second):
+		}
+	}
+	return "", fmt.Errorf("auth failed after %d attempts: %w", maxAttempts, lastErr)
+}
+
+// requestToken makes one token request. retry reports whether the failure
+// was transient (network error or 5xx) and worth another attempt.
+func requestToken(ctx context.Context, apiKey, requestCode, apiSecret string) (token string, retry bool, err error) {
 	input := apiKey + requestCode + apiSecret
 	hash := sha256.Sum256([]byte(input))
 	securityKey := hex.EncodeToString(hash[:])
@@ -34,28 +102,43 @@ func GetSessionToken(apiKey, requestCode, apiSecret string) (string, error) {
 
 	bodyBytes, _ := json.Marshal(payload)
 
-	req, _ := http.NewRequest("POST", "https://authapi.flattrade.in/trade/apitoken", bytes.NewBuffer(bodyBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", TokenURL, bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return "", false, err
+	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return "", ctx.Err() == nil, err
 	}
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
 
+	if resp.StatusCode >= 500 {
+		return "", true, fmt.Errorf("auth server error: %s - raw: %s", resp.Status, string(body))
+	}
+
 	var tr TokenResponse
 	if err := json.Unmarshal(body, &tr); err != nil {
-		return "", fmt.Errorf("invalid JSON: %v - raw: %s", err, string(body))
+		return "", false, fmt.Errorf("invalid JSON: %v - raw: %s", err, string(body))
 	}
 
-	//fmt.Printf("DEBUG - Parsed Stat: %s\n", tr.Stat)
-
 	if tr.Stat == "Ok" {
-		return tr.Token, nil
+		return tr.Token, false, nil
+	}
+
+	if isRequestCodeError(tr.Emsg) {
+		return "", false, fmt.Errorf("%w: %s", ErrRequestCodeExpired, tr.Emsg)
 	}
 
-	return "", fmt.Errorf("failed: stat=%s emsg=%s raw=%s", tr.Stat, tr.Emsg, string(body))
+	return "", false, fmt.Errorf("failed: stat=%s emsg=%s raw=%s", tr.Stat, tr.Emsg, string(body))
+}
+
+func isRequestCodeError(emsg string) bool {
+	msg := strings.ToLower(emsg)
+	return strings.Contains(msg, "request code") ||
+		strings.Contains(msg, "request_code") ||
+		strings.Contains(msg, "requestcode")
 }
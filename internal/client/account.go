@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+
+	"github.com/may-bach/Axiom/internal/auth"
+	"github.com/may-bach/Axiom/internal/config"
+	"github.com/may-bach/Axiom/internal/session"
+)
+
+// Account is one Flattrade login: its credentials, its session token and
+// the Client that signs requests with both. The rate limiter and circuit
+// breaker guard the shared API host and stay package-wide.
+type Account struct {
+	Name    string
+	Creds   config.Credentials
+	Session session.Session
+	Client  *Client
+}
+
+// Client is the PiConnect API for one Account, as both a QuoteProvider
+// and a Broker.
+type Client struct {
+	account *Account
+}
+
+// NewAccount returns the named account with creds and no session yet.
+func NewAccount(name string, creds config.Credentials) *Account {
+	a := &Account{Name: name, Creds: creds}
+	a.Client = &Client{account: a}
+	return a
+}
+
+// Login exchanges the account's request_code for a session token,
+// reusing the token already issued for the same code.
+func (a *Account) Login(ctx context.Context) error {
+	token, err := auth.GetSessionToken(ctx, a.Creds.APIKey, a.Creds.RequestCode, a.Creds.SecretKey)
+	if err != nil {
+		return err
+	}
+	a.Session.Set(token)
+	return nil
+}
+
+// Refresh fetches a new session token, bypassing the cached one, and
+// stores it.
+func (a *Account) Refresh(ctx context.Context) (string, error) {
+	auth.Invalidate(a.Creds.APIKey)
+	if err := a.Login(ctx); err != nil {
+		return "", err
+	}
+	return a.Session.Get(), nil
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the API while the circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("API circuit open - requests suspended")
+
+// CircuitState is the state of a Breaker.
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // requests flow normally
+	CircuitOpen                         // requests fail fast until the cooldown passes
+	CircuitHalfOpen                     // one probe request is in flight
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// Default breaker settings.
+const (
+	DefaultCircuitFailures = 5
+	DefaultCircuitCooldown = 30 * time.Second
+	maxCircuitCooldown     = 5 * time.Minute
+)
+
+// Breaker opens after threshold consecutive transport failures. Once
+// cooldown has passed it lets a single probe through: success closes it,
+// failure reopens it with the cooldown doubled (up to maxCircuitCooldown).
+// A threshold <= 0 disables it.
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	wait      time.Duration // current cooldown, grows on failed probes
+	failures  int
+	state     CircuitState
+	openedAt  time.Time
+	onChange  func(CircuitState)
+	now       func() time.Time
+}
+
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown, wait: cooldown, now: time.Now}
+}
+
+// Allow reports whether a request may be sent, moving an open breaker to
+// half-open once its cooldown has passed.
+func (b *Breaker) Allow() error {
+	var err error
+	b.transition(func() {
+		switch b.state {
+		case CircuitOpen:
+			if b.now().Sub(b.openedAt) < b.wait {
+				err = ErrCircuitOpen
+				return
+			}
+			b.state = CircuitHalfOpen
+		case CircuitHalfOpen:
+			err = ErrCircuitOpen // a probe is already in flight
+		}
+	})
+	return err
+}
+
+// Record reports the outcome of a request let through by Allow.
+func (b *Breaker) Record(ok bool) {
+	b.transition(func() {
+		if ok {
+			b.failures = 0
+			b.wait = b.cooldown
+			b.state = CircuitClosed
+			return
+		}
+
+		b.failures++
+		switch {
+		case b.state == CircuitHalfOpen:
+			b.wait = min(2*b.wait, maxCircuitCooldown)
+			b.open()
+		case b.threshold > 0 && b.failures >= b.threshold:
+			b.open()
+		}
+	})
+}
+
+// Abandon reports that a request let through by Allow ended without an
+// outcome, e.g. because its context was cancelled. A half-open breaker
+// returns to open so the next Allow probes again straight away.
+func (b *Breaker) Abandon() {
+	b.transition(func() {
+		if b.state == CircuitHalfOpen {
+			b.state = CircuitOpen
+		}
+	})
+}
+
+// State returns the breaker's current state.
+func (b *Breaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Configure replaces the threshold and base cooldown and closes the
+// breaker.
+func (b *Breaker) Configure(threshold int, cooldown time.Duration) {
+	b.transition(func() {
+		b.threshold, b.cooldown, b.wait = threshold, cooldown, cooldown
+		b.failures = 0
+		b.state = CircuitClosed
+	})
+}
+
+// OnChange registers fn to be called whenever the state changes. It runs
+// on the goroutine that caused the change, without the breaker's lock.
+func (b *Breaker) OnChange(fn func(CircuitState)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onChange = fn
+}
+
+// open must be called with b.mu held.
+func (b *Breaker) open() {
+	b.openedAt = b.now()
+	b.state = CircuitOpen
+}
+
+// transition runs f under b.mu and then reports any state change.
+func (b *Breaker) transition(f func()) {
+	b.mu.Lock()
+	before := b.state
+	f()
+	after, fn := b.state, b.onChange
+	b.mu.Unlock()
+
+	if after != before && fn != nil {
+		fn(after)
+	}
+}
+
+var breaker = NewBreaker(DefaultCircuitFailures, DefaultCircuitCooldown)
+
+// SetCircuitBreaker configures the breaker applied to MakeRequest; a
+// threshold <= 0 disables it.
+func SetCircuitBreaker(threshold int, cooldown time.Duration) {
+	breaker.Configure(threshold, cooldown)
+}
+
+// Circuit returns the state of the breaker applied to MakeRequest.
+func Circuit() CircuitState {
+	return breaker.State()
+}
+
+// OnCircuitChange registers fn to be called when the breaker changes state.
+func OnCircuitChange(fn func(CircuitState)) {
+	breaker.OnChange(fn)
+}
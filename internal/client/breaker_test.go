@@ -0,0 +1,100 @@
+package client
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestBreaker(threshold int, cooldown time.Duration) (*Breaker, *time.Time) {
+	now := time.Date(2024, 1, 1, 9, 15, 0, 0, time.UTC)
+	b := NewBreaker(threshold, cooldown)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	b, _ := newTestBreaker(3, time.Minute)
+
+	b.Record(false)
+	b.Record(false)
+	b.Record(true) // a success resets the count
+	b.Record(false)
+	b.Record(false)
+	if b.State() != CircuitClosed {
+		t.Fatalf("state = %s after 2 consecutive failures, want closed", b.State())
+	}
+
+	b.Record(false)
+	if b.State() != CircuitOpen {
+		t.Fatalf("state = %s after 3 consecutive failures, want open", b.State())
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Allow while open = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	b, now := newTestBreaker(1, time.Minute)
+	var changes []CircuitState
+	b.OnChange(func(s CircuitState) { changes = append(changes, s) })
+
+	b.Record(false)
+	*now = now.Add(time.Minute)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe refused after cooldown: %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second request during probe = %v, want ErrCircuitOpen", err)
+	}
+
+	// A failed probe reopens with the cooldown doubled.
+	b.Record(false)
+	*now = now.Add(time.Minute)
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("probe allowed before the doubled cooldown: %v", err)
+	}
+	*now = now.Add(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe refused after the doubled cooldown: %v", err)
+	}
+
+	b.Record(true)
+	if b.State() != CircuitClosed {
+		t.Fatalf("state = %s after a successful probe, want closed", b.State())
+	}
+
+	want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if !slices.Equal(changes, want) {
+		t.Errorf("changes = %v, want %v", changes, want)
+	}
+}
+
+func TestBreakerAbandonedProbeRetries(t *testing.T) {
+	b, now := newTestBreaker(1, time.Minute)
+	b.Record(false)
+	*now = now.Add(time.Minute)
+
+	if err := b.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	b.Abandon()
+	if b.State() != CircuitOpen {
+		t.Fatalf("state = %s after abandoned probe, want open", b.State())
+	}
+	if err := b.Allow(); err != nil {
+		t.Errorf("no new probe after an abandoned one: %v", err)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b, _ := newTestBreaker(0, time.Minute)
+	for range 100 {
+		b.Record(false)
+	}
+	if err := b.Allow(); err != nil {
+		t.Errorf("disabled breaker refused a request: %v", err)
+	}
+}
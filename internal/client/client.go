@@ -3,22 +3,46 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
+)
+
+// BaseURL is the PiConnect API root. It is a variable so tests can point
+// the client at a local server.
+var BaseURL = "https://piconnect.flattrade.in/PiConnectTP"
+
+// Side is the direction of an order.
+type Side string
 
-	"github.com/may-bach/Axiom/internal/auth"
-	"github.com/may-bach/Axiom/internal/config"
-	"github.com/may-bach/Axiom/internal/session"
+const (
+	Buy  Side = "BUY"
+	Sell Side = "SELL"
 )
 
+// Code returns the "trantype" value the API expects for s.
+func (s Side) Code() (string, error) {
+	switch s {
+	case Buy:
+		return "B", nil
+	case Sell:
+		return "S", nil
+	}
+	return "", fmt.Errorf("invalid order side %q", string(s))
+}
+
+// Product types accepted in the "prd" field.
 const (
-	BaseURL = "https://piconnect.flattrade.in/PiConnectTP"
+	ProductCNC = "C" // delivery
+	ProductMIS = "I" // intraday, required for leverage and shorts
+	ProductBO  = "B" // bracket order, intraday with exchange-managed target and SL legs
 )
 
 type APIResponse struct {
@@ -28,22 +52,53 @@ type APIResponse struct {
 
 type SearchResult struct {
 	Stat   string `json:"stat"`
+	Emsg   string `json:"emsg"`
 	Values []struct {
 		Tsym  string `json:"tsym"`
 		Token string `json:"token"`
+		Ti    string `json:"ti"` // tick size
+		Ls    string `json:"ls"` // lot size
 	} `json:"values"`
 }
 
-func MakeRequest(endpoint string, payload map[string]string) ([]byte, error) {
-	token := session.Get()
+// MakeRequest posts payload to endpoint through the rate limiter and the
+// circuit breaker. Network errors and 5xx responses count as failures
+// towards opening the circuit; while it is open MakeRequest returns
+// ErrCircuitOpen without contacting the API.
+func (c *Client) MakeRequest(ctx context.Context, endpoint string, payload map[string]string) ([]byte, error) {
+	if err := breaker.Allow(); err != nil {
+		return nil, err
+	}
+	body, err := c.doRequest(ctx, endpoint, payload)
+
+	var te *transportError
+	switch {
+	case ctx.Err() != nil:
+		breaker.Abandon()
+	case errors.As(err, &te):
+		breaker.Record(false)
+	default:
+		breaker.Record(true)
+	}
+	return body, err
+}
+
+// transportError is a failure to reach the API at all: a network error or
+// a 5xx response.
+type transportError struct{ err error }
+
+func (e *transportError) Error() string { return e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
+
+func (c *Client) doRequest(ctx context.Context, endpoint string, payload map[string]string) ([]byte, error) {
+	token := c.account.Session.Get()
 	if token == "" {
 		return nil, fmt.Errorf("no session token - authenticate first")
 	}
 
-	// Load UID from .env (required!)
-	uid := os.Getenv("FLAT_USER_ID")
+	uid := c.account.Creds.UserID
 	if uid == "" {
-		return nil, fmt.Errorf("FLAT_USER_ID missing in .env")
+		return nil, fmt.Errorf("user id not configured for account %q", c.account.Name)
 	}
 
 	// Inject common fields
@@ -51,152 +106,723 @@ func MakeRequest(endpoint string, payload map[string]string) ([]byte, error) {
 	payload["actid"] = uid
 	payload["source"] = "API"
 
-	jsonBody, err := json.Marshal(payload)
+	client := &http.Client{Timeout: 10 * time.Second}
+	body, err := c.post(ctx, client, endpoint, payload, token)
 	if err != nil {
 		return nil, err
 	}
 
-	finalBody := "jData=" + string(jsonBody) + "&jKey=" + token
+	raw := string(body)
 
-	url := BaseURL + endpoint
+	if strings.Contains(raw, "Session Expired") ||
+		strings.Contains(raw, "Invalid Session") ||
+		strings.Contains(raw, "Invalid User Id") ||
+		(strings.Contains(raw, "Not_Ok") && !isHalt(raw)) {
 
-	// Create request with timeout
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer([]byte(finalBody)))
+		// Re-authenticate, bypassing the cached token that just expired
+		newToken, authErr := c.account.Refresh(ctx)
+		if authErr != nil {
+			return nil, fmt.Errorf("re-auth failed: %v", authErr)
+		}
+
+		// Retry with new token
+		payload["jKey"] = newToken // update payload (though not strictly needed)
+		body, err = c.post(ctx, client, endpoint, payload, newToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return body, nil
+}
+
+// post sends one "jData=<payload>&jKey=<token>" request to endpoint and
+// returns the response body, recording the exchange in the debug log.
+func (c *Client) post(ctx context.Context, client *http.Client, endpoint string, payload map[string]string, token string) (body []byte, err error) {
+	status := 0
+	defer func() { c.debugExchange(endpoint, payload, token, status, body, err) }()
+
+	jsonBody, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
+	finalBody := "jData=" + string(jsonBody) + "&jKey=" + token
 
+	req, err := http.NewRequestWithContext(ctx, "POST", BaseURL+endpoint, bytes.NewBuffer([]byte(finalBody)))
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
+	if err := limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	defer func() { latency.Record(time.Since(start)) }()
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %v", err)
+		return nil, &transportError{fmt.Errorf("request failed: %v", err)}
 	}
 	defer resp.Body.Close()
+	status = resp.StatusCode
+	if resp.StatusCode >= 500 {
+		return nil, &transportError{fmt.Errorf("server error: %s", resp.Status)}
+	}
+
+	return io.ReadAll(resp.Body)
+}
 
-	body, err := io.ReadAll(resp.Body)
+func (c *Client) SearchScrip(ctx context.Context, exch, searchText string) ([]byte, error) {
+	payload := map[string]string{
+		"exch":  exch,
+		"stext": searchText,
+	}
+	respBytes, err := c.MakeRequest(ctx, "/SearchScrip", payload)
 	if err != nil {
 		return nil, err
 	}
+	return respBytes, nil
+}
 
-	raw := string(body)
+// Quote is the parsed /GetQuotes response. Flattrade sends every number
+// as a string; missing or empty fields parse as zero.
+type Quote struct {
+	LTP           float64
+	Open          float64
+	High          float64
+	Low           float64
+	Close         float64   // previous close
+	AvgPrice      float64   // average traded price for the day
+	ChangePercent float64   // change from Close, in percent
+	Bid           float64   // best bid, zero if the book side is empty
+	Ask           float64   // best ask, zero if the book side is empty
+	Volume        int64     // cumulative day volume
+	FeedTime      time.Time // zero if the response carried no feed time
+	TickSize      float64   // zero if the response carried no tick size
+}
 
-	if strings.Contains(raw, "Session Expired") ||
-		strings.Contains(raw, "Invalid Session") ||
-		strings.Contains(raw, "Invalid User Id") ||
-		strings.Contains(raw, "Not_Ok") {
+// quoteJSON mirrors the string-typed /GetQuotes fields.
+type quoteJSON struct {
+	Lp  string `json:"lp"`  // last price
+	Ltp string `json:"ltp"` // fallback
+	O   string `json:"o"`
+	H   string `json:"h"`
+	L   string `json:"l"`
+	C   string `json:"c"`
+	Ap  string `json:"ap"`
+	Pc  string `json:"pc"`
+	V   string `json:"v"`
+	Ft  string `json:"ft"` // feed time, epoch seconds
+	Ti  string `json:"ti"`
+	Bp1 string `json:"bp1"`
+	Sp1 string `json:"sp1"`
+}
 
-		// Re-authenticate
-		newToken, authErr := auth.GetSessionToken(config.C.APIKey, config.C.RequestCode, config.C.SecretKey)
-		if authErr != nil {
-			return nil, fmt.Errorf("re-auth failed: %v", authErr)
-		}
+// UnmarshalJSON parses a /GetQuotes body. Only the last price is
+// required: it fails if the body has none or it is not a number. Any other
+// field that is not a number is logged and left zero.
+func (q *Quote) UnmarshalJSON(data []byte) error {
+	var r quoteJSON
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+
+	lp := r.Lp
+	if lp == "" {
+		lp = r.Ltp
+	}
+	if lp == "" {
+		return fmt.Errorf("no price field found")
+	}
+	ltp, err := strconv.ParseFloat(lp, 64)
+	if err != nil {
+		return fmt.Errorf("lp parse error: %v - value: %s", err, lp)
+	}
 
-		session.Set(newToken)
+	var p quoteParser
+	out := Quote{
+		LTP:           ltp,
+		Open:          p.float("o", r.O),
+		High:          p.float("h", r.H),
+		Low:           p.float("l", r.L),
+		Close:         p.float("c", r.C),
+		AvgPrice:      p.float("ap", r.Ap),
+		ChangePercent: p.float("pc", r.Pc),
+		Bid:           p.float("bp1", r.Bp1),
+		Ask:           p.float("sp1", r.Sp1),
+		Volume:        p.int("v", r.V),
+		TickSize:      p.float("ti", r.Ti),
+	}
+	if secs := p.int("ft", r.Ft); secs > 0 {
+		out.FeedTime = time.Unix(secs, 0)
+	}
+	if len(p.bad) > 0 {
+		log.Printf("Quote: ignoring malformed fields %s", strings.Join(p.bad, ", "))
+	}
+	*q = out
+	return nil
+}
 
-		// Retry with new token
-		payload["jKey"] = newToken // update payload (though not strictly needed)
-		jsonBody, _ = json.Marshal(payload)
-		finalBody = "jData=" + string(jsonBody) + "&jKey=" + newToken
+// quoteParser parses optional string fields, zeroing and noting the ones
+// that are not numbers.
+type quoteParser struct{ bad []string }
 
-		req, _ = http.NewRequest("POST", url, bytes.NewBuffer([]byte(finalBody)))
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+func (p *quoteParser) float(name, s string) float64 {
+	if s == "" {
+		return 0
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		p.bad = append(p.bad, fmt.Sprintf("%s=%q", name, s))
+		return 0
+	}
+	return v
+}
 
-		resp, err = client.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("retry request failed: %v", err)
+func (p *quoteParser) int(name, s string) int64 {
+	if s == "" {
+		return 0
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		p.bad = append(p.bad, fmt.Sprintf("%s=%q", name, s))
+		return 0
+	}
+	return v
+}
+
+// ErrInstrumentHalted is returned by GetQuote when the exchange has halted
+// or suspended trading in the scrip. Unlike other quote errors it is not
+// transient: retrying every tick only fails again until trading resumes.
+var ErrInstrumentHalted = errors.New("instrument halted")
+
+// haltKeywords mark a /GetQuotes failure as a trading halt rather than a
+// transient or session error.
+var haltKeywords = []string{"halt", "suspend"}
+
+// isHalt reports whether msg reports a trading halt. Halts are not
+// session errors, so they must not trigger a re-authentication.
+func isHalt(msg string) bool {
+	msg = strings.ToLower(msg)
+	for _, k := range haltKeywords {
+		if strings.Contains(msg, k) {
+			return true
 		}
-		defer resp.Body.Close()
+	}
+	return false
+}
 
-		body, err = io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
+func (c *Client) GetQuote(ctx context.Context, exch, token string) (Quote, error) {
+	payload := map[string]string{
+		"exch":  exch,
+		"token": token,
+	}
+
+	respBytes, err := c.MakeRequest(ctx, "/GetQuotes", payload)
+	if err != nil {
+		return Quote{}, err
+	}
+	return parseQuote(respBytes)
+}
+
+// parseQuote decodes a /GetQuotes response body.
+func parseQuote(body []byte) (Quote, error) {
+	raw := string(body)
+
+	var r APIResponse
+	if err := DecodeResponse(body, &r); err != nil {
+		return Quote{}, fmt.Errorf("JSON unmarshal failed: %v - raw: %s", err, raw)
+	}
+	if r.Stat != "Ok" {
+		if isHalt(r.Emsg) {
+			return Quote{}, fmt.Errorf("%w: %s", ErrInstrumentHalted, r.Emsg)
 		}
+		return Quote{}, fmt.Errorf("GetQuotes failed: stat=%s emsg=%s - raw: %s", r.Stat, r.Emsg, raw)
+	}
 
-		raw = string(body)
+	var q Quote
+	if err := DecodeResponse(body, &q); err != nil {
+		return Quote{}, fmt.Errorf("%v - raw: %s", err, raw)
 	}
+	return q, nil
+}
 
-	return body, nil
+// GetLTP is GetQuote for callers that only need the last price.
+func (c *Client) GetLTP(ctx context.Context, exch, token string) (float64, error) {
+	q, err := c.GetQuote(ctx, exch, token)
+	if err != nil {
+		return 0, err
+	}
+	return q.LTP, nil
 }
 
-func SearchScrip(exch, searchText string) ([]byte, error) {
-	payload := map[string]string{
-		"exch":  exch,
-		"stext": searchText,
+type OrderResponse struct {
+	Stat       string `json:"stat"`
+	Emsg       string `json:"emsg"`
+	NorenOrdNo string `json:"norenordno"`
+}
+
+// Price types accepted in the "prctyp" field.
+const (
+	PriceMarket   = "MKT"
+	PriceLimit    = "LMT"
+	PriceSLLimit  = "SL-LMT" // stop-loss limit, needs Trigger
+	PriceSLMarket = "SL-MKT" // stop-loss market, needs Trigger
+)
+
+// Validities accepted in the "ret" field.
+const (
+	ValidityDay = "DAY"
+	ValidityIOC = "IOC" // fill immediately or cancel
+)
+
+// OrderParams describes an order. Empty PriceType and Validity default to
+// a DAY market order.
+type OrderParams struct {
+	Symbol    string
+	Tsym      string // trading symbol; Symbol + "-EQ" when empty
+	Token     string
+	Side      Side
+	Qty       int
+	Product   string  // ProductCNC, ProductMIS or ProductBO
+	PriceType string  // PriceMarket, PriceLimit, PriceSLLimit or PriceSLMarket
+	Price     float64 // limit price; ignored for market types
+	Trigger   float64 // trigger price; only used by the SL types
+	Validity  string  // ValidityDay or ValidityIOC
+}
+
+// PlaceOrder submits an order and returns the broker's order number.
+func (c *Client) PlaceOrder(ctx context.Context, p OrderParams) (string, error) {
+	payload, err := orderPayload(p)
+	if err != nil {
+		return "", err
+	}
+	return c.submitOrder(ctx, p.Symbol, payload)
+}
+
+// BracketParams describes a bracket order: an entry plus a book-profit and
+// a stop-loss leg that the exchange places and manages once it fills. The
+// entry's Product is always ProductBO.
+type BracketParams struct {
+	OrderParams
+	TargetPoints float64 // book-profit distance from the fill, in price units
+	StopPoints   float64 // stop-loss distance from the fill, in price units
+}
+
+// bracketPayload builds the /PlaceOrder jData fields for a bracket order.
+func bracketPayload(p BracketParams) (map[string]string, error) {
+	if p.TargetPoints <= 0 || p.StopPoints <= 0 {
+		return nil, fmt.Errorf("bracket order needs positive target and stop distances")
 	}
-	respBytes, err := MakeRequest("/SearchScrip", payload)
+	p.Product = ProductBO
+	payload, err := orderPayload(p.OrderParams)
 	if err != nil {
 		return nil, err
 	}
-	return respBytes, nil
+	payload["bpprc"] = strconv.FormatFloat(p.TargetPoints, 'f', 2, 64)
+	payload["blprc"] = strconv.FormatFloat(p.StopPoints, 'f', 2, 64)
+	return payload, nil
 }
 
-type TouchlineResponse struct {
-	Stat string `json:"stat"`
-	Lp   string `json:"lp"`  // Last Price
-	Ltp  string `json:"ltp"` // fallback
-	Emsg string `json:"emsg"`
+// PlaceBracketOrder submits a bracket order and returns the entry's order
+// number. The target and stop legs are then managed exchange-side.
+func (c *Client) PlaceBracketOrder(ctx context.Context, p BracketParams) (string, error) {
+	payload, err := bracketPayload(p)
+	if err != nil {
+		return "", err
+	}
+	return c.submitOrder(ctx, p.Symbol, payload)
 }
 
-func GetLTP(exch, token string) (float64, error) {
+// ExitBracketOrder closes an open bracket position at market, cancelling
+// its remaining legs.
+func (c *Client) ExitBracketOrder(ctx context.Context, orderNo string) error {
 	payload := map[string]string{
-		"exch":  exch,
-		"token": token,
+		"norenordno": orderNo,
+		"prd":        ProductBO,
 	}
 
-	respBytes, err := MakeRequest("/GetQuotes", payload)
+	respBytes, err := c.MakeRequest(ctx, "/ExitSNOOrder", payload)
 	if err != nil {
-		return 0, err
+		return err
+	}
+
+	var r APIResponse
+	if err := DecodeResponse(respBytes, &r); err != nil {
+		return fmt.Errorf("exit bracket unmarshal failed: %v - raw: %s", err, string(respBytes))
+	}
+	if r.Stat != "Ok" {
+		return fmt.Errorf("exit bracket %s failed: %s", orderNo, r.Emsg)
+	}
+	return nil
+}
+
+// submitOrder sends a /PlaceOrder payload and returns the order number.
+func (c *Client) submitOrder(ctx context.Context, sym string, payload map[string]string) (string, error) {
+	respBytes, err := c.MakeRequest(ctx, "/PlaceOrder", payload)
+	if err != nil {
+		return "", err
 	}
 
 	raw := string(respBytes)
 
-	var qr TouchlineResponse
-	if err := json.Unmarshal(respBytes, &qr); err != nil {
-		return 0, fmt.Errorf("JSON unmarshal failed: %v - raw: %s", err, raw)
+	var or OrderResponse
+	if err := DecodeResponse(respBytes, &or); err != nil {
+		return "", fmt.Errorf("order unmarshal failed: %v - raw: %s", err, raw)
 	}
 
-	if qr.Stat != "Ok" {
-		return 0, fmt.Errorf("GetQuotes failed: stat=%s emsg=%s - raw: %s", qr.Stat, qr.Emsg, raw)
+	if or.Stat != "Ok" {
+		return "", &OrderRejection{Reason: ClassifyRejection(or.Emsg), Message: or.Emsg}
+	}
+
+	fmt.Printf("Order placed successfully for %s - Order ID: %s\n", sym, or.NorenOrdNo)
+	return or.NorenOrdNo, nil
+}
+
+// orderPayload builds the /PlaceOrder jData fields (before MakeRequest adds
+// uid/actid/source).
+func orderPayload(p OrderParams) (map[string]string, error) {
+	trantype, err := p.Side.Code()
+	if err != nil {
+		return nil, err
+	}
+	if p.Qty <= 0 {
+		return nil, fmt.Errorf("invalid order quantity %d", p.Qty)
+	}
+
+	priceType := p.PriceType
+	if priceType == "" {
+		priceType = PriceMarket
+	}
+	validity := p.Validity
+	if validity == "" {
+		validity = ValidityDay
+	}
+	tsym := p.Tsym
+	if tsym == "" {
+		tsym = p.Symbol + "-EQ"
 	}
 
-	priceStr := qr.Lp
-	if priceStr == "" {
-		priceStr = qr.Ltp
+	prc, trgprc := "0", "0"
+	switch priceType {
+	case PriceMarket:
+	case PriceLimit:
+		prc = strconv.FormatFloat(p.Price, 'f', 2, 64)
+	case PriceSLLimit, PriceSLMarket:
+		if p.Trigger <= 0 {
+			return nil, fmt.Errorf("%s order needs a trigger price", priceType)
+		}
+		trgprc = strconv.FormatFloat(p.Trigger, 'f', 2, 64)
+		if priceType == PriceSLLimit {
+			prc = strconv.FormatFloat(p.Price, 'f', 2, 64)
+		}
+	default:
+		return nil, fmt.Errorf("invalid price type %q", priceType)
 	}
-	if priceStr == "" {
-		return 0, fmt.Errorf("no price field found - raw: %s", raw)
+
+	switch validity {
+	case ValidityDay, ValidityIOC:
+	default:
+		return nil, fmt.Errorf("invalid validity %q", validity)
 	}
 
-	ltp, err := strconv.ParseFloat(priceStr, 64)
+	return map[string]string{
+		"exch":     "NSE",
+		"tsym":     tsym,
+		"qty":      fmt.Sprint(p.Qty),
+		"prc":      prc,
+		"prd":      p.Product,
+		"trgprc":   trgprc,
+		"prctyp":   priceType,
+		"ret":      validity,
+		"trantype": trantype, // "B" or "S"
+	}, nil
+}
+
+// Limits is the account's funds as reported by /Limits.
+type Limits struct {
+	Cash       float64 // opening cash balance
+	Payin      float64 // funds added today
+	Collateral float64 // margin from pledged holdings
+	MarginUsed float64
+}
+
+// Available is the margin left for new orders.
+func (l Limits) Available() float64 {
+	return l.Cash + l.Payin + l.Collateral - l.MarginUsed
+}
+
+type limitsResponse struct {
+	Stat       string `json:"stat"`
+	Emsg       string `json:"emsg"`
+	Cash       string `json:"cash"`
+	Payin      string `json:"payin"`
+	Collateral string `json:"brkcollamt"`
+	MarginUsed string `json:"marginused"`
+}
+
+// GetLimits returns the account's cash and margin usage.
+func (c *Client) GetLimits(ctx context.Context) (Limits, error) {
+	respBytes, err := c.MakeRequest(ctx, "/Limits", map[string]string{})
 	if err != nil {
-		return 0, fmt.Errorf("price parse error: %v - value: %s", err, priceStr)
+		return Limits{}, err
+	}
+	return parseLimits(respBytes)
+}
+
+func parseLimits(body []byte) (Limits, error) {
+	var lr limitsResponse
+	if err := DecodeResponse(body, &lr); err != nil {
+		return Limits{}, fmt.Errorf("limits unmarshal failed: %v - raw: %s", err, body)
+	}
+	if lr.Stat != "Ok" {
+		return Limits{}, fmt.Errorf("limits failed: %s", lr.Emsg)
 	}
 
-	return ltp, nil
+	var l Limits
+	for _, f := range []struct {
+		dst *float64
+		s   string
+	}{{&l.Cash, lr.Cash}, {&l.Payin, lr.Payin}, {&l.Collateral, lr.Collateral}, {&l.MarginUsed, lr.MarginUsed}} {
+		if f.s == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(f.s, 64)
+		if err != nil {
+			return Limits{}, fmt.Errorf("limits: invalid amount %q", f.s)
+		}
+		*f.dst = v
+	}
+	return l, nil
 }
 
-type OrderResponse struct {
+// Position is one net position from the account's position book.
+type Position struct {
+	Exch     string
+	Tsym     string
+	Product  string
+	NetQty   int // positive long, negative short
+	AvgPrice float64
+}
+
+type positionEntry struct {
+	Stat      string `json:"stat"`
+	Emsg      string `json:"emsg"`
+	Exch      string `json:"exch"`
+	Tsym      string `json:"tsym"`
+	Prd       string `json:"prd"`
+	NetQty    string `json:"netqty"`
+	NetAvgPrc string `json:"netavgprc"`
+}
+
+// GetPositions returns the account's net positions, including those
+// carried over from earlier sessions.
+func (c *Client) GetPositions(ctx context.Context) ([]Position, error) {
+	respBytes, err := c.MakeRequest(ctx, "/PositionBook", map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+	return parsePositions(respBytes)
+}
+
+func parsePositions(body []byte) ([]Position, error) {
+	var book []positionEntry
+	if err := json.Unmarshal(body, &book); err != nil {
+		var e positionEntry
+		if json.Unmarshal(body, &e) == nil && e.Stat != "" {
+			// An empty book is reported as an error object.
+			if strings.Contains(strings.ToLower(e.Emsg), "no data") {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("position book failed: %s", e.Emsg)
+		}
+		return nil, fmt.Errorf("position book unmarshal failed: %v - raw: %s", err, body)
+	}
+
+	positions := make([]Position, 0, len(book))
+	for _, e := range book {
+		if e.Stat != "Ok" {
+			return nil, fmt.Errorf("position book failed: %s", e.Emsg)
+		}
+		qty, err := strconv.Atoi(e.NetQty)
+		if err != nil {
+			return nil, fmt.Errorf("position book: invalid netqty %q for %s", e.NetQty, e.Tsym)
+		}
+		avg, _ := strconv.ParseFloat(e.NetAvgPrc, 64)
+		positions = append(positions, Position{Exch: e.Exch, Tsym: e.Tsym, Product: e.Prd, NetQty: qty, AvgPrice: avg})
+	}
+	return positions, nil
+}
+
+// Order statuses reported by /SingleOrdHist.
+const (
+	StatusOpen      = "OPEN"
+	StatusComplete  = "COMPLETE"
+	StatusRejected  = "REJECTED"
+	StatusCancelled = "CANCELED"
+)
+
+// OrderStatus is the latest state of an order.
+type OrderStatus struct {
+	OrderNo   string
+	Exch      string
+	Tsym      string
+	PriceType string
+	Validity  string
+	Status    string
+	FilledQty int
+	AvgPrice  float64
+	Reason    string // rejection reason, if any
+}
+
+type orderHistoryEntry struct {
 	Stat       string `json:"stat"`
 	Emsg       string `json:"emsg"`
 	NorenOrdNo string `json:"norenordno"`
+	Exch       string `json:"exch"`
+	Tsym       string `json:"tsym"`
+	PrcTyp     string `json:"prctyp"`
+	Ret        string `json:"ret"`
+	Status     string `json:"status"`
+	FillShares string `json:"fillshares"`
+	AvgPrc     string `json:"avgprc"`
+	RejReason  string `json:"rejreason"`
 }
 
-func PlaceOrder(sym, token, buySell, orderType string, qty int) error {
+// GetOrderStatus returns the most recent history entry for orderNo.
+func (c *Client) GetOrderStatus(ctx context.Context, orderNo string) (OrderStatus, error) {
 	payload := map[string]string{
-		"exch":     "NSE",
-		"tsym":     sym + "-EQ",
-		"qty":      fmt.Sprint(qty),
-		"prc":      "0", // market order
-		"prd":      "C", // CNC
-		"trgprc":   "0",
-		"prctyp":   orderType, // "MKT"
-		"ret":      "DAY",
-		"trantype": buySell, // "B" or "S"
+		"norenordno": orderNo,
 	}
 
-	respBytes, err := MakeRequest("/PlaceOrder", payload)
+	respBytes, err := c.MakeRequest(ctx, "/SingleOrdHist", payload)
+	if err != nil {
+		return OrderStatus{}, err
+	}
+
+	raw := string(respBytes)
+
+	// Success is an array of history entries, newest first; errors are
+	// a single object.
+	var hist []orderHistoryEntry
+	if err := json.Unmarshal(respBytes, &hist); err != nil {
+		var e orderHistoryEntry
+		if json.Unmarshal(respBytes, &e) == nil && e.Stat != "" {
+			return OrderStatus{}, fmt.Errorf("order history failed: %s - raw: %s", e.Emsg, raw)
+		}
+		return OrderStatus{}, fmt.Errorf("order history unmarshal failed: %v - raw: %s", err, raw)
+	}
+	if len(hist) == 0 {
+		return OrderStatus{}, fmt.Errorf("empty order history for %s", orderNo)
+	}
+
+	latest := hist[0]
+	if latest.Stat != "Ok" {
+		return OrderStatus{}, fmt.Errorf("order history failed: %s - raw: %s", latest.Emsg, raw)
+	}
+
+	filled, _ := strconv.Atoi(latest.FillShares)
+	avg, _ := strconv.ParseFloat(latest.AvgPrc, 64)
+	return OrderStatus{
+		OrderNo:   orderNo,
+		Exch:      latest.Exch,
+		Tsym:      latest.Tsym,
+		PriceType: latest.PrcTyp,
+		Validity:  latest.Ret,
+		Status:    latest.Status,
+		FilledQty: filled,
+		AvgPrice:  avg,
+		Reason:    latest.RejReason,
+	}, nil
+}
+
+// Bracket leg types, as reported in an order book row's snoordt.
+const (
+	bracketLegTarget = "0"
+	bracketLegStop   = "1"
+)
+
+type orderBookEntry struct {
+	orderHistoryEntry
+	SnoNum   string `json:"snonum"`
+	SnoOrdTp string `json:"snoordt"`
+}
+
+// GetBracketLegs returns the latest state of the target and stop legs of
+// the bracket order entered as orderNo. A leg the order book does not list
+// yet comes back as a zero OrderStatus.
+func (c *Client) GetBracketLegs(ctx context.Context, orderNo string) (target, stop OrderStatus, err error) {
+	respBytes, err := c.MakeRequest(ctx, "/OrderBook", map[string]string{})
+	if err != nil {
+		return OrderStatus{}, OrderStatus{}, err
+	}
+	return parseBracketLegs(respBytes, orderNo)
+}
+
+// parseBracketLegs picks the legs of bracket orderNo out of an order book:
+// the rows whose snonum is the entry's order number.
+func parseBracketLegs(body []byte, orderNo string) (target, stop OrderStatus, err error) {
+	var book []orderBookEntry
+	if err := json.Unmarshal(body, &book); err != nil {
+		var e orderHistoryEntry
+		if json.Unmarshal(body, &e) == nil && e.Stat != "" {
+			// An empty book is reported as an error object.
+			if strings.Contains(strings.ToLower(e.Emsg), "no data") {
+				return OrderStatus{}, OrderStatus{}, nil
+			}
+			return OrderStatus{}, OrderStatus{}, fmt.Errorf("order book failed: %s", e.Emsg)
+		}
+		return OrderStatus{}, OrderStatus{}, fmt.Errorf("order book unmarshal failed: %v - raw: %s", err, body)
+	}
+
+	for _, row := range book {
+		if row.SnoNum != orderNo {
+			continue
+		}
+		filled, _ := strconv.Atoi(row.FillShares)
+		avg, _ := strconv.ParseFloat(row.AvgPrc, 64)
+		st := OrderStatus{
+			OrderNo:   row.NorenOrdNo,
+			Exch:      row.Exch,
+			Tsym:      row.Tsym,
+			PriceType: row.PrcTyp,
+			Validity:  row.Ret,
+			Status:    row.Status,
+			FilledQty: filled,
+			AvgPrice:  avg,
+			Reason:    row.RejReason,
+		}
+		switch row.SnoOrdTp {
+		case bracketLegTarget:
+			target = st
+		case bracketLegStop:
+			stop = st
+		}
+	}
+	return target, stop, nil
+}
+
+// ModifyOrder changes the price, trigger price and quantity of a resting
+// order, e.g. to ratchet an exchange-side stop. The order's exchange,
+// symbol, price type and validity are read back from its history.
+func (c *Client) ModifyOrder(ctx context.Context, orderNo string, newPrice, newTrigger float64, newQty int) error {
+	current, err := c.GetOrderStatus(ctx, orderNo)
+	if err != nil {
+		return fmt.Errorf("modify %s: %v", orderNo, err)
+	}
+
+	payload := map[string]string{
+		"norenordno": orderNo,
+		"exch":       current.Exch,
+		"tsym":       current.Tsym,
+		"prctyp":     current.PriceType,
+		"ret":        current.Validity,
+		"qty":        fmt.Sprint(newQty),
+		"prc":        strconv.FormatFloat(newPrice, 'f', 2, 64),
+		"trgprc":     strconv.FormatFloat(newTrigger, 'f', 2, 64),
+	}
+
+	respBytes, err := c.MakeRequest(ctx, "/ModifyOrder", payload)
 	if err != nil {
 		return err
 	}
@@ -204,14 +830,35 @@ func PlaceOrder(sym, token, buySell, orderType string, qty int) error {
 	raw := string(respBytes)
 
 	var or OrderResponse
-	if err := json.Unmarshal(respBytes, &or); err != nil {
-		return fmt.Errorf("order unmarshal failed: %v - raw: %s", err, raw)
+	if err := DecodeResponse(respBytes, &or); err != nil {
+		return fmt.Errorf("modify unmarshal failed: %v - raw: %s", err, raw)
 	}
 
 	if or.Stat != "Ok" {
-		return fmt.Errorf("place order failed: %s - raw: %s", or.Emsg, raw)
+		return fmt.Errorf("modify order failed: %s - raw: %s", or.Emsg, raw)
+	}
+	return nil
+}
+
+func (c *Client) CancelOrder(ctx context.Context, orderNo string) error {
+	payload := map[string]string{
+		"norenordno": orderNo,
 	}
 
-	fmt.Printf("Order placed successfully for %s - Order ID: %s\n", sym, or.NorenOrdNo)
+	respBytes, err := c.MakeRequest(ctx, "/CancelOrder", payload)
+	if err != nil {
+		return err
+	}
+
+	raw := string(respBytes)
+
+	var or OrderResponse
+	if err := DecodeResponse(respBytes, &or); err != nil {
+		return fmt.Errorf("cancel unmarshal failed: %v - raw: %s", err, raw)
+	}
+
+	if or.Stat != "Ok" {
+		return fmt.Errorf("cancel order failed: %s - raw: %s", or.Emsg, raw)
+	}
 	return nil
 }
@@ -0,0 +1,324 @@
+package client
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrderPayloadTrantype(t *testing.T) {
+	tests := []struct {
+		side Side
+		want string
+	}{
+		{Buy, "B"},
+		{Sell, "S"},
+	}
+	for _, tt := range tests {
+		p, err := orderPayload(OrderParams{Symbol: "SBIN", Side: tt.side, Qty: 10, Product: ProductMIS})
+		if err != nil {
+			t.Fatalf("orderPayload(%s): %v", tt.side, err)
+		}
+		if got := p["trantype"]; got != tt.want {
+			t.Errorf("trantype for %s = %q, want %q", tt.side, got, tt.want)
+		}
+	}
+}
+
+func TestOrderPayloadRejectsUnknownSide(t *testing.T) {
+	for _, side := range []Side{"", "B", "buy", "SHORT"} {
+		if _, err := orderPayload(OrderParams{Symbol: "SBIN", Side: side, Qty: 10, Product: ProductMIS}); err == nil {
+			t.Errorf("orderPayload accepted invalid side %q", side)
+		}
+	}
+}
+
+func TestOrderPayloadFields(t *testing.T) {
+	tests := []struct {
+		name string
+		p    OrderParams
+		want map[string]string
+	}{
+		{
+			name: "defaults to DAY market",
+			p:    OrderParams{Symbol: "SBIN", Side: Buy, Qty: 10, Product: ProductMIS, Price: 801},
+			want: map[string]string{
+				"exch": "NSE", "tsym": "SBIN-EQ", "qty": "10", "prc": "0", "prd": "I",
+				"trgprc": "0", "prctyp": "MKT", "ret": "DAY", "trantype": "B",
+			},
+		},
+		{
+			name: "IOC limit",
+			p:    OrderParams{Symbol: "INFY", Side: Sell, Qty: 3, Product: ProductCNC, PriceType: PriceLimit, Price: 1500.5, Validity: ValidityIOC},
+			want: map[string]string{
+				"exch": "NSE", "tsym": "INFY-EQ", "qty": "3", "prc": "1500.50", "prd": "C",
+				"trgprc": "0", "prctyp": "LMT", "ret": "IOC", "trantype": "S",
+			},
+		},
+		{
+			name: "stop-loss limit",
+			p:    OrderParams{Symbol: "SBIN", Side: Sell, Qty: 1, Product: ProductMIS, PriceType: PriceSLLimit, Price: 790, Trigger: 791.5},
+			want: map[string]string{
+				"exch": "NSE", "tsym": "SBIN-EQ", "qty": "1", "prc": "790.00", "prd": "I",
+				"trgprc": "791.50", "prctyp": "SL-LMT", "ret": "DAY", "trantype": "S",
+			},
+		},
+		{
+			name: "stop-loss market",
+			p:    OrderParams{Symbol: "SBIN", Side: Buy, Qty: 2, Product: ProductMIS, PriceType: PriceSLMarket, Trigger: 810},
+			want: map[string]string{
+				"exch": "NSE", "tsym": "SBIN-EQ", "qty": "2", "prc": "0", "prd": "I",
+				"trgprc": "810.00", "prctyp": "SL-MKT", "ret": "DAY", "trantype": "B",
+			},
+		},
+		{
+			name: "overridden trading symbol",
+			p:    OrderParams{Symbol: "TATAMOTORS", Tsym: "TMPV-EQ", Side: Buy, Qty: 1, Product: ProductMIS},
+			want: map[string]string{
+				"exch": "NSE", "tsym": "TMPV-EQ", "qty": "1", "prc": "0", "prd": "I",
+				"trgprc": "0", "prctyp": "MKT", "ret": "DAY", "trantype": "B",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := orderPayload(tt.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("payload has %d fields, want %d: %v", len(got), len(tt.want), got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderPayloadRejectsInvalidParams(t *testing.T) {
+	base := OrderParams{Symbol: "SBIN", Side: Buy, Qty: 1, Product: ProductMIS}
+	tests := []struct {
+		name   string
+		modify func(*OrderParams)
+	}{
+		{"zero qty", func(p *OrderParams) { p.Qty = 0 }},
+		{"unknown price type", func(p *OrderParams) { p.PriceType = "STOP" }},
+		{"unknown validity", func(p *OrderParams) { p.Validity = "GTC" }},
+		{"SL without trigger", func(p *OrderParams) { p.PriceType = PriceSLMarket }},
+	}
+	for _, tt := range tests {
+		p := base
+		tt.modify(&p)
+		if _, err := orderPayload(p); err == nil {
+			t.Errorf("%s: orderPayload accepted %+v", tt.name, p)
+		}
+	}
+}
+
+func TestBracketPayload(t *testing.T) {
+	p, err := bracketPayload(BracketParams{
+		OrderParams:  OrderParams{Symbol: "SBIN", Side: Buy, PriceType: PriceLimit, Qty: 5, Price: 801.25},
+		TargetPoints: 12, StopPoints: 6.4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"prd": ProductBO, "prctyp": "LMT", "prc": "801.25",
+		"bpprc": "12.00", "blprc": "6.40", "trantype": "B", "qty": "5",
+	}
+	for k, v := range want {
+		if p[k] != v {
+			t.Errorf("%s = %q, want %q", k, p[k], v)
+		}
+	}
+
+	if _, err := bracketPayload(BracketParams{OrderParams: OrderParams{Symbol: "SBIN", Side: Buy, Qty: 5}, TargetPoints: 10}); err == nil {
+		t.Error("bracketPayload accepted a missing stop distance")
+	}
+}
+
+func TestParseQuote(t *testing.T) {
+	body := `{"stat":"Ok","lp":"801.25","o":"795.00","h":"804.10","l":"793.55","c":"790.00",
+		"ap":"799.87","pc":"1.42","v":"1234567","ft":"1700000000","ti":"0.05","bp1":"801.20","sp1":"801.30"}`
+	q, err := parseQuote([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Quote{
+		LTP: 801.25, Open: 795, High: 804.1, Low: 793.55, Close: 790, AvgPrice: 799.87,
+		ChangePercent: 1.42, Bid: 801.2, Ask: 801.3, Volume: 1234567,
+		FeedTime: time.Unix(1700000000, 0), TickSize: 0.05,
+	}
+	if q != want {
+		t.Errorf("parseQuote = %+v, want %+v", q, want)
+	}
+}
+
+func TestParseQuoteToleratesEmptyFields(t *testing.T) {
+	q, err := parseQuote([]byte(`{"stat":"Ok","ltp":"12.5","o":"","v":"","bp1":"","ft":""}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.LTP != 12.5 {
+		t.Errorf("LTP = %v, want the ltp fallback 12.5", q.LTP)
+	}
+	if q.Open != 0 || q.Volume != 0 || q.Bid != 0 || !q.FeedTime.IsZero() {
+		t.Errorf("empty fields not zero: %+v", q)
+	}
+}
+
+func TestParseQuoteZeroesMalformedOptionalFields(t *testing.T) {
+	q, err := parseQuote([]byte(`{"stat":"Ok","lp":"10","o":"-","h":"11","v":"1.5","ft":"x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Quote{LTP: 10, High: 11}
+	if q != want {
+		t.Errorf("parseQuote = %+v, want %+v", q, want)
+	}
+}
+
+func TestParseQuoteErrors(t *testing.T) {
+	for name, body := range map[string]string{
+		"not ok":       `{"stat":"Not_Ok","emsg":"Session Expired"}`,
+		"no price":     `{"stat":"Ok","v":"10"}`,
+		"bad price":    `{"stat":"Ok","lp":"abc"}`,
+		"invalid json": `{"stat":`,
+	} {
+		if _, err := parseQuote([]byte(body)); err == nil {
+			t.Errorf("%s: parseQuote accepted %s", name, body)
+		}
+	}
+}
+
+func TestParseQuoteHalted(t *testing.T) {
+	_, err := parseQuote([]byte(`{"stat":"Not_Ok","emsg":"Trading in this Security is Suspended"}`))
+	if !errors.Is(err, ErrInstrumentHalted) {
+		t.Errorf("suspended scrip: err = %v, want ErrInstrumentHalted", err)
+	}
+	_, err = parseQuote([]byte(`{"stat":"Not_Ok","emsg":"Session Expired"}`))
+	if err == nil || errors.Is(err, ErrInstrumentHalted) {
+		t.Errorf("session error: err = %v, want a non-halt error", err)
+	}
+}
+
+func TestClassifyRejection(t *testing.T) {
+	for _, tc := range []struct {
+		emsg string
+		want RejectReason
+	}{
+		{"RMS:Margin Exceeds,Cash Available:0.00,Margin Used:5000", RejectInsufficientFunds},
+		{"Insufficient funds", RejectInsufficientFunds},
+		{"RMS:Blocked for trading in this scrip", RejectRMSBlock},
+		{"Market is closed", RejectMarketClosed},
+		{"Price not a multiple of tick size", RejectTickSize},
+		{"Quantity should be multiple of lot size", RejectLotSize},
+		{"Security is in ban period", RejectRMSBlock},
+		{"Order price is outside the circuit band", RejectUnknown},
+		{"Invalid order for BANKBARODA-EQ", RejectUnknown},
+		{"Something unexpected", RejectUnknown},
+	} {
+		if got := ClassifyRejection(tc.emsg); got != tc.want {
+			t.Errorf("ClassifyRejection(%q) = %s, want %s", tc.emsg, got, tc.want)
+		}
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	l, err := parseLimits([]byte(`{"stat":"Ok","cash":"50000.00","payin":"10000","brkcollamt":"","marginused":"15000.50"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Available() != 44999.5 {
+		t.Errorf("Available = %v, want 44999.5", l.Available())
+	}
+
+	if _, err := parseLimits([]byte(`{"stat":"Not_Ok","emsg":"Session Expired"}`)); err == nil {
+		t.Error("parseLimits accepted a failed response")
+	}
+}
+
+func TestParsePositions(t *testing.T) {
+	got, err := parsePositions([]byte(`[
+		{"stat":"Ok","exch":"NSE","tsym":"SBIN-EQ","prd":"C","netqty":"10","netavgprc":"801.50"},
+		{"stat":"Ok","exch":"NSE","tsym":"INFY-EQ","prd":"I","netqty":"-5","netavgprc":"1500"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Position{
+		{Exch: "NSE", Tsym: "SBIN-EQ", Product: "C", NetQty: 10, AvgPrice: 801.5},
+		{Exch: "NSE", Tsym: "INFY-EQ", Product: "I", NetQty: -5, AvgPrice: 1500},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("parsePositions = %+v, want %+v", got, want)
+	}
+
+	if got, err := parsePositions([]byte(`{"stat":"Not_Ok","emsg":"no data"}`)); err != nil || len(got) != 0 {
+		t.Errorf("empty book: %+v, %v; want no positions", got, err)
+	}
+	if _, err := parsePositions([]byte(`{"stat":"Not_Ok","emsg":"Session Expired"}`)); err == nil {
+		t.Error("parsePositions accepted a failed response")
+	}
+	if _, err := parsePositions([]byte(`[{"stat":"Ok","tsym":"SBIN-EQ","netqty":"x"}]`)); err == nil {
+		t.Error("parsePositions accepted a bad netqty")
+	}
+}
+
+func TestParseBracketLegs(t *testing.T) {
+	body := []byte(`[
+		{"stat":"Ok","norenordno":"11","snonum":"10","snoordt":"0","status":"OPEN","prctyp":"LMT"},
+		{"stat":"Ok","norenordno":"12","snonum":"10","snoordt":"1","status":"COMPLETE","fillshares":"5","avgprc":"98.95"},
+		{"stat":"Ok","norenordno":"21","snonum":"20","snoordt":"1","status":"OPEN"}
+	]`)
+	target, stop, err := parseBracketLegs(body, "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.OrderNo != "11" || target.Status != StatusOpen {
+		t.Errorf("target = %+v, want open order 11", target)
+	}
+	if stop.OrderNo != "12" || stop.Status != StatusComplete || stop.FilledQty != 5 || stop.AvgPrice != 98.95 {
+		t.Errorf("stop = %+v, want order 12 filled 5 @ 98.95", stop)
+	}
+
+	if target, stop, err := parseBracketLegs([]byte(`{"stat":"Not_Ok","emsg":"Error Occurred : 5 \"no data\""}`), "10"); err != nil || target.Status != "" || stop.Status != "" {
+		t.Errorf("empty book: %+v %+v %v, want no legs and no error", target, stop, err)
+	}
+	if _, _, err := parseBracketLegs([]byte(`{"stat":"Not_Ok","emsg":"Session Expired"}`), "10"); err == nil {
+		t.Error("parseBracketLegs accepted a failed response")
+	}
+}
+
+func TestDecodeResponseArrayError(t *testing.T) {
+	body := []byte(` [{"stat":"Not_Ok","emsg":"Session Expired : Invalid Session Key"}]`)
+
+	var r APIResponse
+	if err := DecodeResponse(body, &r); err != nil || r.Stat != "Not_Ok" || r.Emsg != "Session Expired : Invalid Session Key" {
+		t.Errorf("DecodeResponse = %+v, %v; want the wrapped error object", r, err)
+	}
+
+	_, err := parseQuote(body)
+	if err == nil || strings.Contains(err.Error(), "cannot unmarshal") || !strings.Contains(err.Error(), "emsg=Session Expired") {
+		t.Errorf("parseQuote err = %v, want the API error", err)
+	}
+	_, err = parseQuote([]byte(`[{"stat":"Not_Ok","emsg":"Security is suspended"}]`))
+	if !errors.Is(err, ErrInstrumentHalted) {
+		t.Errorf("array-shaped halt: err = %v, want ErrInstrumentHalted", err)
+	}
+	if _, err := parseLimits(body); err == nil || strings.Contains(err.Error(), "unmarshal") {
+		t.Errorf("parseLimits err = %v, want the API error", err)
+	}
+
+	for _, odd := range []string{`[]`, `["Not_Ok"]`, `[[]]`} {
+		if err := DecodeResponse([]byte(odd), &r); !errors.Is(err, ErrUnexpectedResponse) {
+			t.Errorf("DecodeResponse(%s) = %v, want ErrUnexpectedResponse", odd, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted replaces secrets in the debug log.
+const redacted = "[REDACTED]"
+
+// secretFields are payload keys whose values never reach the debug log.
+var secretFields = []string{"jKey", "uid", "actid", "pwd", "appkey", "susertoken"}
+
+var (
+	debugMu  sync.Mutex
+	debugOut io.Writer
+)
+
+// SetDebugLog writes a JSON line for every API request and its response to
+// w, or stops when w is nil. The session token and user id are redacted
+// from payloads and responses before anything is written.
+func SetDebugLog(w io.Writer) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	debugOut = w
+}
+
+// debugRecord is one line of the debug log.
+type debugRecord struct {
+	Time     time.Time         `json:"time"`
+	Account  string            `json:"account,omitempty"`
+	Endpoint string            `json:"endpoint"`
+	Payload  map[string]string `json:"payload"`
+	Status   int               `json:"status,omitempty"`
+	Response string            `json:"response,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// debugExchange logs one request to endpoint and its outcome when the
+// debug log is enabled.
+func (c *Client) debugExchange(endpoint string, payload map[string]string, token string, status int, body []byte, err error) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	if debugOut == nil {
+		return
+	}
+
+	secrets := []string{token, c.account.Creds.UserID}
+	rec := debugRecord{
+		Time:     time.Now(),
+		Account:  c.account.Name,
+		Endpoint: endpoint,
+		Payload:  make(map[string]string, len(payload)),
+		Status:   status,
+		Response: redact(string(body), secrets),
+	}
+	for k, v := range payload {
+		rec.Payload[k] = redact(v, secrets)
+	}
+	for _, k := range secretFields {
+		if _, ok := rec.Payload[k]; ok {
+			rec.Payload[k] = redacted
+		}
+	}
+	if err != nil {
+		rec.Error = redact(err.Error(), secrets)
+	}
+
+	line, mErr := json.Marshal(rec)
+	if mErr != nil {
+		return
+	}
+	debugOut.Write(append(line, '\n'))
+}
+
+// redact replaces every occurrence of each non-empty secret in s.
+func redact(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	return s
+}
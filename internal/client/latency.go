@@ -0,0 +1,67 @@
+package client
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// latencyWindow is how many of the most recent requests the latency
+// percentiles are taken over.
+const latencyWindow = 50
+
+// LatencyStats summarizes recent API request durations.
+type LatencyStats struct {
+	P50     time.Duration
+	P95     time.Duration
+	Samples int // requests the percentiles are taken over
+}
+
+// LatencyTracker keeps the durations of the last latencyWindow requests.
+type LatencyTracker struct {
+	mu      sync.Mutex
+	samples []time.Duration // ring buffer
+	next    int
+}
+
+// Record adds one request's duration.
+func (l *LatencyTracker) Record(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.samples) < latencyWindow {
+		l.samples = append(l.samples, d)
+		return
+	}
+	l.samples[l.next] = d
+	l.next = (l.next + 1) % latencyWindow
+}
+
+// Stats returns the percentiles over the recorded requests, zero before
+// any request completes.
+func (l *LatencyTracker) Stats() LatencyStats {
+	l.mu.Lock()
+	sorted := slices.Clone(l.samples)
+	l.mu.Unlock()
+	if len(sorted) == 0 {
+		return LatencyStats{}
+	}
+	slices.Sort(sorted)
+	at := func(p float64) time.Duration { return sorted[int(p*float64(len(sorted)-1))] }
+	return LatencyStats{P50: at(0.50), P95: at(0.95), Samples: len(sorted)}
+}
+
+// Reset forgets every recorded request.
+func (l *LatencyTracker) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.samples, l.next = nil, 0
+}
+
+var latency = &LatencyTracker{}
+
+// APILatency returns the latency of recent requests made through
+// MakeRequest, from sending each to reading its response. Requests that
+// fail to connect or time out count with the time they took.
+func APILatency() LatencyStats {
+	return latency.Stats()
+}
@@ -0,0 +1,324 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/auth"
+	"github.com/may-bach/Axiom/internal/config"
+)
+
+// mockAPI is a Flattrade-shaped test server. Each endpoint replies from a
+// queue of canned responses; the last one repeats once the queue is
+// drained.
+type mockAPI struct {
+	mu        sync.Mutex
+	replies   map[string][]mockReply
+	requests  []mockRequest
+	authCalls int
+	delay     time.Duration // added to every API response
+
+	acct   *Account
+	client *Client
+}
+
+type mockReply struct {
+	status int
+	body   string
+}
+
+// mockRequest is one request the server received.
+type mockRequest struct {
+	Endpoint string
+	JData    map[string]string
+	JKey     string
+	At       time.Time
+}
+
+// newMockAPI starts a server, points BaseURL and auth.TokenURL at it and
+// seeds a session. Everything is restored when the test ends.
+func newMockAPI(t *testing.T) *mockAPI {
+	t.Helper()
+	m := &mockAPI{replies: make(map[string][]mockReply)}
+	srv := httptest.NewServer(http.HandlerFunc(m.serve))
+
+	oldBase, oldToken := BaseURL, auth.TokenURL
+	BaseURL, auth.TokenURL = srv.URL, srv.URL+"/apitoken"
+	m.acct = NewAccount("", config.Credentials{UserID: "FT0001", APIKey: "key", RequestCode: "code", SecretKey: "secret"})
+	m.client = m.acct.Client
+	auth.Invalidate("key")
+	m.acct.Session.Set("token-1")
+	SetRateLimit(0)
+	SetCircuitBreaker(DefaultCircuitFailures, DefaultCircuitCooldown)
+	latency.Reset()
+
+	t.Cleanup(func() {
+		srv.Close()
+		BaseURL, auth.TokenURL = oldBase, oldToken
+		auth.Invalidate("key")
+		SetRateLimit(DefaultRateLimit)
+		SetCircuitBreaker(DefaultCircuitFailures, DefaultCircuitCooldown)
+	})
+	return m
+}
+
+// reply queues 200 responses with bodies for endpoint.
+func (m *mockAPI) reply(endpoint string, bodies ...string) {
+	for _, b := range bodies {
+		m.replyStatus(endpoint, http.StatusOK, b)
+	}
+}
+
+// replyStatus queues one response with the given status for endpoint.
+func (m *mockAPI) replyStatus(endpoint string, status int, body string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.replies[endpoint] = append(m.replies[endpoint], mockReply{status, body})
+}
+
+// received returns the requests made so far.
+func (m *mockAPI) received() []mockRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.requests)
+}
+
+func (m *mockAPI) serve(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r.URL.Path == "/apitoken" {
+		m.authCalls++
+		w.Write([]byte(`{"stat":"Ok","token":"token-2","client":"FT0001"}`))
+		return
+	}
+
+	// The body is "jData=<raw JSON>&jKey=<token>", not form-encoded.
+	body, _ := io.ReadAll(r.Body)
+	jdata, key, _ := strings.Cut(string(body), "&jKey=")
+	req := mockRequest{Endpoint: r.URL.Path, JKey: key, At: time.Now()}
+	json.Unmarshal([]byte(strings.TrimPrefix(jdata, "jData=")), &req.JData)
+	m.requests = append(m.requests, req)
+
+	q := m.replies[r.URL.Path]
+	if len(q) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	if len(q) > 1 {
+		m.replies[r.URL.Path] = q[1:]
+	}
+	time.Sleep(m.delay)
+	w.WriteHeader(q[0].status)
+	w.Write([]byte(q[0].body))
+}
+
+func TestMockGetQuote(t *testing.T) {
+	m := newMockAPI(t)
+	m.reply("/GetQuotes", `{"stat":"Ok","lp":"101.50","o":"100","h":"102","l":"99","ti":"0.05"}`)
+
+	q, err := m.client.GetQuote(context.Background(), "NSE", "2885")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.LTP != 101.5 || q.TickSize != 0.05 {
+		t.Errorf("quote = %+v", q)
+	}
+
+	reqs := m.received()
+	if len(reqs) != 1 {
+		t.Fatalf("%d requests, want 1", len(reqs))
+	}
+	r := reqs[0]
+	if r.JKey != "token-1" || r.JData["uid"] != "FT0001" || r.JData["token"] != "2885" || r.JData["exch"] != "NSE" {
+		t.Errorf("request = %+v", r)
+	}
+}
+
+func TestMockPlaceOrder(t *testing.T) {
+	m := newMockAPI(t)
+	m.reply("/PlaceOrder", `{"stat":"Ok","norenordno":"24010100001"}`)
+
+	id, err := m.client.PlaceOrder(context.Background(), OrderParams{Symbol: "SBIN", Side: Buy, Qty: 10, Product: ProductMIS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "24010100001" {
+		t.Errorf("order id = %q", id)
+	}
+	if got := m.received()[0].JData; got["tsym"] != "SBIN-EQ" || got["trantype"] != "B" || got["qty"] != "10" {
+		t.Errorf("payload = %v", got)
+	}
+}
+
+func TestMockSessionExpiryReauthenticatesAndRetries(t *testing.T) {
+	m := newMockAPI(t)
+	m.reply("/GetQuotes",
+		`{"stat":"Not_Ok","emsg":"Session Expired :  Invalid Session Key"}`,
+		`{"stat":"Ok","lp":"55.25"}`)
+
+	ltp, err := m.client.GetLTP(context.Background(), "NSE", "11536")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ltp != 55.25 {
+		t.Errorf("ltp = %v, want the retried 55.25", ltp)
+	}
+	if m.authCalls != 1 {
+		t.Errorf("auth calls = %d, want 1", m.authCalls)
+	}
+	reqs := m.received()
+	if len(reqs) != 2 || reqs[0].JKey != "token-1" || reqs[1].JKey != "token-2" {
+		t.Errorf("requests = %+v, want a retry with the new token", reqs)
+	}
+	if m.acct.Session.Get() != "token-2" {
+		t.Errorf("session = %q, want the new token stored", m.acct.Session.Get())
+	}
+}
+
+func TestMockDebugLogRedactsSecrets(t *testing.T) {
+	m := newMockAPI(t)
+	var out bytes.Buffer
+	SetDebugLog(&out)
+	t.Cleanup(func() { SetDebugLog(nil) })
+
+	m.reply("/GetQuotes",
+		`{"stat":"Not_Ok","emsg":"Session Expired :  Invalid Session Key"}`,
+		`{"stat":"Ok","uid":"FT0001","lp":"55.25"}`)
+	if _, err := m.client.GetLTP(context.Background(), "NSE", "11536"); err != nil {
+		t.Fatal(err)
+	}
+
+	log := out.String()
+	for _, secret := range []string{"token-1", "token-2", "FT0001"} {
+		if strings.Contains(log, secret) {
+			t.Errorf("debug log contains %q:\n%s", secret, log)
+		}
+	}
+
+	var recs []debugRecord
+	for line := range strings.Lines(log) {
+		var rec debugRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("bad debug line %q: %v", line, err)
+		}
+		recs = append(recs, rec)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("%d debug records, want the request and its retry", len(recs))
+	}
+	first, retry := recs[0], recs[1]
+	if first.Endpoint != "/GetQuotes" || first.Status != 200 || !strings.Contains(first.Response, "Session Expired") {
+		t.Errorf("first record = %+v, want the expired-session reply", first)
+	}
+	if first.Payload["token"] != "11536" || first.Payload["uid"] != redacted {
+		t.Errorf("payload = %v, want the instrument token kept and uid redacted", first.Payload)
+	}
+	if retry.Payload["jKey"] != redacted || !strings.Contains(retry.Response, `"uid":"`+redacted) {
+		t.Errorf("retry record = %+v, want jKey and the echoed uid redacted", retry)
+	}
+}
+
+func TestMockRateLimitSpacesRequests(t *testing.T) {
+	m := newMockAPI(t)
+	m.reply("/GetQuotes", `{"stat":"Ok","lp":"10"}`)
+	SetRateLimit(20) // one request per 50ms after the first
+
+	for range 3 {
+		if _, err := m.client.GetLTP(context.Background(), "NSE", "1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reqs := m.received()
+	if gap := reqs[2].At.Sub(reqs[0].At); gap < 90*time.Millisecond {
+		t.Errorf("3 requests spanned %v at 20/s, want at least ~100ms", gap)
+	}
+}
+
+func TestMockServerErrorCountsTowardsCircuit(t *testing.T) {
+	m := newMockAPI(t)
+	m.replyStatus("/GetQuotes", http.StatusServiceUnavailable, "down")
+	SetCircuitBreaker(2, time.Minute)
+
+	for range 2 {
+		if _, err := m.client.GetLTP(context.Background(), "NSE", "1"); err == nil {
+			t.Fatal("GetLTP succeeded against a 503")
+		}
+	}
+	if _, err := m.client.GetLTP(context.Background(), "NSE", "1"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("err = %v, want ErrCircuitOpen after 2 failures", err)
+	}
+	if n := len(m.received()); n != 2 {
+		t.Errorf("%d requests reached the server, want 2", n)
+	}
+}
+
+func TestMockMalformedJSON(t *testing.T) {
+	m := newMockAPI(t)
+	m.reply("/GetQuotes", `{"stat":"Ok","lp":`)
+	m.reply("/PlaceOrder", `<html>bad gateway</html>`)
+
+	if _, err := m.client.GetQuote(context.Background(), "NSE", "1"); err == nil {
+		t.Error("GetQuote accepted malformed JSON")
+	}
+	if _, err := m.client.PlaceOrder(context.Background(), OrderParams{Symbol: "SBIN", Side: Buy, Qty: 1}); err == nil {
+		t.Error("PlaceOrder accepted a non-JSON body")
+	}
+}
+
+func TestMockAccountsAreIndependent(t *testing.T) {
+	m := newMockAPI(t)
+	m.reply("/GetQuotes", `{"stat":"Ok","lp":"10"}`)
+	alt := NewAccount("alt", config.Credentials{UserID: "FT0002", APIKey: "key-2", RequestCode: "code-2", SecretKey: "secret"})
+	alt.Session.Set("alt-token")
+
+	for _, c := range []*Client{m.client, alt.Client} {
+		if _, err := c.GetLTP(context.Background(), "NSE", "1"); err != nil {
+			t.Fatalf("GetLTP: %v", err)
+		}
+	}
+
+	reqs := m.received()
+	if len(reqs) != 2 {
+		t.Fatalf("received %d requests, want 2", len(reqs))
+	}
+	for i, want := range []struct{ uid, key string }{{"FT0001", "token-1"}, {"FT0002", "alt-token"}} {
+		if reqs[i].JData["uid"] != want.uid || reqs[i].JKey != want.key {
+			t.Errorf("request %d signed as %s/%s, want %s/%s", i, reqs[i].JData["uid"], reqs[i].JKey, want.uid, want.key)
+		}
+	}
+}
+
+func TestMockLatencyTracked(t *testing.T) {
+	m := newMockAPI(t)
+	m.reply("/GetQuotes", `{"stat":"Ok","lp":"101.50"}`)
+
+	for range 3 {
+		m.client.GetQuote(context.Background(), "NSE", "2885")
+	}
+	fast := APILatency()
+	if fast.Samples != 3 {
+		t.Fatalf("samples = %d, want 3", fast.Samples)
+	}
+
+	m.mu.Lock()
+	m.delay = 40 * time.Millisecond
+	m.mu.Unlock()
+	for range 3 {
+		m.client.GetQuote(context.Background(), "NSE", "2885")
+	}
+	slow := APILatency()
+	if slow.P95 < m.delay || slow.P95 <= fast.P95 {
+		t.Errorf("p95 = %s after slow replies, want at least %s", slow.P95, m.delay)
+	}
+}
@@ -0,0 +1,37 @@
+package client
+
+import "math"
+
+// DefaultTickSize is the NSE equity tick used when an instrument's own tick
+// size is unknown.
+const DefaultTickSize = 0.05
+
+// RoundToTick rounds price to the nearest multiple of tickSize, as the
+// exchange rejects prices that are not tick-aligned. A non-positive tick
+// size leaves price unchanged.
+func RoundToTick(price, tickSize float64) float64 {
+	if tickSize <= 0 {
+		return price
+	}
+	ticks := math.Round(price / tickSize)
+	// Trim float noise such as 101.15000000000001.
+	return math.Round(ticks*tickSize*1e6) / 1e6
+}
+
+// FloorToTick rounds price down to a multiple of tickSize.
+func FloorToTick(price, tickSize float64) float64 {
+	if tickSize <= 0 {
+		return price
+	}
+	// The nudge keeps an aligned price whose division lands a hair under a
+	// whole tick count from slipping down a tick.
+	return math.Round(math.Floor(price/tickSize+1e-9)*tickSize*1e6) / 1e6
+}
+
+// CeilToTick rounds price up to a multiple of tickSize.
+func CeilToTick(price, tickSize float64) float64 {
+	if tickSize <= 0 {
+		return price
+	}
+	return math.Round(math.Ceil(price/tickSize-1e-9)*tickSize*1e6) / 1e6
+}
@@ -0,0 +1,53 @@
+package client
+
+import "testing"
+
+func TestRoundToTick(t *testing.T) {
+	tests := []struct {
+		name  string
+		price float64
+		tick  float64
+		want  float64
+	}{
+		{"already aligned", 101.15, 0.05, 101.15},
+		{"rounds down", 101.12, 0.05, 101.10},
+		{"rounds up", 101.13, 0.05, 101.15},
+		{"midpoint rounds away from zero", 101.125, 0.05, 101.15},
+		{"just below boundary", 101.1749, 0.05, 101.15},
+		{"just above boundary", 101.1751, 0.05, 101.20},
+		{"whole rupee", 99.999, 0.05, 100},
+		{"one paisa tick", 250.234, 0.01, 250.23},
+		{"large tick", 1234.3, 0.5, 1234.5},
+		{"zero tick unchanged", 101.123, 0, 101.123},
+		{"negative tick unchanged", 101.123, -0.05, 101.123},
+		{"zero price", 0, 0.05, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoundToTick(tt.price, tt.tick); got != tt.want {
+				t.Errorf("RoundToTick(%v, %v) = %v, want %v", tt.price, tt.tick, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFloorCeilToTick(t *testing.T) {
+	tests := []struct {
+		price, tick, floor, ceil float64
+	}{
+		{101.15, 0.05, 101.15, 101.15},
+		{101.12, 0.05, 101.10, 101.15},
+		{101.149, 0.05, 101.10, 101.15},
+		{100.95, 0.05, 100.95, 100.95}, // 2019 ticks; must not slip a tick on float error
+		{250.234, 0.01, 250.23, 250.24},
+		{101.123, 0, 101.123, 101.123},
+	}
+	for _, tt := range tests {
+		if got := FloorToTick(tt.price, tt.tick); got != tt.floor {
+			t.Errorf("FloorToTick(%v, %v) = %v, want %v", tt.price, tt.tick, got, tt.floor)
+		}
+		if got := CeilToTick(tt.price, tt.tick); got != tt.ceil {
+			t.Errorf("CeilToTick(%v, %v) = %v, want %v", tt.price, tt.tick, got, tt.ceil)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package client
+
+import "context"
+
+// QuoteProvider supplies market data. It is kept separate from Broker so
+// quotes can come from a different vendor than the one routing orders.
+type QuoteProvider interface {
+	GetLTP(ctx context.Context, exch, token string) (float64, error)
+	GetQuote(ctx context.Context, exch, token string) (Quote, error)
+}
+
+// Broker places and manages orders.
+type Broker interface {
+	PlaceOrder(ctx context.Context, p OrderParams) (string, error)
+	PlaceBracketOrder(ctx context.Context, p BracketParams) (string, error)
+	ExitBracketOrder(ctx context.Context, orderNo string) error
+	GetBracketLegs(ctx context.Context, orderNo string) (target, stop OrderStatus, err error)
+	ModifyOrder(ctx context.Context, orderNo string, newPrice, newTrigger float64, newQty int) error
+	CancelOrder(ctx context.Context, orderNo string) error
+	GetOrderStatus(ctx context.Context, orderNo string) (OrderStatus, error)
+	GetLimits(ctx context.Context) (Limits, error)
+	GetPositions(ctx context.Context) ([]Position, error)
+}
+
+var (
+	_ QuoteProvider = (*Client)(nil)
+	_ Broker        = (*Client)(nil)
+)
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimit is Flattrade's published ceiling for PiConnect requests
+// per second.
+const DefaultRateLimit = 10.0
+
+// Limiter is a token bucket shared by every API call. Tokens refill at
+// rate per second up to burst; a rate <= 0 disables limiting.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func NewLimiter(rate float64, burst int) *Limiter {
+	b := float64(max(burst, 1))
+	return &Limiter{rate: rate, burst: b, tokens: b, last: time.Now()}
+}
+
+// Wait blocks until a request may be sent or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.rate <= 0 {
+			l.mu.Unlock()
+			return nil
+		}
+		now := time.Now()
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// SetRate changes the refill rate; rate <= 0 disables limiting.
+func (l *Limiter) SetRate(rate float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+}
+
+var limiter = NewLimiter(DefaultRateLimit, 1)
+
+// SetRateLimit sets the requests-per-second ceiling applied to MakeRequest.
+func SetRateLimit(rate float64) {
+	limiter.SetRate(rate)
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterPacesRequests(t *testing.T) {
+	l := NewLimiter(50, 1)
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 6 {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first request uses the initial token; the other 5 wait 20ms each.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("6 requests at 50/s took %v, want >= 100ms", elapsed)
+	}
+}
+
+func TestLimiterHonoursContext(t *testing.T) {
+	l := NewLimiter(0.1, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err == nil {
+		t.Error("Wait returned nil after the context expired")
+	}
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	l := NewLimiter(0, 1)
+	for range 100 {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
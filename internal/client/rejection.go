@@ -0,0 +1,78 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RejectReason classifies why the broker refused an order.
+type RejectReason int
+
+const (
+	RejectUnknown RejectReason = iota
+	RejectInsufficientFunds
+	RejectRMSBlock
+	RejectMarketClosed
+	RejectTickSize
+	RejectLotSize
+)
+
+func (r RejectReason) String() string {
+	switch r {
+	case RejectInsufficientFunds:
+		return "insufficient funds"
+	case RejectRMSBlock:
+		return "RMS block"
+	case RejectMarketClosed:
+		return "market closed"
+	case RejectTickSize:
+		return "tick size"
+	case RejectLotSize:
+		return "lot size"
+	}
+	return "unknown"
+}
+
+// OrderRejection is returned when the broker answers an order with a
+// failure status.
+type OrderRejection struct {
+	Reason  RejectReason
+	Message string // the broker's emsg
+}
+
+func (e *OrderRejection) Error() string {
+	return fmt.Sprintf("order rejected (%s): %s", e.Reason, e.Message)
+}
+
+// Fixable reports whether the order can be corrected and resent: a price
+// off the tick grid or a quantity that is not a whole number of lots.
+func (e *OrderRejection) Fixable() bool {
+	return e.Reason == RejectTickSize || e.Reason == RejectLotSize
+}
+
+// rejectionPatterns are checked in order; margin messages often also
+// mention RMS, so funds come first. Bans are matched as phrases, since a
+// bare "ban" also turns up in "circuit band" and symbols like BANKBARODA.
+var rejectionPatterns = []struct {
+	reason   RejectReason
+	keywords []string
+}{
+	{RejectInsufficientFunds, []string{"insufficient", "margin", "funds", "fund limit"}},
+	{RejectMarketClosed, []string{"market closed", "market is closed", "exchange closed", "outside market hours", "session closed"}},
+	{RejectTickSize, []string{"tick size", "tick_size", "ticksize", "multiple of tick"}},
+	{RejectLotSize, []string{"lot size", "lot_size", "lotsize", "multiple of lot"}},
+	{RejectRMSBlock, []string{"rms", "blocked", "not allowed", "ban period", "securities in ban", "scrip in ban", "under ban"}},
+}
+
+// ClassifyRejection maps a broker rejection message to a reason.
+func ClassifyRejection(emsg string) RejectReason {
+	msg := strings.ToLower(emsg)
+	for _, p := range rejectionPatterns {
+		for _, k := range p.keywords {
+			if strings.Contains(msg, k) {
+				return p.reason
+			}
+		}
+	}
+	return RejectUnknown
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrUnexpectedResponse is returned for an API body that is an array where
+// an object was expected and does not wrap one.
+var ErrUnexpectedResponse = errors.New("unexpected API response")
+
+// DecodeResponse unmarshals an API body into v. Some endpoints answer
+// certain errors with a one-element array, [{"stat":"Not_Ok","emsg":...}],
+// in place of the documented object; DecodeResponse unwraps it so callers
+// read stat and emsg as they would from the object, instead of failing
+// with "cannot unmarshal array". Other arrays give ErrUnexpectedResponse.
+// Endpoints that succeed with an array decode it themselves.
+func DecodeResponse(body []byte, v any) error {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return json.Unmarshal(body, v)
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(trimmed, &items); err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("%w: empty array", ErrUnexpectedResponse)
+	}
+	if first := bytes.TrimSpace(items[0]); len(first) == 0 || first[0] != '{' {
+		return fmt.Errorf("%w: array of %s", ErrUnexpectedResponse, first)
+	}
+	return json.Unmarshal(items[0], v)
+}
@@ -4,31 +4,133 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
-type Config struct {
+// Credentials are one Flattrade login.
+type Credentials struct {
 	APIKey      string
 	RequestCode string
 	SecretKey   string
+	UserID      string
 }
 
-var C Config
+type Config struct {
+	// ControlToken authenticates the control API. Optional: the control
+	// endpoints refuse every request while it is empty.
+	ControlToken string
+
+	// WebhookURL, when set, forwards notifications to a Discord or Slack
+	// incoming webhook. WebhookEvents limits which events are sent (entry,
+	// exit, error, summary); empty sends all of them.
+	WebhookURL    string
+	WebhookEvents []string
+}
+
+var (
+	C Config
+
+	// secrets is the secrets file read by Load, consulted after the
+	// environment when resolving a key.
+	secrets map[string]string
+)
 
-func Load() {
-	err := godotenv.Load()
-	if err != nil {
+// Load resolves settings from, in order of precedence, the process
+// environment, a .env file in the working directory, and the optional
+// secretsPath (a KEY=VALUE file such as a mounted container secret).
+// Account credentials are resolved separately by Account.
+func Load(secretsPath string) error {
+	// godotenv never overrides variables that are already set, so real
+	// environment variables win over .env.
+	if err := godotenv.Load(); err != nil && !os.IsNotExist(err) {
 		log.Printf("Warning: Error loading .env file: %v", err)
 	}
 
-	C.APIKey = os.Getenv("FLAT_API_KEY")
-	C.RequestCode = os.Getenv("FLAT_REQUEST_CODE")
-	C.SecretKey = os.Getenv("FLAT_SECRET_KEY")
+	secrets = nil
+	if secretsPath != "" {
+		var err error
+		secrets, err = godotenv.Read(secretsPath)
+		if err != nil {
+			return fmt.Errorf("cannot read secrets file %s: %v", secretsPath, err)
+		}
+	}
 
-	if C.APIKey == "" || C.RequestCode == "" || C.SecretKey == "" {
-		log.Fatal("Missing core credentials in .env (FLAT_API_KEY, FLAT_REQUEST_CODE, FLAT_SECRET_KEY)")
+	C.ControlToken = lookup("AXIOM_CONTROL_TOKEN")
+	C.WebhookURL = lookup("AXIOM_WEBHOOK_URL")
+	C.WebhookEvents = nil
+	for _, e := range strings.Split(lookup("AXIOM_WEBHOOK_EVENTS"), ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			C.WebhookEvents = append(C.WebhookEvents, strings.ToLower(e))
+		}
 	}
 
 	fmt.Println("Configuration loaded successfully")
+	return nil
+}
+
+func lookup(key string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return secrets[key]
+}
+
+// Account resolves the credentials of the named account after Load. The
+// default account, name "", reads FLAT_API_KEY, FLAT_REQUEST_CODE,
+// FLAT_SECRET_KEY and FLAT_USER_ID; account "alt" reads FLAT_ALT_API_KEY
+// and so on. Every missing key is reported at once.
+func Account(name string) (Credentials, error) {
+	prefix := accountPrefix(name)
+	creds := Credentials{
+		APIKey:      lookup(prefix + "API_KEY"),
+		RequestCode: lookup(prefix + "REQUEST_CODE"),
+		SecretKey:   lookup(prefix + "SECRET_KEY"),
+		UserID:      lookup(prefix + "USER_ID"),
+	}
+
+	var missing []string
+	for _, f := range []struct{ key, value string }{
+		{"API_KEY", creds.APIKey},
+		{"REQUEST_CODE", creds.RequestCode},
+		{"SECRET_KEY", creds.SecretKey},
+		{"USER_ID", creds.UserID},
+	} {
+		if f.value == "" {
+			missing = append(missing, prefix+f.key)
+		}
+	}
+	if len(missing) > 0 {
+		return creds, fmt.Errorf("missing credentials: %s", strings.Join(missing, ", "))
+	}
+	return creds, nil
+}
+
+func accountPrefix(name string) string {
+	if name == "" {
+		return "FLAT_"
+	}
+	return "FLAT_" + strings.ToUpper(name) + "_"
+}
+
+// Setting is one key read from the environment or secrets file.
+type Setting struct {
+	Key         string
+	Description string
+	Required    bool
+}
+
+// Settings lists every key Load and Account read for the named account.
+func Settings(account string) []Setting {
+	prefix := accountPrefix(account)
+	return []Setting{
+		{prefix + "API_KEY", "Flattrade API key", true},
+		{prefix + "REQUEST_CODE", "request code from the Flattrade login redirect", true},
+		{prefix + "SECRET_KEY", "Flattrade API secret", true},
+		{prefix + "USER_ID", "Flattrade client id", true},
+		{"AXIOM_CONTROL_TOKEN", "bearer token for the control API; the endpoints refuse every request while unset", false},
+		{"AXIOM_WEBHOOK_URL", "Discord or Slack incoming webhook for notifications", false},
+		{"AXIOM_WEBHOOK_EVENTS", "comma-separated events to send to the webhook (entry, exit, error, summary); empty sends all", false},
+	}
 }
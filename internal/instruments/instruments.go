@@ -0,0 +1,179 @@
+package instruments
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/atomicfile"
+)
+
+// DefaultURL is the NSE instrument master published by Flattrade. It may
+// be a plain CSV or a zip holding one.
+const DefaultURL = "https://flattrade.s3.ap-south-1.amazonaws.com/scripmaster/Nse_Equity.csv"
+
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+// Instrument is one row of the master.
+type Instrument struct {
+	Exchange      string
+	Token         string
+	Symbol        string // e.g. "SBIN"
+	TradingSymbol string // e.g. "SBIN-EQ"
+	LotSize       int
+	TickSize      float64
+}
+
+// Master indexes instruments by trading symbol.
+type Master struct {
+	byTsym map[string]Instrument
+}
+
+// Len returns the number of instruments in m.
+func (m *Master) Len() int { return len(m.byTsym) }
+
+// Lookup returns sym's NSE equity ("-EQ") instrument.
+func (m *Master) Lookup(sym string) (Instrument, bool) {
+	in, ok := m.byTsym[strings.ToUpper(sym)+"-EQ"]
+	return in, ok
+}
+
+// Load returns the master for today, reading cachePath if it was written
+// today and downloading url into it otherwise.
+func Load(ctx context.Context, url, cachePath string) (*Master, error) {
+	if fi, err := os.Stat(cachePath); err == nil && sameDay(fi.ModTime(), time.Now()) {
+		data, err := os.ReadFile(cachePath)
+		if err == nil {
+			if m, err := parseData(data); err == nil {
+				return m, nil
+			}
+		}
+	}
+
+	data, err := download(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	m, err := parseData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := atomicfile.Write(cachePath, data, 0644); err != nil {
+		return m, fmt.Errorf("instrument master loaded but not cached: %v", err)
+	}
+	return m, nil
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("instrument master download failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("instrument master download failed: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// parseData parses a master that is either CSV or a zip holding one.
+func parseData(data []byte) (*Master, error) {
+	if !bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return Parse(bytes.NewReader(data))
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid instrument master zip: %v", err)
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return Parse(rc)
+	}
+	return nil, fmt.Errorf("instrument master zip is empty")
+}
+
+// Parse reads a CSV master. Columns are found by header name, ignoring
+// case: Token and TradingSymbol are required; Exchange, Symbol, LotSize
+// and TickSize are optional. Rows for exchanges other than NSE are skipped.
+func Parse(r io.Reader) (*Master, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("instrument master header: %v", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, req := range []string{"token", "tradingsymbol"} {
+		if _, ok := col[req]; !ok {
+			return nil, fmt.Errorf("instrument master has no %q column", req)
+		}
+	}
+	field := func(rec []string, name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	m := &Master{byTsym: make(map[string]Instrument)}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("instrument master: %v", err)
+		}
+
+		in := Instrument{
+			Exchange:      field(rec, "exchange"),
+			Token:         field(rec, "token"),
+			Symbol:        field(rec, "symbol"),
+			TradingSymbol: strings.ToUpper(field(rec, "tradingsymbol")),
+		}
+		if in.Token == "" || in.TradingSymbol == "" || (in.Exchange != "" && in.Exchange != "NSE") {
+			continue
+		}
+		in.LotSize, _ = strconv.Atoi(field(rec, "lotsize"))
+		in.TickSize, _ = strconv.ParseFloat(field(rec, "ticksize"), 64)
+		m.byTsym[in.TradingSymbol] = in
+	}
+	if len(m.byTsym) == 0 {
+		return nil, fmt.Errorf("instrument master has no NSE instruments")
+	}
+	return m, nil
+}
@@ -0,0 +1,99 @@
+package instruments
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const sampleCSV = `Exchange,Token,LotSize,Symbol,TradingSymbol,Instrument,TickSize,
+NSE,3045,1,SBIN,SBIN-EQ,EQ,0.05,
+NSE,1594,1,INFY,INFY-EQ,EQ,0.05,
+NSE,99926000,1,Nifty 50,NIFTY INDEX,INDEX,0,
+BSE,500112,1,SBIN,SBIN,EQ,0.05,
+`
+
+func TestParse(t *testing.T) {
+	m, err := Parse(strings.NewReader(sampleCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in, ok := m.Lookup("sbin")
+	if !ok {
+		t.Fatal("SBIN not found")
+	}
+	want := Instrument{Exchange: "NSE", Token: "3045", Symbol: "SBIN", TradingSymbol: "SBIN-EQ", LotSize: 1, TickSize: 0.05}
+	if in != want {
+		t.Errorf("SBIN = %+v, want %+v", in, want)
+	}
+	if _, ok := m.Lookup("TCS"); ok {
+		t.Error("found a symbol missing from the master")
+	}
+	if m.Len() != 3 {
+		t.Errorf("Len = %d, want 3 NSE rows", m.Len())
+	}
+}
+
+func TestParseRequiresColumns(t *testing.T) {
+	if _, err := Parse(strings.NewReader("Exchange,Symbol\nNSE,SBIN\n")); err == nil {
+		t.Error("Parse accepted a master without token columns")
+	}
+}
+
+func TestParseZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create("NSE_symbols.txt")
+	f.Write([]byte(sampleCSV))
+	zw.Close()
+
+	m, err := parseData(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in, ok := m.Lookup("INFY"); !ok || in.Token != "1594" {
+		t.Errorf("INFY = %+v, %v", in, ok)
+	}
+}
+
+func TestLoadCachesForTheDay(t *testing.T) {
+	var downloads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		w.Write([]byte(sampleCSV))
+	}))
+	defer srv.Close()
+
+	cache := filepath.Join(t.TempDir(), "instruments.csv")
+	for range 2 {
+		m, err := Load(context.Background(), srv.URL, cache)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := m.Lookup("SBIN"); !ok {
+			t.Fatal("SBIN not found")
+		}
+	}
+	if n := downloads.Load(); n != 1 {
+		t.Errorf("downloads = %d, want 1 (second load from cache)", n)
+	}
+
+	// Yesterday's cache is refreshed.
+	old := time.Now().AddDate(0, 0, -1)
+	os.Chtimes(cache, old, old)
+	if _, err := Load(context.Background(), srv.URL, cache); err != nil {
+		t.Fatal(err)
+	}
+	if n := downloads.Load(); n != 2 {
+		t.Errorf("downloads = %d, want 2 after the cache went stale", n)
+	}
+}
@@ -0,0 +1,168 @@
+package models
+
+// Direction of a position or signal.
+type Direction string
+
+const (
+	Long  Direction = "LONG"
+	Short Direction = "SHORT"
+)
+
+// StockStrategy is the per-symbol configuration written by brain.py to
+// config.json in the data directory.
+type StockStrategy struct {
+	Class         string  `json:"class"`
+	AllowShort    bool    `json:"allow_short"`
+	BreakoutLong  float64 `json:"breakout_long"`
+	BreakoutShort float64 `json:"breakout_short"`
+	BounceRebound float64 `json:"bounce_rebound,omitempty"` // rebound off the low for a bounce-back buy
+	QuickDrop     float64 `json:"quick_drop,omitempty"`     // single-tick fall for a quick-drop short
+	Target        float64 `json:"target"`
+	SL            float64 `json:"sl"`
+	Leverage      float64 `json:"leverage"`
+	Sector        string  `json:"sector,omitempty"`
+	Product       string  `json:"product,omitempty"`        // "C" (CNC) or "I" (MIS); derived from Leverage when empty
+	TrailActivate float64 `json:"trail_activate,omitempty"` // profit fraction before the trailing SL arms
+	TrailPercent  float64 `json:"trail_percent,omitempty"`  // trailing distance from the best price, as a fraction
+
+	// EntryLimitOffset, when > 0, enters with a limit order this fraction
+	// better than the signal price instead of a market order.
+	EntryLimitOffset float64 `json:"entry_limit_offset,omitempty"`
+
+	// ConfirmTicks is how many consecutive ticks an entry condition must
+	// hold before it fires; 0 or 1 fires on the first tick.
+	ConfirmTicks int `json:"confirm_ticks,omitempty"`
+
+	// BreakEvenTrigger, when > 0, moves the stop to entry (plus
+	// BreakEvenBuffer) once the best price is this fraction past entry.
+	BreakEvenTrigger float64 `json:"break_even_trigger,omitempty"`
+	BreakEvenBuffer  float64 `json:"break_even_buffer,omitempty"`
+
+	// MaxHoldMinutes exits a position held this long without hitting SL
+	// or target; 0 uses the global -max-hold.
+	MaxHoldMinutes float64 `json:"max_hold_minutes,omitempty"`
+
+	// EntryValidity is the order validity used for entries: "DAY" (the
+	// default) or "IOC" for fast-fill entries that must not rest.
+	EntryValidity string `json:"entry_validity,omitempty"`
+
+	// MinPrice and MaxPrice bound the LTP at which new entries are taken;
+	// 0 uses the global -min-price / -max-price.
+	MinPrice float64 `json:"min_price,omitempty"`
+	MaxPrice float64 `json:"max_price,omitempty"`
+
+	// GapUp and GapDown enable the opening gap strategies: enter when the
+	// open is at least this fraction above/below the previous close. 0
+	// disables. They only fire within GapWindowMinutes of the open
+	// (default 15).
+	GapUp            float64 `json:"gap_up,omitempty"`
+	GapDown          float64 `json:"gap_down,omitempty"`
+	GapWindowMinutes float64 `json:"gap_window_minutes,omitempty"`
+
+	// MaxNotional caps the symbol's open exposure (qty * price) in both
+	// directions together; 0 uses the global -max-symbol-notional.
+	MaxNotional float64 `json:"max_notional,omitempty"`
+
+	// UnderBudget says what to do when the budget buys less than one lot:
+	// UnderBudgetSkip (the default) skips the entry, UnderBudgetMinQty
+	// enters MinQty (at least one lot) anyway, and UnderBudgetStretch
+	// raises the budget to buy one lot as long as that costs no more than
+	// MaxBudget.
+	UnderBudget string  `json:"under_budget,omitempty"`
+	MinQty      int     `json:"min_qty,omitempty"`
+	MaxBudget   float64 `json:"max_budget,omitempty"`
+
+	// ExitPriceType is "market" or "protected" (a limit
+	// ExitProtectionTicks through the ltp); empty and 0 use the global
+	// -exit-type and -exit-protection-ticks.
+	ExitPriceType       string `json:"exit_price_type,omitempty"`
+	ExitProtectionTicks int    `json:"exit_protection_ticks,omitempty"`
+
+	// DirectionBias is the day's directional view: BiasLong or BiasShort
+	// takes entries one way only, BiasNone takes none, and BiasBoth (or
+	// empty) takes both. Shorts still need AllowShort.
+	DirectionBias string `json:"direction_bias,omitempty"`
+
+	// MaxDayChangeLong skips longs once LTP is more than this fraction
+	// above the previous close, and MaxDayChangeShort skips shorts once it
+	// is more than this fraction below; 0 uses the global
+	// -max-day-change-long / -max-day-change-short.
+	MaxDayChangeLong  float64 `json:"max_day_change_long,omitempty"`
+	MaxDayChangeShort float64 `json:"max_day_change_short,omitempty"`
+
+	// MaxVWAPExtension skips entries once LTP is more than this fraction
+	// beyond the session VWAP in the entry's direction (above it for longs,
+	// below it for shorts), and MaxOpenExtension likewise beyond the day's
+	// open, so a breakout at an already stretched extreme is not chased;
+	// 0 uses the global -max-vwap-extension / -max-open-extension.
+	MaxVWAPExtension float64 `json:"max_vwap_extension,omitempty"`
+	MaxOpenExtension float64 `json:"max_open_extension,omitempty"`
+
+	// AllowReentry re-enters in the same direction when price makes a new
+	// extreme within ReentryWindowMinutes (0 uses the global
+	// -reentry-window) of a target exit, up to MaxReentries times a day
+	// (at least once).
+	AllowReentry         bool    `json:"allow_reentry,omitempty"`
+	MaxReentries         int     `json:"max_reentries,omitempty"`
+	ReentryWindowMinutes float64 `json:"reentry_window_minutes,omitempty"`
+
+	// MaxConsecutiveLosses benches the symbol for the rest of the day
+	// after this many losing trades in a row; 0 uses the global
+	// -max-symbol-losses.
+	MaxConsecutiveLosses int `json:"max_consecutive_losses,omitempty"`
+
+	// EntryWindows limits entries to these "HH:MM-HH:MM" IST ranges, e.g.
+	// ["09:30-11:30", "13:00-14:30"] to skip the open, the lunch lull and
+	// the run-up to the close; exits are unaffected. Empty uses the global
+	// -entry-windows, which by default allows the whole session.
+	EntryWindows []string `json:"entry_windows,omitempty"`
+
+	// PartialFill says what to do with the unfilled rest of a partially
+	// filled entry: "accept" keeps it working, "cancel" cancels it and
+	// "chase" re-sends it at market; empty uses the global -partial-fill.
+	PartialFill string `json:"partial_fill,omitempty"`
+}
+
+// StockStrategy.DirectionBias values.
+const (
+	BiasLong  = "long"
+	BiasShort = "short"
+	BiasBoth  = "both"
+	BiasNone  = "none"
+)
+
+// Allows reports whether the strategy's DirectionBias permits entries in
+// dir.
+func (s StockStrategy) Allows(dir Direction) bool {
+	switch s.DirectionBias {
+	case BiasNone:
+		return false
+	case BiasLong:
+		return dir == Long
+	case BiasShort:
+		return dir == Short
+	}
+	return true
+}
+
+// StockStrategy.UnderBudget policies.
+const (
+	UnderBudgetSkip    = "skip"
+	UnderBudgetMinQty  = "min_qty"
+	UnderBudgetStretch = "stretch"
+)
+
+// Signal is an entry decision produced by a strategy.
+type Signal struct {
+	Symbol    string
+	Direction Direction
+	Price     float64
+	Strategy  string
+	Reason    string   // human-readable trigger description for the console
+	Tags      []string // context labels carried to the TradeRecord, e.g. "gap-play"
+
+	// Strength scores how decisively the signal fired: 1 when the trigger
+	// move just meets the strategy's threshold, 2 at twice the threshold,
+	// and so on. Each strategy documents its move and threshold.
+	Strength float64
+}
@@ -0,0 +1,26 @@
+package notify
+
+import "log"
+
+// Event classifies a notification so sinks can filter what they forward.
+type Event string
+
+const (
+	EventEntry   Event = "entry"
+	EventExit    Event = "exit"
+	EventError   Event = "error"
+	EventSummary Event = "summary"
+)
+
+// Notifier delivers operational alerts to the operator. Implementations must
+// not block the trading loop for long.
+type Notifier interface {
+	Notify(event Event, msg string)
+}
+
+// Log writes notifications to the standard logger.
+type Log struct{}
+
+func (Log) Notify(event Event, msg string) {
+	log.Printf("[NOTIFY %s] %s", event, msg)
+}
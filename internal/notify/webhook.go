@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	webhookTimeout  = 5 * time.Second
+	webhookAttempts = 3
+)
+
+// Webhook POSTs notifications as JSON to an incoming-webhook URL. The
+// payload carries the message under both "content" (Discord) and "text"
+// (Slack), so one implementation serves either.
+//
+// Notify returns immediately; delivery, including retries on 5xx, happens
+// in the background and failures are only logged.
+type Webhook struct {
+	URL    string
+	Events map[Event]bool // events to forward; nil forwards everything
+
+	client  *http.Client
+	backoff time.Duration // delay before the n-th retry is n*backoff
+}
+
+// NewWebhook returns a webhook notifier for url forwarding only events, or
+// every event when none are given.
+func NewWebhook(url string, events ...Event) *Webhook {
+	w := &Webhook{
+		URL:     url,
+		client:  &http.Client{Timeout: webhookTimeout},
+		backoff: time.Second,
+	}
+	if len(events) > 0 {
+		w.Events = make(map[Event]bool, len(events))
+		for _, e := range events {
+			w.Events[e] = true
+		}
+	}
+	return w
+}
+
+func (w *Webhook) Notify(event Event, msg string) {
+	if w.Events != nil && !w.Events[event] {
+		return
+	}
+	go func() {
+		if err := w.send(context.Background(), event, msg); err != nil {
+			log.Printf("Webhook notification failed: %v", err)
+		}
+	}()
+}
+
+type webhookPayload struct {
+	Content string `json:"content"`
+	Text    string `json:"text"`
+}
+
+// format renders msg with a label for its event, e.g. "[Axiom] EXIT: ...".
+func format(event Event, msg string) string {
+	return fmt.Sprintf("[Axiom] %s: %s", strings.ToUpper(string(event)), msg)
+}
+
+// send delivers one notification, retrying network errors and 5xx
+// responses.
+func (w *Webhook) send(ctx context.Context, event Event, msg string) error {
+	text := format(event, msg)
+	body, err := json.Marshal(webhookPayload{Content: text, Text: text})
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt-1) * w.backoff):
+			}
+		}
+
+		retry, err := w.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry {
+			return err
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("webhook failed after %d attempts: %w", webhookAttempts, lastErr)
+}
+
+// post makes one request. retry reports whether the failure was transient.
+func (w *Webhook) post(ctx context.Context, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return resp.StatusCode >= 500, fmt.Errorf("webhook returned %s: %s", resp.Status, raw)
+}
+
+// Multi fans a notification out to several notifiers.
+type Multi []Notifier
+
+func (m Multi) Notify(event Event, msg string) {
+	for _, n := range m {
+		n.Notify(event, msg)
+	}
+}
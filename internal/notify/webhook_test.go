@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWebhookSendPayload(t *testing.T) {
+	var got webhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	w := NewWebhook(srv.URL)
+	if err := w.send(context.Background(), EventExit, "SBIN P&L ₹120.00"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got.Content, "EXIT") || !strings.Contains(got.Content, "SBIN P&L ₹120.00") {
+		t.Errorf("content = %q", got.Content)
+	}
+	if got.Text != got.Content {
+		t.Errorf("text = %q, want it to match content", got.Text)
+	}
+}
+
+func TestWebhookRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < webhookAttempts {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	w := NewWebhook(srv.URL)
+	w.backoff = 0
+	if err := w.send(context.Background(), EventError, "boom"); err != nil {
+		t.Fatalf("send after transient failures: %v", err)
+	}
+	if n := calls.Load(); n != webhookAttempts {
+		t.Errorf("calls = %d, want %d", n, webhookAttempts)
+	}
+}
+
+func TestWebhookDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	w := NewWebhook(srv.URL)
+	w.backoff = 0
+	if err := w.send(context.Background(), EventError, "boom"); err == nil {
+		t.Fatal("send succeeded on a 404")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1", n)
+	}
+}
+
+func TestWebhookEventFilter(t *testing.T) {
+	w := NewWebhook("http://unused", EventSummary, EventError)
+	for _, tt := range []struct {
+		event Event
+		want  bool
+	}{
+		{EventSummary, true},
+		{EventError, true},
+		{EventEntry, false},
+		{EventExit, false},
+	} {
+		if got := w.Events[tt.event]; got != tt.want {
+			t.Errorf("forwards %s = %v, want %v", tt.event, got, tt.want)
+		}
+	}
+	if NewWebhook("http://unused").Events != nil {
+		t.Error("webhook without events should forward everything")
+	}
+}
@@ -1,11 +1,37 @@
 package session
 
-var Token string
+import (
+	"sync"
+	"time"
+)
 
-func Set(token string) {
-	Token = token
+// Session is one account's API session token. The zero value holds no
+// token and is ready to use.
+type Session struct {
+	mu    sync.Mutex
+	token string
+	setAt time.Time
 }
 
-func Get() string {
-	return Token
+func (s *Session) Set(t string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = t
+	s.setAt = time.Now()
+}
+
+func (s *Session) Get() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+// Age is how long ago the current token was set, or 0 if none is set.
+func (s *Session) Age() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == "" {
+		return 0
+	}
+	return time.Since(s.setAt)
 }
@@ -0,0 +1,158 @@
+package state
+
+import (
+	"math"
+	"time"
+)
+
+// MarketState is the per-symbol view of the trading session built from polled
+// quotes. Strategies receive a snapshot of it on every tick.
+type MarketState struct {
+	Symbol  string
+	LTP     float64
+	High    float64   // session high, excluding the tick being evaluated
+	Low     float64   // session low, excluding the tick being evaluated
+	History []float64 // recent LTPs, oldest first; the last entry is LTP
+
+	FeedTime  time.Time // exchange timestamp of the last quote, zero if unknown
+	FirstSeen time.Time // wall time of the first tick observed this session
+	Ticks     int       // ticks observed this session
+
+	Bid float64 // best bid with the last quote, zero if unknown
+	Ask float64 // best ask with the last quote, zero if unknown
+
+	Open      float64 // day's open from the last quote, zero if unknown
+	PrevClose float64 // previous session's close, zero if unknown
+
+	Volume    int64   // cumulative day volume reported with the last quote
+	SumPV     float64 // Σ price × traded volume, for VWAP
+	SumVolume float64 // Σ traded volume, for VWAP
+}
+
+// AddTick appends ltp to the history (keeping at most window entries) and
+// folds the volume traded since the previous quote into the VWAP sums.
+func (s *MarketState) AddTick(ltp float64, volume int64, window int) {
+	if s.FirstSeen.IsZero() {
+		s.FirstSeen = time.Now()
+	}
+	s.Ticks++
+	s.LTP = ltp
+
+	s.History = append(s.History, ltp)
+	if window > 0 && len(s.History) > window {
+		s.History = s.History[len(s.History)-window:]
+	}
+
+	if s.Volume > 0 && volume > s.Volume {
+		traded := float64(volume - s.Volume)
+		s.SumPV += ltp * traded
+		s.SumVolume += traded
+	}
+	if volume > 0 {
+		s.Volume = volume
+	}
+}
+
+// UpdateRange extends the session high/low with ltp.
+func (s *MarketState) UpdateRange(ltp float64) {
+	if s.High == 0 || ltp > s.High {
+		s.High = ltp
+	}
+	if s.Low == 0 || ltp < s.Low {
+		s.Low = ltp
+	}
+}
+
+// Snapshot returns a copy that is safe to hand to strategies outside the lock.
+func (s *MarketState) Snapshot() *MarketState {
+	c := *s
+	c.History = append([]float64(nil), s.History...)
+	return &c
+}
+
+// Prev returns the LTP before the current one, or 0 if there is none.
+func (s *MarketState) Prev() float64 {
+	if len(s.History) < 2 {
+		return 0
+	}
+	return s.History[len(s.History)-2]
+}
+
+// SMA returns the simple average of the last n prices. With fewer than n
+// prices it averages what is available; with none it returns 0.
+func (s *MarketState) SMA(n int) float64 {
+	hist := s.last(n)
+	if len(hist) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, p := range hist {
+		sum += p
+	}
+	return sum / float64(len(hist))
+}
+
+// EMA returns the n-period exponential moving average over the history,
+// seeded with the oldest price (same as brain.py). With fewer than n prices
+// it falls back to SMA.
+func (s *MarketState) EMA(n int) float64 {
+	if n <= 0 || len(s.History) < n {
+		return s.SMA(n)
+	}
+	k := 2 / float64(n+1)
+	ema := s.History[0]
+	for _, p := range s.History[1:] {
+		ema = p*k + ema*(1-k)
+	}
+	return ema
+}
+
+// ATR returns the average true range over the last n tick-to-tick moves:
+// with no bars to work from, each move's range is the absolute change
+// between consecutive LTPs. With fewer than n moves it averages what is
+// available; with none it returns 0.
+func (s *MarketState) ATR(n int) float64 {
+	hist := s.last(n + 1)
+	if len(hist) < 2 {
+		return 0
+	}
+	sum := 0.0
+	for i := 1; i < len(hist); i++ {
+		sum += math.Abs(hist[i] - hist[i-1])
+	}
+	return sum / float64(len(hist)-1)
+}
+
+// Spread is the bid-ask spread as a fraction of the mid price, or 0 when
+// either side is unknown.
+func (s *MarketState) Spread() float64 {
+	if s.Bid <= 0 || s.Ask <= 0 {
+		return 0
+	}
+	return (s.Ask - s.Bid) / ((s.Ask + s.Bid) / 2)
+}
+
+// DayChange is LTP's move from the previous close as a fraction of it;
+// ok is false while the previous close is unknown.
+func (s *MarketState) DayChange() (change float64, ok bool) {
+	if s.PrevClose <= 0 || s.LTP <= 0 {
+		return 0, false
+	}
+	return (s.LTP - s.PrevClose) / s.PrevClose, true
+}
+
+// VWAP returns the intraday volume-weighted average price since the bot
+// started observing the symbol, or 0 before any volume has traded.
+func (s *MarketState) VWAP() float64 {
+	if s.SumVolume == 0 {
+		return 0
+	}
+	return s.SumPV / s.SumVolume
+}
+
+func (s *MarketState) last(n int) []float64 {
+	if n <= 0 || n > len(s.History) {
+		return s.History
+	}
+	return s.History[len(s.History)-n:]
+}
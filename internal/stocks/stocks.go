@@ -4,19 +4,22 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 )
 
 // Tickers is globally accessible list of symbols
 var Tickers []string
 
-// Load reads and validates stocks.json
-func Load(filePath string) error {
-	// Default path if empty
-	if filePath == "" {
-		filePath = filepath.Join("data", "stocks.json")
-	}
+// Disabled lists watchlist symbols that are monitored and have their open
+// positions managed, but take no new entries.
+var Disabled []string
 
+// Shortable, when non-empty, lists the only symbols that may be sold short
+// (intraday). An empty list allows shorts on every symbol.
+var Shortable []string
+
+// Load reads and validates the watchlist at filePath.
+func Load(filePath string) error {
 	// Check existence
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return fmt.Errorf("stocks file not found at: %s", filePath)
@@ -28,7 +31,9 @@ func Load(filePath string) error {
 	}
 
 	var config struct {
-		Tickers []string `json:"tickers"`
+		Tickers   []string `json:"tickers"`
+		Disabled  []string `json:"disabled"`
+		Shortable []string `json:"shortable"`
 	}
 
 	if err := json.Unmarshal(data, &config); err != nil {
@@ -39,8 +44,57 @@ func Load(filePath string) error {
 		return fmt.Errorf("no tickers found in stocks.json")
 	}
 
-	Tickers = config.Tickers
+	tickers, errs := Normalize(config.Tickers)
+	disabled, disabledErrs := Normalize(config.Disabled)
+	shortable, shortableErrs := Normalize(config.Shortable)
+	for _, err := range append(append(errs, disabledErrs...), shortableErrs...) {
+		fmt.Printf("Warning: skipping stocks.json %v\n", err)
+	}
+	if len(tickers) == 0 {
+		return fmt.Errorf("no valid tickers found in stocks.json")
+	}
+
+	Tickers = tickers
+	Disabled = disabled
+	Shortable = shortable
 	fmt.Printf("Loaded %d stocks to monitor\n", len(Tickers))
 
 	return nil
 }
+
+// Normalize trims and uppercases symbols and drops duplicates, keeping the
+// first occurrence. Entries that cannot be NSE symbols are left out and
+// reported, one error each, so a single typo does not fail the whole list.
+func Normalize(symbols []string) ([]string, []error) {
+	var out []string
+	var errs []error
+	seen := make(map[string]bool)
+	for i, raw := range symbols {
+		sym := strings.ToUpper(strings.TrimSpace(raw))
+		if err := validSymbol(sym); err != nil {
+			errs = append(errs, fmt.Errorf("entry %d %q: %v", i+1, raw, err))
+			continue
+		}
+		if seen[sym] {
+			errs = append(errs, fmt.Errorf("entry %d %q: duplicate of %s", i+1, raw, sym))
+			continue
+		}
+		seen[sym] = true
+		out = append(out, sym)
+	}
+	return out, errs
+}
+
+// validSymbol accepts NSE trading symbols: letters and digits, plus the
+// '&' and '-' some of them use (M&M, BAJAJ-AUTO).
+func validSymbol(sym string) error {
+	if sym == "" {
+		return fmt.Errorf("empty symbol")
+	}
+	for _, r := range sym {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '&' && r != '-' {
+			return fmt.Errorf("invalid character %q", r)
+		}
+	}
+	return nil
+}
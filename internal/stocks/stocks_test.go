@@ -0,0 +1,51 @@
+package stocks
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	got, errs := Normalize([]string{" sbin", "Infy ", "SBIN", "m&m", "bajaj-auto", "", "TCS.NS", "infy"})
+	if want := []string{"SBIN", "INFY", "M&M", "BAJAJ-AUTO"}; !slices.Equal(got, want) {
+		t.Errorf("Normalize = %v, want %v", got, want)
+	}
+	if len(errs) != 4 {
+		t.Fatalf("errors = %v, want one each for the SBIN and INFY duplicates, the empty entry and TCS.NS", errs)
+	}
+	for i, want := range []string{`entry 3 "SBIN": duplicate`, `entry 6 "": empty`, `entry 7 "TCS.NS": invalid character '.'`, `entry 8 "infy": duplicate of INFY`} {
+		if !strings.HasPrefix(errs[i].Error(), want) {
+			t.Errorf("error %d = %q, want prefix %q", i, errs[i], want)
+		}
+	}
+}
+
+func TestLoadNormalizesLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stocks.json")
+	body := `{"tickers": ["hdfcbank", " SBIN ", "Sbin", "bad ticker"], "disabled": ["sbin"], "shortable": [" hdfcbank"]}`
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(path); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := []string{"HDFCBANK", "SBIN"}; !slices.Equal(Tickers, want) {
+		t.Errorf("Tickers = %v, want %v", Tickers, want)
+	}
+	if !slices.Equal(Disabled, []string{"SBIN"}) || !slices.Equal(Shortable, []string{"HDFCBANK"}) {
+		t.Errorf("Disabled = %v, Shortable = %v, want them normalized too", Disabled, Shortable)
+	}
+}
+
+func TestLoadRejectsAllInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stocks.json")
+	if err := os.WriteFile(path, []byte(`{"tickers": ["  ", "a.b"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(path); err == nil {
+		t.Error("Load accepted a list with no valid tickers")
+	}
+}
@@ -0,0 +1,52 @@
+package strategy
+
+import (
+	"fmt"
+
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+// BreakoutLong buys when price clears the session high by cfg.BreakoutLong.
+// Strength is the move past the high (LTP/High - 1) over BreakoutLong.
+type BreakoutLong struct{}
+
+func (BreakoutLong) Name() string                { return "breakout_long" }
+func (BreakoutLong) Direction() models.Direction { return models.Long }
+
+func (b BreakoutLong) Evaluate(ms *state.MarketState, cfg models.StockStrategy) (models.Signal, bool) {
+	threshold := cfg.BreakoutLong
+	if ms.High > 0 && ms.LTP > ms.High*(1+threshold) {
+		return models.Signal{
+			Symbol:    ms.Symbol,
+			Direction: models.Long,
+			Price:     ms.LTP,
+			Strategy:  b.Name(),
+			Reason:    fmt.Sprintf("BREAKOUT LONG BUY %s @ %.2f (threshold %.3f)", ms.Symbol, ms.LTP, threshold),
+			Strength:  strength(ms.LTP/ms.High-1, threshold),
+		}, true
+	}
+	return models.Signal{}, false
+}
+
+// BreakdownShort sells when price breaks the session low by cfg.BreakoutShort.
+// Strength is the move below the low (1 - LTP/Low) over BreakoutShort.
+type BreakdownShort struct{}
+
+func (BreakdownShort) Name() string                { return "breakdown_short" }
+func (BreakdownShort) Direction() models.Direction { return models.Short }
+
+func (b BreakdownShort) Evaluate(ms *state.MarketState, cfg models.StockStrategy) (models.Signal, bool) {
+	threshold := cfg.BreakoutShort
+	if ms.Low > 0 && ms.LTP < ms.Low*(1-threshold) {
+		return models.Signal{
+			Symbol:    ms.Symbol,
+			Direction: models.Short,
+			Price:     ms.LTP,
+			Strategy:  b.Name(),
+			Reason:    fmt.Sprintf("BREAKDOWN SHORT SELL %s @ %.2f (threshold %.3f)", ms.Symbol, ms.LTP, threshold),
+			Strength:  strength(1-ms.LTP/ms.Low, threshold),
+		}, true
+	}
+	return models.Signal{}, false
+}
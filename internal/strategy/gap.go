@@ -0,0 +1,97 @@
+package strategy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+var ist = time.FixedZone("IST", 5*60*60+30*60)
+
+const (
+	marketOpenMinute = 9*60 + 15 // 09:15 IST
+	defaultGapWindow = 15        // minutes after the open
+)
+
+// inOpeningWindow reports whether the exchange time of ms's last quote is
+// within cfg's gap window after the open. Quotes without a feed time never
+// qualify, since the session time is then unknown.
+func inOpeningWindow(ms *state.MarketState, cfg models.StockStrategy) bool {
+	if ms.FeedTime.IsZero() {
+		return false
+	}
+	window := cfg.GapWindowMinutes
+	if window <= 0 {
+		window = defaultGapWindow
+	}
+	t := ms.FeedTime.In(ist)
+	since := float64(t.Hour()*60+t.Minute()-marketOpenMinute) + float64(t.Second())/60
+	return since >= 0 && since < window
+}
+
+// GapTag labels trades opened by the gap strategies.
+const GapTag = "gap-play"
+
+// gap returns the open's move from the previous close as a fraction.
+func gap(ms *state.MarketState) (float64, bool) {
+	if ms.PrevClose <= 0 || ms.Open <= 0 {
+		return 0, false
+	}
+	return (ms.Open - ms.PrevClose) / ms.PrevClose, true
+}
+
+// GapUp buys a gap-up open of at least cfg.GapUp that is still holding
+// (LTP at or above the open) in the first minutes of the session.
+// Strength is the gap over GapUp.
+type GapUp struct{}
+
+func (GapUp) Name() string                { return "gap_up" }
+func (GapUp) Direction() models.Direction { return models.Long }
+
+func (g GapUp) Evaluate(ms *state.MarketState, cfg models.StockStrategy) (models.Signal, bool) {
+	if cfg.GapUp <= 0 || !inOpeningWindow(ms, cfg) {
+		return models.Signal{}, false
+	}
+	pct, ok := gap(ms)
+	if !ok || pct < cfg.GapUp || ms.LTP < ms.Open {
+		return models.Signal{}, false
+	}
+	return models.Signal{
+		Symbol:    ms.Symbol,
+		Direction: models.Long,
+		Price:     ms.LTP,
+		Strategy:  g.Name(),
+		Reason:    fmt.Sprintf("GAP UP BUY %s @ %.2f (open %.2f, prev close %.2f, gap %.2f%%)", ms.Symbol, ms.LTP, ms.Open, ms.PrevClose, pct*100),
+		Strength:  strength(pct, cfg.GapUp),
+		Tags:      []string{GapTag},
+	}, true
+}
+
+// GapDown shorts a gap-down open of at least cfg.GapDown that is still
+// holding (LTP at or below the open) in the first minutes of the session.
+// Strength is the gap's size over GapDown.
+type GapDown struct{}
+
+func (GapDown) Name() string                { return "gap_down" }
+func (GapDown) Direction() models.Direction { return models.Short }
+
+func (g GapDown) Evaluate(ms *state.MarketState, cfg models.StockStrategy) (models.Signal, bool) {
+	if cfg.GapDown <= 0 || !inOpeningWindow(ms, cfg) {
+		return models.Signal{}, false
+	}
+	pct, ok := gap(ms)
+	if !ok || -pct < cfg.GapDown || ms.LTP > ms.Open {
+		return models.Signal{}, false
+	}
+	return models.Signal{
+		Symbol:    ms.Symbol,
+		Direction: models.Short,
+		Price:     ms.LTP,
+		Strategy:  g.Name(),
+		Reason:    fmt.Sprintf("GAP DOWN SHORT SELL %s @ %.2f (open %.2f, prev close %.2f, gap %.2f%%)", ms.Symbol, ms.LTP, ms.Open, ms.PrevClose, pct*100),
+		Strength:  strength(-pct, cfg.GapDown),
+		Tags:      []string{GapTag},
+	}, true
+}
@@ -0,0 +1,50 @@
+package strategy
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+func TestGapStrategies(t *testing.T) {
+	at := func(hh, mm int) time.Time { return time.Date(2024, 3, 4, hh, mm, 0, 0, ist) }
+	cfg := models.StockStrategy{GapUp: 0.02, GapDown: 0.02}
+
+	tests := []struct {
+		name     string
+		ms       state.MarketState
+		cfg      models.StockStrategy
+		wantUp   bool
+		wantDown bool
+	}{
+		{"gap up holding", state.MarketState{Open: 103, PrevClose: 100, LTP: 104, FeedTime: at(9, 16)}, cfg, true, false},
+		{"gap up faded below open", state.MarketState{Open: 103, PrevClose: 100, LTP: 102.5, FeedTime: at(9, 16)}, cfg, false, false},
+		{"gap too small", state.MarketState{Open: 101, PrevClose: 100, LTP: 101.5, FeedTime: at(9, 16)}, cfg, false, false},
+		{"gap down holding", state.MarketState{Open: 97, PrevClose: 100, LTP: 96.5, FeedTime: at(9, 20)}, cfg, false, true},
+		{"after the window", state.MarketState{Open: 103, PrevClose: 100, LTP: 104, FeedTime: at(9, 30)}, cfg, false, false},
+		{"custom window", state.MarketState{Open: 103, PrevClose: 100, LTP: 104, FeedTime: at(9, 40)},
+			models.StockStrategy{GapUp: 0.02, GapWindowMinutes: 30}, true, false},
+		{"before the open", state.MarketState{Open: 103, PrevClose: 100, LTP: 104, FeedTime: at(9, 10)}, cfg, false, false},
+		{"no feed time", state.MarketState{Open: 103, PrevClose: 100, LTP: 104}, cfg, false, false},
+		{"no previous close", state.MarketState{Open: 103, LTP: 104, FeedTime: at(9, 16)}, cfg, false, false},
+		{"disabled", state.MarketState{Open: 103, PrevClose: 100, LTP: 104, FeedTime: at(9, 16)}, models.StockStrategy{}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.ms.Symbol = "TEST"
+			sig, got := (GapUp{}).Evaluate(&tt.ms, tt.cfg)
+			if got != tt.wantUp {
+				t.Errorf("GapUp fired = %v, want %v", got, tt.wantUp)
+			}
+			if got && !slices.Equal(sig.Tags, []string{GapTag}) {
+				t.Errorf("GapUp tags = %v, want [%s]", sig.Tags, GapTag)
+			}
+			if _, got := (GapDown{}).Evaluate(&tt.ms, tt.cfg); got != tt.wantDown {
+				t.Errorf("GapDown fired = %v, want %v", got, tt.wantDown)
+			}
+		})
+	}
+}
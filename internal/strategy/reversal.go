@@ -0,0 +1,67 @@
+package strategy
+
+import (
+	"fmt"
+
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+// BounceBack buys a sharp rebound off the session low: the previous tick sat
+// within 0.5% of the low and the current tick is cfg.BounceRebound above it.
+// Strength is the rebound (LTP/prev - 1) over BounceRebound.
+type BounceBack struct{}
+
+func (BounceBack) Name() string                { return "bounce_back" }
+func (BounceBack) Direction() models.Direction { return models.Long }
+
+func (b BounceBack) Evaluate(ms *state.MarketState, cfg models.StockStrategy) (models.Signal, bool) {
+	if len(ms.History) < 2 {
+		return models.Signal{}, false
+	}
+
+	prev := ms.Prev()
+	if prev <= ms.Low*1.005 && ms.LTP >= prev*(1+cfg.BounceRebound) {
+		return models.Signal{
+			Symbol:    ms.Symbol,
+			Direction: models.Long,
+			Price:     ms.LTP,
+			Strategy:  b.Name(),
+			Reason:    fmt.Sprintf("BOUNCE BACK BUY %s @ %.2f (prev %.2f, low %.2f)", ms.Symbol, ms.LTP, prev, ms.Low),
+			Strength:  strength(ms.LTP/prev-1, cfg.BounceRebound),
+		}, true
+	}
+	return models.Signal{}, false
+}
+
+// QuickDrop shorts a single-tick fall of at least cfg.QuickDrop. Strength
+// is the fall over QuickDrop.
+type QuickDrop struct{}
+
+func (QuickDrop) Name() string                { return "quick_drop" }
+func (QuickDrop) Direction() models.Direction { return models.Short }
+
+func (q QuickDrop) Evaluate(ms *state.MarketState, cfg models.StockStrategy) (models.Signal, bool) {
+	if len(ms.History) < 2 {
+		return models.Signal{}, false
+	}
+
+	// A zero or negative price can only come from a bad quote; dividing
+	// by it would yield Inf/NaN and could fire a bogus short.
+	prev := ms.Prev()
+	if prev <= 0 {
+		return models.Signal{}, false
+	}
+	drop := (prev - ms.LTP) / prev
+	if drop >= cfg.QuickDrop {
+		return models.Signal{
+			Symbol:    ms.Symbol,
+			Direction: models.Short,
+			Price:     ms.LTP,
+			Strategy:  q.Name(),
+			Reason:    fmt.Sprintf("QUICK DROP SHORT SELL %s @ %.2f (drop %.2f%%)", ms.Symbol, ms.LTP, drop*100),
+			Strength:  strength(drop, cfg.QuickDrop),
+		}, true
+	}
+	return models.Signal{}, false
+}
@@ -0,0 +1,71 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+func TestQuickDrop(t *testing.T) {
+	cfg := models.StockStrategy{QuickDrop: 0.012}
+
+	tests := []struct {
+		name    string
+		history []float64 // oldest first; the last entry is the LTP
+		want    bool
+	}{
+		{"drop above threshold", []float64{100, 98.5}, true},
+		{"drop at threshold", []float64{100, 98.8}, true},
+		{"drop below threshold", []float64{100, 99}, false},
+		{"price spike", []float64{100, 105}, false},
+		{"flat", []float64{100, 100}, false},
+		{"zero prev", []float64{0, 98}, false},
+		{"negative prev", []float64{-5, 98}, false},
+		{"single tick", []float64{98}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &state.MarketState{
+				Symbol:  "TEST",
+				LTP:     tt.history[len(tt.history)-1],
+				History: tt.history,
+			}
+			sig, ok := QuickDrop{}.Evaluate(ms, cfg)
+			if ok != tt.want {
+				t.Fatalf("fired = %v, want %v", ok, tt.want)
+			}
+			if ok && (sig.Direction != models.Short || sig.Price != ms.LTP) {
+				t.Errorf("signal = %+v", sig)
+			}
+		})
+	}
+}
+
+func TestSignalStrength(t *testing.T) {
+	cfg := models.StockStrategy{BreakoutLong: 0.002, BreakoutShort: 0.002, QuickDrop: 0.01}
+	tests := []struct {
+		name string
+		s    Strategy
+		cfg  models.StockStrategy
+		ms   *state.MarketState
+		want float64
+	}{
+		{"breakout 0.4% over 0.2%", BreakoutLong{}, cfg, &state.MarketState{LTP: 100.4, High: 100}, 2},
+		{"breakdown 0.3% over 0.2%", BreakdownShort{}, cfg, &state.MarketState{LTP: 99.7, Low: 100}, 1.5},
+		{"quick drop 2% over 1%", QuickDrop{}, cfg, &state.MarketState{LTP: 98, History: []float64{100, 98}}, 2},
+		{"no threshold scores the move", BreakoutLong{}, models.StockStrategy{}, &state.MarketState{LTP: 100.5, High: 100}, 1.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, ok := tt.s.Evaluate(tt.ms, tt.cfg)
+			if !ok {
+				t.Fatal("did not fire")
+			}
+			if math.Abs(sig.Strength-tt.want) > 1e-6 {
+				t.Errorf("strength = %v, want %v", sig.Strength, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package strategy
+
+import (
+	"github.com/may-bach/Axiom/internal/models"
+	"github.com/may-bach/Axiom/internal/state"
+)
+
+// Strategy is an entry rule evaluated against a symbol's market state on
+// every tick.
+type Strategy interface {
+	Name() string
+	Direction() models.Direction
+	Evaluate(ms *state.MarketState, cfg models.StockStrategy) (models.Signal, bool)
+}
+
+// strength scores a trigger move against the threshold it had to clear,
+// as move/threshold. With no threshold any move fires, so the move is
+// scored in percent on top of a base of 1.
+func strength(move, threshold float64) float64 {
+	if threshold > 0 {
+		return move / threshold
+	}
+	return 1 + move*100
+}